@@ -2,22 +2,34 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	"ru/mvideo/com/gitlab/token-exporter/internal/config"
 	"ru/mvideo/com/gitlab/token-exporter/internal/gitlab"
 	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+	"ru/mvideo/com/gitlab/token-exporter/internal/policy"
+	"ru/mvideo/com/gitlab/token-exporter/internal/reload"
+	"ru/mvideo/com/gitlab/token-exporter/internal/rotation"
 	"ru/mvideo/com/gitlab/token-exporter/internal/scraper"
+	"ru/mvideo/com/gitlab/token-exporter/internal/secret"
 )
 
 func main() {
-	cfg, err := config.Load()
+	configPath := flag.String("config", "", "path to YAML or JSON configuration file (environment variables override its values)")
+	watchInterval := flag.Duration("config-watch-interval", 30*time.Second, "how often the configuration file is checked for changes (0 - disabled)")
+	flag.Parse()
+
+	cfg, err := config.LoadFile(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
@@ -34,24 +46,149 @@ func main() {
 		cancel()
 	}()
 
-	gitlabClient, err := gitlab.NewClient(cfg.Gitlab.Token, cfg.Gitlab.BaseURL)
+	// Все инстансы GitLab отдают метрики через общий реестр
+	registry := metrics.NewRegistry()
+	configReloads := metrics.NewConfigReloads(registry)
+
+	tokenPolicy, err := loadPolicy(cfg.Scraper.PolicyFile)
+	if err != nil {
+		log.Fatalf("Failed to load token policy: %v", err)
+	}
+	rotationConfig, err := loadRotation(cfg.Rotation.RulesFile)
 	if err != nil {
-		log.Fatalf("Failed to create GitLab client: %v", err)
+		log.Fatalf("Failed to load token rotation rules: %v", err)
 	}
+	if rotationConfig != nil && cfg.Rotation.DryRun {
+		log.Println("Token rotation runs in dry-run mode, set ROTATION_DRY_RUN=false to rotate tokens")
+	}
+
+	// По одному скрейперу на инстанс; ключ - имя инстанса (пустое без списка instances)
+	scrapers := make(map[string]*instanceScraper)
+	var scrapersWG sync.WaitGroup
+	for _, instance := range cfg.GitLabInstances() {
+		// Метрики проб строятся с теми же опциями, но в собственном реестре
+		handlerOptions := []metrics.Option{
+			metrics.WithLegacyNameLabel(cfg.Metrics.LegacyNameLabel),
+			metrics.WithLegacyExpiresAt(cfg.Metrics.LegacyExpiresAt),
+		}
+		if instance.Name != "" {
+			handlerOptions = append(handlerOptions, metrics.WithInstance(instance.Name))
+		}
+		metricsHandler := metrics.NewHandler(append(handlerOptions, metrics.WithRegistry(registry))...)
 
-	metricsHandler := metrics.NewHandler()
-	tokenScraper := scraper.NewTokenScraper(gitlabClient, metricsHandler, []int(cfg.Gitlab.ProjectIDs), []int(cfg.Gitlab.GroupIDs))
+		apiClient, err := newAPIClient(ctx, cfg, instance, metricsHandler)
+		if err != nil {
+			log.Fatalf("Failed to create GitLab client for %s: %v", instance.BaseURL, err)
+		}
+		// Без нужных прав токена мониторинг молча не собирал бы данные, поэтому запуск прерывается
+		if err := scraper.VerifyCredential(ctx, apiClient, rotates(cfg)); err != nil {
+			log.Fatalf("Failed to verify GitLab token for %s: %v", instance.BaseURL, err)
+		}
 
+		tokenScraper := scraper.NewTokenScraper(
+			apiClient,
+			metricsHandler,
+			[]int(instance.ProjectIDs),
+			[]int(instance.GroupIDs),
+			scraperOptions(cfg, instance, tokenPolicy, newRotator(cfg, instance, rotationConfig))...,
+		)
+		scrapers[instance.Name] = &instanceScraper{
+			scraper: tokenScraper,
+			metrics: metricsHandler,
+			probe:   tokenScraper.ProbeHandler(cfg.Scraper.ProbeTimeout, handlerOptions...),
+		}
+
+		if instance.Name != "" {
+			log.Printf("Monitoring GitLab instance %q at %s", instance.Name, instance.BaseURL)
+		}
+		scrapersWG.Add(1)
+		go func() {
+			defer scrapersWG.Done()
+			tokenScraper.Start(ctx, instance.Interval)
+		}()
+	}
+
+	scraperDone := make(chan struct{})
 	go func() {
-		tokenScraper.Start(ctx, cfg.Scraper.Interval)
+		scrapersWG.Wait()
+		close(scraperDone)
 	}()
 
+	// Перезагрузка заменяет цели, интервал, клиент GitLab, политику и правила ротации каждого
+	// инстанса; остальные настройки (порт, обнаружение, набор собираемых ресурсов, список
+	// инстансов) применяются только после перезапуска
+	reloader := reload.New(func() error {
+		newCfg, err := config.LoadFile(*configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		newPolicy, err := loadPolicy(newCfg.Scraper.PolicyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load token policy: %w", err)
+		}
+		newRotationConfig, err := loadRotation(newCfg.Rotation.RulesFile)
+		if err != nil {
+			return fmt.Errorf("failed to load token rotation rules: %w", err)
+		}
+
+		// Сначала создаем все клиенты, чтобы ошибка не оставила инстансы с разными версиями конфигурации
+		instances := newCfg.GitLabInstances()
+		if len(instances) != len(scrapers) {
+			return fmt.Errorf("list of gitlab instances changed, restart is required")
+		}
+		reloads := make(map[string]scraper.ReloadConfig, len(instances))
+		for _, instance := range instances {
+			running, ok := scrapers[instance.Name]
+			if !ok {
+				return fmt.Errorf("list of gitlab instances changed, restart is required")
+			}
+			newClient, err := newAPIClient(ctx, newCfg, instance, running.metrics)
+			if err != nil {
+				return fmt.Errorf("failed to create GitLab client for %s: %w", instance.BaseURL, err)
+			}
+			if err := scraper.VerifyCredential(ctx, newClient, rotates(newCfg)); err != nil {
+				return fmt.Errorf("failed to verify GitLab token for %s: %w", instance.BaseURL, err)
+			}
+			reloads[instance.Name] = scraper.ReloadConfig{
+				Client:     newClient,
+				ProjectIDs: instance.ProjectIDs,
+				GroupIDs:   instance.GroupIDs,
+				Interval:   instance.Interval,
+				Policy:     newPolicy,
+				Rotator:    newRotator(newCfg, instance, newRotationConfig),
+			}
+		}
+
+		for name, reloadConfig := range reloads {
+			scrapers[name].scraper.Reload(reloadConfig)
+		}
+		return nil
+	}, reload.WithObserver(configReloads), reload.WithFileWatch(*configPath, *watchInterval))
+	go reloader.Run(ctx)
+
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", metricsHandler.Handler())
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
+	mux.Handle("/-/reload", reloader.Handler())
+	mux.HandleFunc("/probe", func(w http.ResponseWriter, r *http.Request) {
+		// С несколькими инстансами GitLab инстанс цели выбирается параметром instance
+		name := r.URL.Query().Get("instance")
+		if len(scrapers) == 1 && name == "" {
+			for _, running := range scrapers {
+				running.probe.ServeHTTP(w, r)
+			}
+			return
+		}
+		running, ok := scrapers[name]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown gitlab instance %q", name), http.StatusBadRequest)
+			return
+		}
+		running.probe.ServeHTTP(w, r)
+	})
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
@@ -74,5 +211,156 @@ func main() {
 		log.Printf("HTTP server shutdown error: %v", err)
 	}
 
+	// Отмена контекста прерывает текущие запросы к GitLab, дожидаемся остановки скрейпера
+	select {
+	case <-scraperDone:
+	case <-shutdownCtx.Done():
+		log.Println("Token scraper did not stop before shutdown timeout")
+	}
+
 	log.Println("Server stopped gracefully")
 }
+
+// instanceScraper - скрейпер инстанса GitLab, его метрики и обработчик /probe
+type instanceScraper struct {
+	scraper *scraper.TokenScraper
+	metrics *metrics.Handler
+	probe   http.Handler
+}
+
+// scraperOptions возвращает настройки скрейпера инстанса
+func scraperOptions(cfg *config.Config, instance config.Instance, tokenPolicy *policy.Policy, rotator *rotation.Rotator) []scraper.Option {
+	options := []scraper.Option{
+		scraper.WithNeverExpiresPolicy(scraper.NeverExpiresPolicy(cfg.Scraper.NeverExpiresPolicy)),
+		scraper.WithConcurrency(cfg.Scraper.Concurrency),
+		scraper.WithScrapeTimeout(cfg.Scraper.Timeout),
+		scraper.WithDeployTokens(cfg.Scraper.DeployTokens),
+		scraper.WithPipelines(cfg.Scraper.Pipelines),
+		scraper.WithKeys(cfg.Scraper.Keys),
+		scraper.WithRunners(cfg.Scraper.Runners, cfg.Scraper.RunnerTokenWarning),
+		scraper.WithUnusedThreshold(time.Duration(cfg.Scraper.UnusedTokenDays) * 24 * time.Hour),
+	}
+	if cfg.Discovery.Enabled || instance.AdminMode {
+		options = append(options, scraper.WithDiscovery(scraper.DiscoveryConfig{
+			IncludeSubgroups: cfg.Discovery.IncludeSubgroups,
+			SkipArchived:     cfg.Discovery.SkipArchived,
+			Interval:         cfg.Discovery.Interval,
+		}))
+	}
+	if instance.AdminMode {
+		options = append(options, scraper.WithAdminMode())
+	}
+	if tokenPolicy != nil {
+		options = append(options, scraper.WithPolicy(tokenPolicy))
+	}
+	if rotator != nil {
+		options = append(options, scraper.WithRotator(rotator))
+	}
+	return options
+}
+
+// newRotator создает ротатор токенов инстанса (nil - ротация выключена). Состояние
+// повторов у каждого инстанса свое, поэтому ротатор не разделяется между ними.
+func newRotator(cfg *config.Config, instance config.Instance, rotationConfig *rotation.Config) *rotation.Rotator {
+	if rotationConfig == nil {
+		return nil
+	}
+	return rotation.NewRotator(rotationConfig,
+		rotation.WithDryRun(cfg.Rotation.DryRun),
+		rotation.WithMaxPerRun(cfg.Rotation.MaxPerRun),
+		rotation.WithRetryInterval(cfg.Rotation.RetryInterval),
+		rotation.WithInstance(instance.Name),
+	)
+}
+
+// rotates сообщает, ротирует ли экспортер токены, для чего его токену нужен scope api
+func rotates(cfg *config.Config) bool {
+	return cfg.Rotation.RulesFile != "" && !cfg.Rotation.DryRun
+}
+
+// newAPIClient создает клиент инстанса GitLab с общими настройками клиента из конфигурации
+func newAPIClient(ctx context.Context, cfg *config.Config, instance config.Instance, metricsHandler *metrics.Handler) (gitlab.GitLabClientInterface, error) {
+	clientOptions := []gitlab.Option{
+		gitlab.WithPerPage(cfg.Gitlab.PerPage),
+		gitlab.WithMaxPages(cfg.Gitlab.MaxPages),
+		gitlab.WithRequestTimeout(cfg.Gitlab.RequestTimeout),
+		gitlab.WithRetry(cfg.Gitlab.MaxRetries, cfg.Gitlab.RetryWaitMin, cfg.Gitlab.RetryWaitMax),
+		gitlab.WithRateLimit(cfg.Gitlab.RateLimit, cfg.Gitlab.RateLimitBurst),
+		gitlab.WithObserver(metricsHandler),
+	}
+
+	if tokenSource := newTokenSource(cfg, instance); tokenSource != nil {
+		// Проверяем источник при запуске, чтобы ошибка настройки не проявилась только при первом scrape
+		if _, err := tokenSource.Secret(ctx); err != nil {
+			return nil, fmt.Errorf("failed to read gitlab token: %w", err)
+		}
+		clientOptions = append(clientOptions, gitlab.WithTokenSource(tokenSource))
+	}
+
+	gitlabClient, err := gitlab.NewClient(instance.Token, instance.BaseURL, clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Gitlab.NameCacheTTL <= 0 {
+		return gitlabClient, nil
+	}
+	return gitlab.NewCachedClient(
+		gitlabClient,
+		cfg.Gitlab.NameCacheTTL,
+		gitlab.WithNegativeTTL(cfg.Gitlab.NameCacheNegativeTTL),
+		gitlab.WithCacheObserver(metricsHandler),
+	), nil
+}
+
+// newTokenSource возвращает источник токена инстанса из файла или Vault (nil - токен задан напрямую)
+func newTokenSource(cfg *config.Config, instance config.Instance) secret.Source {
+	switch {
+	case instance.TokenFile != "":
+		return secret.NewFile(instance.TokenFile)
+	case instance.VaultSecretPath != "":
+		var vaultToken secret.Source = secret.Static(cfg.Vault.Token)
+		if cfg.Vault.TokenFile != "" {
+			vaultToken = secret.NewFile(cfg.Vault.TokenFile)
+		}
+		return secret.NewVault(
+			cfg.Vault.Address,
+			vaultToken,
+			cfg.Vault.Mount,
+			instance.VaultSecretPath,
+			cfg.Vault.SecretKey,
+			secret.WithKVVersion(cfg.Vault.KVVersion),
+			secret.WithRefreshInterval(cfg.Vault.RefreshInterval),
+		)
+	default:
+		return nil
+	}
+}
+
+// loadPolicy загружает политику токенов, если задан путь к файлу
+func loadPolicy(path string) (*policy.Policy, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	tokenPolicy, err := policy.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Loaded %d token policy rules from %s", len(tokenPolicy.Rules), path)
+	return tokenPolicy, nil
+}
+
+// loadRotation загружает правила ротации токенов, если задан путь к файлу
+func loadRotation(path string) (*rotation.Config, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	rotationConfig, err := rotation.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Loaded %d token rotation rules and %d sinks from %s", len(rotationConfig.Rules), len(rotationConfig.Sinks), path)
+	return rotationConfig, nil
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -17,7 +19,10 @@ type GroupIDsSlice []int
 
 func (p *ProjectIDsSlice) Decode(value string) error {
 	if value == "" {
-		return fmt.Errorf("GITLAB_PROJECT_IDS is empty")
+		// Проекты не обязательны при обнаружении и в режиме администратора,
+		// их наличие проверяет validateGitlab
+		*p = []int{}
+		return nil
 	}
 
 	var projectIDs []int
@@ -37,10 +42,6 @@ func (p *ProjectIDsSlice) Decode(value string) error {
 		projectIDs = append(projectIDs, id)
 	}
 
-	if len(projectIDs) == 0 {
-		return fmt.Errorf("no valid project IDs found in GITLAB_PROJECT_IDS")
-	}
-
 	*p = projectIDs
 	return nil
 }
@@ -73,26 +74,364 @@ func (g *GroupIDsSlice) Decode(value string) error {
 	return nil
 }
 
+// Instance - инстанс GitLab в режиме мониторинга нескольких инстансов.
+// Настройки клиента, скрейпера и обнаружения берутся из общих секций конфигурации.
+type Instance struct {
+	// Name - значение метки instance у всех метрик инстанса
+	Name      string `yaml:"name"`
+	BaseURL   string `yaml:"base_url"`
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"token_file"`
+	// VaultSecretPath - путь к секрету с токеном; адрес и токен Vault берутся из секции vault
+	VaultSecretPath string          `yaml:"vault_secret_path"`
+	ProjectIDs      ProjectIDsSlice `yaml:"project_ids"`
+	GroupIDs        GroupIDsSlice   `yaml:"group_ids"`
+	AdminMode       bool            `yaml:"admin_mode"`
+	// Interval - период сбора токенов инстанса (0 - scraper.interval)
+	Interval time.Duration `yaml:"interval"`
+}
+
 type Config struct {
-	Server struct {
-		Port int `envconfig:"SERVER_PORT" default:"8080"`
-	} `envconfig:"SERVER"`
+	// Instances - инстансы GitLab (только в файле конфигурации). Если список пуст,
+	// отслеживается один инстанс из секции gitlab, а метрики не получают метку instance.
+	Instances []Instance `ignored:"true" yaml:"instances"`
+	Server    struct {
+		Port int `envconfig:"SERVER_PORT" default:"8080" yaml:"port"`
+	} `envconfig:"SERVER" yaml:"server"`
 	Gitlab struct {
-		Token      string          `envconfig:"GITLAB_TOKEN" required:"true"`
-		BaseURL    string          `envconfig:"GITLAB_BASE_URL" required:"true"`
-		ProjectIDs ProjectIDsSlice `envconfig:"GITLAB_PROJECT_IDS" required:"true"`
-		GroupIDs   GroupIDsSlice   `envconfig:"GITLAB_GROUP_IDS"`
-	} `envconfig:"GITLAB"`
+		Token string `envconfig:"GITLAB_TOKEN" yaml:"token"`
+		// TokenFile - файл с токеном, перечитывается при изменении (например, смонтированный Kubernetes-секрет)
+		TokenFile  string          `envconfig:"GITLAB_TOKEN_FILE" yaml:"token_file"`
+		BaseURL    string          `envconfig:"GITLAB_BASE_URL" yaml:"base_url"`
+		ProjectIDs ProjectIDsSlice `envconfig:"GITLAB_PROJECT_IDS" yaml:"project_ids"`
+		GroupIDs   GroupIDsSlice   `envconfig:"GITLAB_GROUP_IDS" yaml:"group_ids"`
+		PerPage    int             `envconfig:"GITLAB_PER_PAGE" default:"100" yaml:"per_page"`
+		MaxPages   int             `envconfig:"GITLAB_MAX_PAGES" default:"0" yaml:"max_pages"`
+		// RequestTimeout - таймаут одного запроса к API (0 - без ограничений)
+		RequestTimeout time.Duration `envconfig:"GITLAB_REQUEST_TIMEOUT" default:"30s" yaml:"request_timeout"`
+		// MaxRetries - количество повторов при 429, 5xx и сетевых ошибках
+		MaxRetries   int           `envconfig:"GITLAB_MAX_RETRIES" default:"5" yaml:"max_retries"`
+		RetryWaitMin time.Duration `envconfig:"GITLAB_RETRY_WAIT_MIN" default:"1s" yaml:"retry_wait_min"`
+		RetryWaitMax time.Duration `envconfig:"GITLAB_RETRY_WAIT_MAX" default:"30s" yaml:"retry_wait_max"`
+		// RateLimit - ограничение запросов в секунду на стороне клиента (0 - без ограничений)
+		RateLimit      float64 `envconfig:"GITLAB_RATE_LIMIT" default:"0" yaml:"rate_limit"`
+		RateLimitBurst int     `envconfig:"GITLAB_RATE_LIMIT_BURST" default:"10" yaml:"rate_limit_burst"`
+		// NameCacheTTL - время хранения имен проектов, групп и пользователей (0 - без кэша)
+		NameCacheTTL time.Duration `envconfig:"GITLAB_NAME_CACHE_TTL" default:"1h" yaml:"name_cache_ttl"`
+		// NameCacheNegativeTTL - время хранения ответов 404 при запросе имен
+		NameCacheNegativeTTL time.Duration `envconfig:"GITLAB_NAME_CACHE_NEGATIVE_TTL" default:"5m" yaml:"name_cache_negative_ttl"`
+		// AdminMode - собирать все токены инстанса (требуется токен администратора)
+		AdminMode bool `envconfig:"GITLAB_ADMIN_MODE" default:"false" yaml:"admin_mode"`
+	} `envconfig:"GITLAB" yaml:"gitlab"`
 	Scraper struct {
-		Interval time.Duration `envconfig:"SCRAPER_INTERVAL" default:"10s"`
-	} `envconfig:"SCRAPER"`
+		Interval           time.Duration `envconfig:"SCRAPER_INTERVAL" default:"10s" yaml:"interval"`
+		NeverExpiresPolicy string        `envconfig:"SCRAPER_NEVER_EXPIRES_POLICY" default:"allow" yaml:"never_expires_policy"`
+		Concurrency        int           `envconfig:"SCRAPER_CONCURRENCY" default:"4" yaml:"concurrency"`
+		// Timeout - ограничение времени одного прохода скрейпера (0 - без ограничений)
+		Timeout time.Duration `envconfig:"SCRAPER_TIMEOUT" default:"5m" yaml:"timeout"`
+		// DeployTokens - собирать deploy-токены проектов и групп
+		DeployTokens bool `envconfig:"SCRAPER_DEPLOY_TOKENS" default:"true" yaml:"deploy_tokens"`
+		// Pipelines - собирать триггеры и расписания пайплайнов проектов
+		Pipelines bool `envconfig:"SCRAPER_PIPELINES" default:"true" yaml:"pipelines"`
+		// Keys - собирать deploy-ключи, а в режиме администратора - SSH и GPG-ключи пользователей
+		Keys bool `envconfig:"SCRAPER_KEYS" default:"true" yaml:"keys"`
+		// Runners - собирать раннеры проектов и групп, а в режиме администратора - всего инстанса
+		Runners bool `envconfig:"SCRAPER_RUNNERS" default:"true" yaml:"runners"`
+		// RunnerTokenWarning - окно предупреждения об истечении токена раннера
+		RunnerTokenWarning time.Duration `envconfig:"SCRAPER_RUNNER_TOKEN_WARNING" default:"336h" yaml:"runner_token_warning"`
+		// UnusedTokenDays - токен, не использовавшийся столько дней, отмечается как неиспользуемый (0 - выключено)
+		UnusedTokenDays int `envconfig:"SCRAPER_UNUSED_TOKEN_DAYS" default:"0" yaml:"unused_token_days"`
+		// PolicyFile - путь к YAML-файлу с правилами гигиены токенов (пусто - политика выключена)
+		PolicyFile string `envconfig:"SCRAPER_POLICY_FILE" yaml:"policy_file"`
+		// ProbeTimeout - ограничение времени одной пробы /probe (0 - только таймаут scrape Prometheus)
+		ProbeTimeout time.Duration `envconfig:"SCRAPER_PROBE_TIMEOUT" default:"30s" yaml:"probe_timeout"`
+	} `envconfig:"SCRAPER" yaml:"scraper"`
+	Discovery struct {
+		// Enabled - обнаруживать проекты и подгруппы в группах из GITLAB_GROUP_IDS
+		Enabled          bool          `envconfig:"DISCOVERY_ENABLED" default:"false" yaml:"enabled"`
+		IncludeSubgroups bool          `envconfig:"DISCOVERY_INCLUDE_SUBGROUPS" default:"true" yaml:"include_subgroups"`
+		SkipArchived     bool          `envconfig:"DISCOVERY_SKIP_ARCHIVED" default:"true" yaml:"skip_archived"`
+		Interval         time.Duration `envconfig:"DISCOVERY_INTERVAL" default:"10m" yaml:"interval"`
+	} `envconfig:"DISCOVERY" yaml:"discovery"`
+	// Rotation - автоматическая ротация истекающих токенов проектов и групп (включается заданием RulesFile)
+	Rotation struct {
+		// RulesFile - путь к YAML-файлу с правилами ротации и приемниками новых секретов
+		RulesFile string `envconfig:"ROTATION_RULES_FILE" yaml:"rules_file"`
+		// DryRun - только выводить в лог токены, которые были бы ротированы
+		DryRun bool `envconfig:"ROTATION_DRY_RUN" default:"true" yaml:"dry_run"`
+		// MaxPerRun - не больше стольких ротаций за один проход скрейпера
+		MaxPerRun int `envconfig:"ROTATION_MAX_PER_RUN" default:"1" yaml:"max_per_run"`
+		// RetryInterval - пауза перед повтором неудачной ротации токена
+		RetryInterval time.Duration `envconfig:"ROTATION_RETRY_INTERVAL" default:"1h" yaml:"retry_interval"`
+	} `envconfig:"ROTATION" yaml:"rotation"`
+	// Vault - чтение токена GitLab из движка KV HashiCorp Vault (включается заданием SecretPath)
+	Vault struct {
+		Address string `envconfig:"VAULT_ADDR" yaml:"address"`
+		// Token и TokenFile - токен Vault напрямую или из файла (например, от Vault Agent)
+		Token      string `envconfig:"VAULT_TOKEN" yaml:"token"`
+		TokenFile  string `envconfig:"VAULT_TOKEN_FILE" yaml:"token_file"`
+		Mount      string `envconfig:"VAULT_MOUNT" default:"secret" yaml:"mount"`
+		SecretPath string `envconfig:"VAULT_SECRET_PATH" yaml:"secret_path"`
+		SecretKey  string `envconfig:"VAULT_SECRET_KEY" default:"token" yaml:"secret_key"`
+		KVVersion  int    `envconfig:"VAULT_KV_VERSION" default:"2" yaml:"kv_version"`
+		// RefreshInterval - как часто секрет перечитывается из Vault
+		RefreshInterval time.Duration `envconfig:"VAULT_REFRESH_INTERVAL" default:"5m" yaml:"refresh_interval"`
+	} `envconfig:"VAULT" yaml:"vault"`
+	Metrics struct {
+		// LegacyNameLabel - режим совместимости: дополнительно экспортировать метку "name"
+		LegacyNameLabel bool `envconfig:"METRICS_LEGACY_NAME_LABEL" default:"true" yaml:"legacy_name_label"`
+		// LegacyExpiresAt - экспортировать устаревшие метрики *_expires_at в часах
+		LegacyExpiresAt bool `envconfig:"METRICS_LEGACY_EXPIRES_AT" default:"true" yaml:"legacy_expires_at"`
+	} `envconfig:"METRICS" yaml:"metrics"`
 }
 
+// Load загружает конфигурацию из переменных окружения
 func Load() (*Config, error) {
-	var cfg Config
-	if err := envconfig.Process("", &cfg); err != nil {
+	return LoadFile("")
+}
+
+// LoadFile загружает конфигурацию из YAML- или JSON-файла path (пусто - без файла).
+// Значения из файла заменяют значения по умолчанию, переменные окружения имеют приоритет над файлом.
+func LoadFile(path string) (*Config, error) {
+	var env Config
+	if err := envconfig.Process("", &env); err != nil {
 		return nil, fmt.Errorf("failed to process environment variables: %w", err)
 	}
 
+	cfg := env
+	if path != "" {
+		if err := decodeFile(path, &cfg); err != nil {
+			return nil, err
+		}
+		overrideFromEnv(reflect.ValueOf(&cfg).Elem(), reflect.ValueOf(env))
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 	return &cfg, nil
 }
+
+// validate проверяет конфигурацию и возвращает все найденные ошибки, а не только первую
+func (cfg *Config) validate() error {
+	var errs []error
+
+	if len(cfg.Instances) == 0 {
+		errs = append(errs, cfg.validateGitlab()...)
+	} else {
+		errs = append(errs, cfg.validateInstances()...)
+	}
+	if cfg.usesVault() {
+		if cfg.Vault.Address == "" {
+			errs = append(errs, fmt.Errorf("vault.address (VAULT_ADDR) is required when the gitlab token is read from Vault"))
+		}
+		if countSet(cfg.Vault.Token, cfg.Vault.TokenFile) != 1 {
+			errs = append(errs, fmt.Errorf("exactly one of vault.token (VAULT_TOKEN) or vault.token_file (VAULT_TOKEN_FILE) is required when the gitlab token is read from Vault"))
+		}
+		if cfg.Vault.SecretKey == "" {
+			errs = append(errs, fmt.Errorf("vault.secret_key (VAULT_SECRET_KEY) must not be empty"))
+		}
+		if cfg.Vault.KVVersion != 1 && cfg.Vault.KVVersion != 2 {
+			errs = append(errs, fmt.Errorf("vault.kv_version (VAULT_KV_VERSION) must be 1 or 2, got %d", cfg.Vault.KVVersion))
+		}
+		if cfg.Vault.RefreshInterval <= 0 {
+			errs = append(errs, fmt.Errorf("vault.refresh_interval (VAULT_REFRESH_INTERVAL) must be positive, got %s", cfg.Vault.RefreshInterval))
+		}
+	}
+	if cfg.Gitlab.AdminMode || cfg.Discovery.Enabled || cfg.anyInstanceAdminMode() {
+		// Обнаружение включается явно или режимом администратора
+		if cfg.Discovery.Interval <= 0 {
+			errs = append(errs, fmt.Errorf("discovery.interval (DISCOVERY_INTERVAL) must be positive, got %s", cfg.Discovery.Interval))
+		}
+	}
+	if cfg.Gitlab.PerPage < 1 || cfg.Gitlab.PerPage > 100 {
+		errs = append(errs, fmt.Errorf("gitlab.per_page (GITLAB_PER_PAGE) must be between 1 and 100, got %d", cfg.Gitlab.PerPage))
+	}
+	if cfg.Gitlab.MaxPages < 0 {
+		errs = append(errs, fmt.Errorf("gitlab.max_pages (GITLAB_MAX_PAGES) must not be negative, got %d", cfg.Gitlab.MaxPages))
+	}
+	if cfg.Gitlab.RequestTimeout < 0 {
+		errs = append(errs, fmt.Errorf("gitlab.request_timeout (GITLAB_REQUEST_TIMEOUT) must not be negative, got %s", cfg.Gitlab.RequestTimeout))
+	}
+	if cfg.Gitlab.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("gitlab.max_retries (GITLAB_MAX_RETRIES) must not be negative, got %d", cfg.Gitlab.MaxRetries))
+	}
+	if cfg.Gitlab.RetryWaitMin <= 0 || cfg.Gitlab.RetryWaitMax < cfg.Gitlab.RetryWaitMin {
+		errs = append(errs, fmt.Errorf("gitlab.retry_wait_min (GITLAB_RETRY_WAIT_MIN) must be positive and not greater than gitlab.retry_wait_max (GITLAB_RETRY_WAIT_MAX), got %s and %s", cfg.Gitlab.RetryWaitMin, cfg.Gitlab.RetryWaitMax))
+	}
+	if cfg.Gitlab.RateLimit < 0 {
+		errs = append(errs, fmt.Errorf("gitlab.rate_limit (GITLAB_RATE_LIMIT) must not be negative, got %g", cfg.Gitlab.RateLimit))
+	}
+	if cfg.Gitlab.RateLimitBurst < 1 {
+		errs = append(errs, fmt.Errorf("gitlab.rate_limit_burst (GITLAB_RATE_LIMIT_BURST) must be at least 1, got %d", cfg.Gitlab.RateLimitBurst))
+	}
+	if cfg.Gitlab.NameCacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("gitlab.name_cache_ttl (GITLAB_NAME_CACHE_TTL) must not be negative, got %s", cfg.Gitlab.NameCacheTTL))
+	}
+	if cfg.Gitlab.NameCacheNegativeTTL < 0 {
+		errs = append(errs, fmt.Errorf("gitlab.name_cache_negative_ttl (GITLAB_NAME_CACHE_NEGATIVE_TTL) must not be negative, got %s", cfg.Gitlab.NameCacheNegativeTTL))
+	}
+	if cfg.Scraper.NeverExpiresPolicy != "allow" && cfg.Scraper.NeverExpiresPolicy != "violation" {
+		errs = append(errs, fmt.Errorf("scraper.never_expires_policy (SCRAPER_NEVER_EXPIRES_POLICY) must be \"allow\" or \"violation\", got %q", cfg.Scraper.NeverExpiresPolicy))
+	}
+	if cfg.Scraper.Concurrency < 1 {
+		errs = append(errs, fmt.Errorf("scraper.concurrency (SCRAPER_CONCURRENCY) must be at least 1, got %d", cfg.Scraper.Concurrency))
+	}
+	if cfg.Scraper.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("scraper.timeout (SCRAPER_TIMEOUT) must not be negative, got %s", cfg.Scraper.Timeout))
+	}
+	if cfg.Scraper.RunnerTokenWarning < 0 {
+		errs = append(errs, fmt.Errorf("scraper.runner_token_warning (SCRAPER_RUNNER_TOKEN_WARNING) must not be negative, got %s", cfg.Scraper.RunnerTokenWarning))
+	}
+	if cfg.Scraper.ProbeTimeout < 0 {
+		errs = append(errs, fmt.Errorf("scraper.probe_timeout (SCRAPER_PROBE_TIMEOUT) must not be negative, got %s", cfg.Scraper.ProbeTimeout))
+	}
+	if cfg.Scraper.UnusedTokenDays < 0 {
+		errs = append(errs, fmt.Errorf("scraper.unused_token_days (SCRAPER_UNUSED_TOKEN_DAYS) must not be negative, got %d", cfg.Scraper.UnusedTokenDays))
+	}
+	if cfg.Rotation.RulesFile != "" {
+		if cfg.Rotation.MaxPerRun < 1 {
+			errs = append(errs, fmt.Errorf("rotation.max_per_run (ROTATION_MAX_PER_RUN) must be at least 1, got %d", cfg.Rotation.MaxPerRun))
+		}
+		if cfg.Rotation.RetryInterval <= 0 {
+			errs = append(errs, fmt.Errorf("rotation.retry_interval (ROTATION_RETRY_INTERVAL) must be positive, got %s", cfg.Rotation.RetryInterval))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateGitlab проверяет подключение к единственному инстансу из секции gitlab
+func (cfg *Config) validateGitlab() []error {
+	var errs []error
+
+	switch tokenSources := countSet(cfg.Gitlab.Token, cfg.Gitlab.TokenFile, cfg.Vault.SecretPath); {
+	case tokenSources == 0:
+		errs = append(errs, fmt.Errorf("one of gitlab.token (GITLAB_TOKEN), gitlab.token_file (GITLAB_TOKEN_FILE) or vault.secret_path (VAULT_SECRET_PATH) is required"))
+	case tokenSources > 1:
+		errs = append(errs, fmt.Errorf("gitlab.token (GITLAB_TOKEN), gitlab.token_file (GITLAB_TOKEN_FILE) and vault.secret_path (VAULT_SECRET_PATH) are mutually exclusive"))
+	}
+	if cfg.Gitlab.BaseURL == "" {
+		errs = append(errs, fmt.Errorf("gitlab.base_url (GITLAB_BASE_URL) is required"))
+	}
+	if cfg.Gitlab.AdminMode {
+		return errs
+	}
+	if cfg.Discovery.Enabled {
+		if len(cfg.Gitlab.GroupIDs) == 0 {
+			errs = append(errs, fmt.Errorf("gitlab.group_ids (GITLAB_GROUP_IDS) is required when discovery.enabled (DISCOVERY_ENABLED) is set"))
+		}
+	} else if len(cfg.Gitlab.ProjectIDs) == 0 {
+		errs = append(errs, fmt.Errorf("gitlab.project_ids (GITLAB_PROJECT_IDS) is required unless discovery.enabled (DISCOVERY_ENABLED) or gitlab.admin_mode (GITLAB_ADMIN_MODE) is set"))
+	}
+
+	return errs
+}
+
+// validateInstances проверяет список инстансов. Параметры подключения из секции gitlab
+// в этом режиме не используются, поэтому их заполнение считается ошибкой.
+func (cfg *Config) validateInstances() []error {
+	var errs []error
+
+	if countSet(cfg.Gitlab.Token, cfg.Gitlab.TokenFile, cfg.Gitlab.BaseURL, cfg.Vault.SecretPath) > 0 ||
+		len(cfg.Gitlab.ProjectIDs) > 0 || len(cfg.Gitlab.GroupIDs) > 0 || cfg.Gitlab.AdminMode {
+		errs = append(errs, fmt.Errorf("gitlab.token, gitlab.token_file, gitlab.base_url, gitlab.project_ids, gitlab.group_ids, gitlab.admin_mode and vault.secret_path must be set per instance when instances are configured"))
+	}
+
+	names := make(map[string]bool)
+	for i, instance := range cfg.Instances {
+		path := fmt.Sprintf("instances[%d]", i)
+
+		switch {
+		case instance.Name == "":
+			errs = append(errs, fmt.Errorf("%s.name is required", path))
+		case names[instance.Name]:
+			errs = append(errs, fmt.Errorf("%s.name %q is not unique", path, instance.Name))
+		}
+		names[instance.Name] = true
+
+		switch tokenSources := countSet(instance.Token, instance.TokenFile, instance.VaultSecretPath); {
+		case tokenSources == 0:
+			errs = append(errs, fmt.Errorf("one of %[1]s.token, %[1]s.token_file or %[1]s.vault_secret_path is required", path))
+		case tokenSources > 1:
+			errs = append(errs, fmt.Errorf("%[1]s.token, %[1]s.token_file and %[1]s.vault_secret_path are mutually exclusive", path))
+		}
+		if instance.BaseURL == "" {
+			errs = append(errs, fmt.Errorf("%s.base_url is required", path))
+		}
+		if instance.Interval < 0 {
+			errs = append(errs, fmt.Errorf("%s.interval must not be negative, got %s", path, instance.Interval))
+		}
+		if instance.AdminMode {
+			continue
+		}
+		if cfg.Discovery.Enabled {
+			if len(instance.GroupIDs) == 0 {
+				errs = append(errs, fmt.Errorf("%s.group_ids is required when discovery.enabled (DISCOVERY_ENABLED) is set", path))
+			}
+		} else if len(instance.ProjectIDs) == 0 {
+			errs = append(errs, fmt.Errorf("%s.project_ids is required unless discovery.enabled (DISCOVERY_ENABLED) or %s.admin_mode is set", path, path))
+		}
+	}
+
+	return errs
+}
+
+// usesVault сообщает, читается ли хотя бы один токен GitLab из Vault
+func (cfg *Config) usesVault() bool {
+	if len(cfg.Instances) == 0 {
+		return cfg.Vault.SecretPath != ""
+	}
+	for _, instance := range cfg.Instances {
+		if instance.VaultSecretPath != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *Config) anyInstanceAdminMode() bool {
+	for _, instance := range cfg.Instances {
+		if instance.AdminMode {
+			return true
+		}
+	}
+	return false
+}
+
+// GitLabInstances возвращает отслеживаемые инстансы GitLab. Без списка instances
+// возвращается один инстанс без имени, собранный из секций gitlab и vault.
+func (cfg *Config) GitLabInstances() []Instance {
+	if len(cfg.Instances) == 0 {
+		return []Instance{{
+			BaseURL:         cfg.Gitlab.BaseURL,
+			Token:           cfg.Gitlab.Token,
+			TokenFile:       cfg.Gitlab.TokenFile,
+			VaultSecretPath: cfg.Vault.SecretPath,
+			ProjectIDs:      cfg.Gitlab.ProjectIDs,
+			GroupIDs:        cfg.Gitlab.GroupIDs,
+			AdminMode:       cfg.Gitlab.AdminMode,
+			Interval:        cfg.Scraper.Interval,
+		}}
+	}
+
+	instances := make([]Instance, len(cfg.Instances))
+	for i, instance := range cfg.Instances {
+		if instance.Interval == 0 {
+			instance.Interval = cfg.Scraper.Interval
+		}
+		instances[i] = instance
+	}
+	return instances
+}
+
+// countSet возвращает количество непустых значений
+func countSet(values ...string) int {
+	count := 0
+	for _, value := range values {
+		if value != "" {
+			count++
+		}
+	}
+	return count
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -14,6 +15,32 @@ func TestLoad(t *testing.T) {
 	originalProjectIDs := os.Getenv("GITLAB_PROJECT_IDS")
 	originalPort := os.Getenv("SERVER_PORT")
 	originalInterval := os.Getenv("SCRAPER_INTERVAL")
+	originalPerPage := os.Getenv("GITLAB_PER_PAGE")
+	originalMaxPages := os.Getenv("GITLAB_MAX_PAGES")
+	originalNeverExpiresPolicy := os.Getenv("SCRAPER_NEVER_EXPIRES_POLICY")
+	originalConcurrency := os.Getenv("SCRAPER_CONCURRENCY")
+	originalRequestTimeout := os.Getenv("GITLAB_REQUEST_TIMEOUT")
+	originalScraperTimeout := os.Getenv("SCRAPER_TIMEOUT")
+	originalMaxRetries := os.Getenv("GITLAB_MAX_RETRIES")
+	originalRetryWaitMin := os.Getenv("GITLAB_RETRY_WAIT_MIN")
+	originalRetryWaitMax := os.Getenv("GITLAB_RETRY_WAIT_MAX")
+	originalRateLimit := os.Getenv("GITLAB_RATE_LIMIT")
+	originalRateLimitBurst := os.Getenv("GITLAB_RATE_LIMIT_BURST")
+	originalNameCacheTTL := os.Getenv("GITLAB_NAME_CACHE_TTL")
+	originalNameCacheNegativeTTL := os.Getenv("GITLAB_NAME_CACHE_NEGATIVE_TTL")
+	originalGroupIDs := os.Getenv("GITLAB_GROUP_IDS")
+	originalDiscoveryEnabled := os.Getenv("DISCOVERY_ENABLED")
+	originalDiscoveryIncludeSubgroups := os.Getenv("DISCOVERY_INCLUDE_SUBGROUPS")
+	originalDiscoverySkipArchived := os.Getenv("DISCOVERY_SKIP_ARCHIVED")
+	originalDiscoveryInterval := os.Getenv("DISCOVERY_INTERVAL")
+	originalAdminMode := os.Getenv("GITLAB_ADMIN_MODE")
+	originalDeployTokens := os.Getenv("SCRAPER_DEPLOY_TOKENS")
+	originalPipelines := os.Getenv("SCRAPER_PIPELINES")
+	originalKeys := os.Getenv("SCRAPER_KEYS")
+	originalRunners := os.Getenv("SCRAPER_RUNNERS")
+	originalRunnerTokenWarning := os.Getenv("SCRAPER_RUNNER_TOKEN_WARNING")
+	originalUnusedTokenDays := os.Getenv("SCRAPER_UNUSED_TOKEN_DAYS")
+	originalPolicyFile := os.Getenv("SCRAPER_POLICY_FILE")
 
 	// Восстанавливаем переменные после теста
 	defer func() {
@@ -42,6 +69,136 @@ func TestLoad(t *testing.T) {
 		} else {
 			os.Unsetenv("SCRAPER_INTERVAL")
 		}
+		if originalPerPage != "" {
+			os.Setenv("GITLAB_PER_PAGE", originalPerPage)
+		} else {
+			os.Unsetenv("GITLAB_PER_PAGE")
+		}
+		if originalMaxPages != "" {
+			os.Setenv("GITLAB_MAX_PAGES", originalMaxPages)
+		} else {
+			os.Unsetenv("GITLAB_MAX_PAGES")
+		}
+		if originalNeverExpiresPolicy != "" {
+			os.Setenv("SCRAPER_NEVER_EXPIRES_POLICY", originalNeverExpiresPolicy)
+		} else {
+			os.Unsetenv("SCRAPER_NEVER_EXPIRES_POLICY")
+		}
+		if originalConcurrency != "" {
+			os.Setenv("SCRAPER_CONCURRENCY", originalConcurrency)
+		} else {
+			os.Unsetenv("SCRAPER_CONCURRENCY")
+		}
+		if originalRequestTimeout != "" {
+			os.Setenv("GITLAB_REQUEST_TIMEOUT", originalRequestTimeout)
+		} else {
+			os.Unsetenv("GITLAB_REQUEST_TIMEOUT")
+		}
+		if originalScraperTimeout != "" {
+			os.Setenv("SCRAPER_TIMEOUT", originalScraperTimeout)
+		} else {
+			os.Unsetenv("SCRAPER_TIMEOUT")
+		}
+		if originalMaxRetries != "" {
+			os.Setenv("GITLAB_MAX_RETRIES", originalMaxRetries)
+		} else {
+			os.Unsetenv("GITLAB_MAX_RETRIES")
+		}
+		if originalRetryWaitMin != "" {
+			os.Setenv("GITLAB_RETRY_WAIT_MIN", originalRetryWaitMin)
+		} else {
+			os.Unsetenv("GITLAB_RETRY_WAIT_MIN")
+		}
+		if originalRetryWaitMax != "" {
+			os.Setenv("GITLAB_RETRY_WAIT_MAX", originalRetryWaitMax)
+		} else {
+			os.Unsetenv("GITLAB_RETRY_WAIT_MAX")
+		}
+		if originalRateLimit != "" {
+			os.Setenv("GITLAB_RATE_LIMIT", originalRateLimit)
+		} else {
+			os.Unsetenv("GITLAB_RATE_LIMIT")
+		}
+		if originalRateLimitBurst != "" {
+			os.Setenv("GITLAB_RATE_LIMIT_BURST", originalRateLimitBurst)
+		} else {
+			os.Unsetenv("GITLAB_RATE_LIMIT_BURST")
+		}
+		if originalNameCacheTTL != "" {
+			os.Setenv("GITLAB_NAME_CACHE_TTL", originalNameCacheTTL)
+		} else {
+			os.Unsetenv("GITLAB_NAME_CACHE_TTL")
+		}
+		if originalNameCacheNegativeTTL != "" {
+			os.Setenv("GITLAB_NAME_CACHE_NEGATIVE_TTL", originalNameCacheNegativeTTL)
+		} else {
+			os.Unsetenv("GITLAB_NAME_CACHE_NEGATIVE_TTL")
+		}
+		if originalGroupIDs != "" {
+			os.Setenv("GITLAB_GROUP_IDS", originalGroupIDs)
+		} else {
+			os.Unsetenv("GITLAB_GROUP_IDS")
+		}
+		if originalDiscoveryEnabled != "" {
+			os.Setenv("DISCOVERY_ENABLED", originalDiscoveryEnabled)
+		} else {
+			os.Unsetenv("DISCOVERY_ENABLED")
+		}
+		if originalDiscoveryIncludeSubgroups != "" {
+			os.Setenv("DISCOVERY_INCLUDE_SUBGROUPS", originalDiscoveryIncludeSubgroups)
+		} else {
+			os.Unsetenv("DISCOVERY_INCLUDE_SUBGROUPS")
+		}
+		if originalDiscoverySkipArchived != "" {
+			os.Setenv("DISCOVERY_SKIP_ARCHIVED", originalDiscoverySkipArchived)
+		} else {
+			os.Unsetenv("DISCOVERY_SKIP_ARCHIVED")
+		}
+		if originalDiscoveryInterval != "" {
+			os.Setenv("DISCOVERY_INTERVAL", originalDiscoveryInterval)
+		} else {
+			os.Unsetenv("DISCOVERY_INTERVAL")
+		}
+		if originalAdminMode != "" {
+			os.Setenv("GITLAB_ADMIN_MODE", originalAdminMode)
+		} else {
+			os.Unsetenv("GITLAB_ADMIN_MODE")
+		}
+		if originalDeployTokens != "" {
+			os.Setenv("SCRAPER_DEPLOY_TOKENS", originalDeployTokens)
+		} else {
+			os.Unsetenv("SCRAPER_DEPLOY_TOKENS")
+		}
+		if originalPipelines != "" {
+			os.Setenv("SCRAPER_PIPELINES", originalPipelines)
+		} else {
+			os.Unsetenv("SCRAPER_PIPELINES")
+		}
+		if originalKeys != "" {
+			os.Setenv("SCRAPER_KEYS", originalKeys)
+		} else {
+			os.Unsetenv("SCRAPER_KEYS")
+		}
+		if originalRunners != "" {
+			os.Setenv("SCRAPER_RUNNERS", originalRunners)
+		} else {
+			os.Unsetenv("SCRAPER_RUNNERS")
+		}
+		if originalRunnerTokenWarning != "" {
+			os.Setenv("SCRAPER_RUNNER_TOKEN_WARNING", originalRunnerTokenWarning)
+		} else {
+			os.Unsetenv("SCRAPER_RUNNER_TOKEN_WARNING")
+		}
+		if originalUnusedTokenDays != "" {
+			os.Setenv("SCRAPER_UNUSED_TOKEN_DAYS", originalUnusedTokenDays)
+		} else {
+			os.Unsetenv("SCRAPER_UNUSED_TOKEN_DAYS")
+		}
+		if originalPolicyFile != "" {
+			os.Setenv("SCRAPER_POLICY_FILE", originalPolicyFile)
+		} else {
+			os.Unsetenv("SCRAPER_POLICY_FILE")
+		}
 	}()
 
 	tests := []struct {
@@ -153,6 +310,346 @@ func TestLoad(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid pagination settings",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "12345",
+				"GITLAB_PER_PAGE":    "50",
+				"GITLAB_MAX_PAGES":   "10",
+			},
+			wantErr: false,
+		},
+		{
+			name: "per page too large",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "12345",
+				"GITLAB_PER_PAGE":    "500",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative max pages",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "12345",
+				"GITLAB_MAX_PAGES":   "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "never expires violation policy",
+			env: map[string]string{
+				"GITLAB_TOKEN":                 "test-token",
+				"GITLAB_BASE_URL":              "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":           "12345",
+				"SCRAPER_NEVER_EXPIRES_POLICY": "violation",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid never expires policy",
+			env: map[string]string{
+				"GITLAB_TOKEN":                 "test-token",
+				"GITLAB_BASE_URL":              "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":           "12345",
+				"SCRAPER_NEVER_EXPIRES_POLICY": "deny",
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom concurrency",
+			env: map[string]string{
+				"GITLAB_TOKEN":        "test-token",
+				"GITLAB_BASE_URL":     "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":  "12345",
+				"SCRAPER_CONCURRENCY": "16",
+			},
+			wantErr: false,
+		},
+		{
+			name: "zero concurrency",
+			env: map[string]string{
+				"GITLAB_TOKEN":        "test-token",
+				"GITLAB_BASE_URL":     "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":  "12345",
+				"SCRAPER_CONCURRENCY": "0",
+			},
+			wantErr: true,
+		},
+		{
+			name: "custom timeouts",
+			env: map[string]string{
+				"GITLAB_TOKEN":           "test-token",
+				"GITLAB_BASE_URL":        "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":     "12345",
+				"GITLAB_REQUEST_TIMEOUT": "10s",
+				"SCRAPER_TIMEOUT":        "0",
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative request timeout",
+			env: map[string]string{
+				"GITLAB_TOKEN":           "test-token",
+				"GITLAB_BASE_URL":        "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":     "12345",
+				"GITLAB_REQUEST_TIMEOUT": "-1s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid scraper timeout",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "12345",
+				"SCRAPER_TIMEOUT":    "soon",
+			},
+			wantErr: true,
+		},
+		{
+			name: "retry and rate limit settings",
+			env: map[string]string{
+				"GITLAB_TOKEN":            "test-token",
+				"GITLAB_BASE_URL":         "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":      "12345",
+				"GITLAB_MAX_RETRIES":      "3",
+				"GITLAB_RETRY_WAIT_MIN":   "500ms",
+				"GITLAB_RETRY_WAIT_MAX":   "10s",
+				"GITLAB_RATE_LIMIT":       "2.5",
+				"GITLAB_RATE_LIMIT_BURST": "5",
+			},
+			wantErr: false,
+		},
+		{
+			name: "retry wait min greater than max",
+			env: map[string]string{
+				"GITLAB_TOKEN":          "test-token",
+				"GITLAB_BASE_URL":       "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":    "12345",
+				"GITLAB_RETRY_WAIT_MIN": "1m",
+				"GITLAB_RETRY_WAIT_MAX": "10s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative rate limit",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "12345",
+				"GITLAB_RATE_LIMIT":  "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero rate limit burst",
+			env: map[string]string{
+				"GITLAB_TOKEN":            "test-token",
+				"GITLAB_BASE_URL":         "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":      "12345",
+				"GITLAB_RATE_LIMIT_BURST": "0",
+			},
+			wantErr: true,
+		},
+		{
+			name: "name cache disabled",
+			env: map[string]string{
+				"GITLAB_TOKEN":          "test-token",
+				"GITLAB_BASE_URL":       "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":    "12345",
+				"GITLAB_NAME_CACHE_TTL": "0",
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative name cache ttl",
+			env: map[string]string{
+				"GITLAB_TOKEN":                   "test-token",
+				"GITLAB_BASE_URL":                "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":             "12345",
+				"GITLAB_NAME_CACHE_NEGATIVE_TTL": "-5m",
+			},
+			wantErr: true,
+		},
+		{
+			name: "discovery without project ids",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_GROUP_IDS":   "10,20",
+				"DISCOVERY_ENABLED":  "true",
+				"DISCOVERY_INTERVAL": "30m",
+			},
+			wantErr: false,
+		},
+		{
+			// docker-compose передает GITLAB_PROJECT_IDS пустым, если переменная не задана
+			name: "discovery with empty project ids",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "",
+				"GITLAB_GROUP_IDS":   "10,20",
+				"DISCOVERY_ENABLED":  "true",
+			},
+			wantErr: false,
+		},
+		{
+			name: "admin mode with empty project ids",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "",
+				"GITLAB_ADMIN_MODE":  "true",
+			},
+			wantErr: false,
+		},
+		{
+			name: "discovery without group ids",
+			env: map[string]string{
+				"GITLAB_TOKEN":      "test-token",
+				"GITLAB_BASE_URL":   "https://gitlab.com",
+				"DISCOVERY_ENABLED": "true",
+			},
+			wantErr: true,
+		},
+		{
+			name: "discovery with zero interval",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_GROUP_IDS":   "10",
+				"DISCOVERY_ENABLED":  "true",
+				"DISCOVERY_INTERVAL": "0s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "admin mode without project and group ids",
+			env: map[string]string{
+				"GITLAB_TOKEN":      "test-token",
+				"GITLAB_BASE_URL":   "https://gitlab.com",
+				"GITLAB_ADMIN_MODE": "true",
+			},
+			wantErr: false,
+		},
+		{
+			name: "deploy tokens disabled",
+			env: map[string]string{
+				"GITLAB_TOKEN":          "test-token",
+				"GITLAB_BASE_URL":       "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":    "12345",
+				"SCRAPER_DEPLOY_TOKENS": "false",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid deploy tokens flag",
+			env: map[string]string{
+				"GITLAB_TOKEN":          "test-token",
+				"GITLAB_BASE_URL":       "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":    "12345",
+				"SCRAPER_DEPLOY_TOKENS": "maybe",
+			},
+			wantErr: true,
+		},
+		{
+			name: "pipelines disabled",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "12345",
+				"SCRAPER_PIPELINES":  "false",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid pipelines flag",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "12345",
+				"SCRAPER_PIPELINES":  "maybe",
+			},
+			wantErr: true,
+		},
+		{
+			name: "keys disabled",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "12345",
+				"SCRAPER_KEYS":       "false",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid keys flag",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "test-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "12345",
+				"SCRAPER_KEYS":       "maybe",
+			},
+			wantErr: true,
+		},
+		{
+			name: "runners with custom token warning",
+			env: map[string]string{
+				"GITLAB_TOKEN":                 "test-token",
+				"GITLAB_BASE_URL":              "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":           "12345",
+				"SCRAPER_RUNNERS":              "true",
+				"SCRAPER_RUNNER_TOKEN_WARNING": "72h",
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative runner token warning",
+			env: map[string]string{
+				"GITLAB_TOKEN":                 "test-token",
+				"GITLAB_BASE_URL":              "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":           "12345",
+				"SCRAPER_RUNNER_TOKEN_WARNING": "-1h",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unused token detection",
+			env: map[string]string{
+				"GITLAB_TOKEN":              "test-token",
+				"GITLAB_BASE_URL":           "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":        "12345",
+				"SCRAPER_UNUSED_TOKEN_DAYS": "90",
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative unused token days",
+			env: map[string]string{
+				"GITLAB_TOKEN":              "test-token",
+				"GITLAB_BASE_URL":           "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":        "12345",
+				"SCRAPER_UNUSED_TOKEN_DAYS": "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "policy file",
+			env: map[string]string{
+				"GITLAB_TOKEN":        "test-token",
+				"GITLAB_BASE_URL":     "https://gitlab.com",
+				"GITLAB_PROJECT_IDS":  "12345",
+				"SCRAPER_POLICY_FILE": "/etc/token-exporter/policy.yaml",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -163,6 +660,32 @@ func TestLoad(t *testing.T) {
 			os.Unsetenv("GITLAB_PROJECT_IDS")
 			os.Unsetenv("SERVER_PORT")
 			os.Unsetenv("SCRAPER_INTERVAL")
+			os.Unsetenv("GITLAB_PER_PAGE")
+			os.Unsetenv("GITLAB_MAX_PAGES")
+			os.Unsetenv("SCRAPER_NEVER_EXPIRES_POLICY")
+			os.Unsetenv("SCRAPER_CONCURRENCY")
+			os.Unsetenv("GITLAB_REQUEST_TIMEOUT")
+			os.Unsetenv("SCRAPER_TIMEOUT")
+			os.Unsetenv("GITLAB_MAX_RETRIES")
+			os.Unsetenv("GITLAB_RETRY_WAIT_MIN")
+			os.Unsetenv("GITLAB_RETRY_WAIT_MAX")
+			os.Unsetenv("GITLAB_RATE_LIMIT")
+			os.Unsetenv("GITLAB_RATE_LIMIT_BURST")
+			os.Unsetenv("GITLAB_NAME_CACHE_TTL")
+			os.Unsetenv("GITLAB_NAME_CACHE_NEGATIVE_TTL")
+			os.Unsetenv("GITLAB_GROUP_IDS")
+			os.Unsetenv("DISCOVERY_ENABLED")
+			os.Unsetenv("DISCOVERY_INCLUDE_SUBGROUPS")
+			os.Unsetenv("DISCOVERY_SKIP_ARCHIVED")
+			os.Unsetenv("DISCOVERY_INTERVAL")
+			os.Unsetenv("GITLAB_ADMIN_MODE")
+			os.Unsetenv("SCRAPER_DEPLOY_TOKENS")
+			os.Unsetenv("SCRAPER_PIPELINES")
+			os.Unsetenv("SCRAPER_KEYS")
+			os.Unsetenv("SCRAPER_RUNNERS")
+			os.Unsetenv("SCRAPER_RUNNER_TOKEN_WARNING")
+			os.Unsetenv("SCRAPER_UNUSED_TOKEN_DAYS")
+			os.Unsetenv("SCRAPER_POLICY_FILE")
 
 			// Устанавливаем переменные окружения для теста
 			for key, value := range tt.env {
@@ -217,3 +740,81 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestLoad_TokenSources(t *testing.T) {
+	base := map[string]string{
+		"GITLAB_BASE_URL":    "https://gitlab.com",
+		"GITLAB_PROJECT_IDS": "1",
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name: "token file",
+			env:  map[string]string{"GITLAB_TOKEN_FILE": "/var/run/secrets/gitlab/token"},
+		},
+		{
+			name: "vault",
+			env: map[string]string{
+				"VAULT_ADDR":        "http://127.0.0.1:8200",
+				"VAULT_TOKEN_FILE":  "/var/run/secrets/vault/token",
+				"VAULT_SECRET_PATH": "token-exporter",
+			},
+		},
+		{
+			name: "token and token file",
+			env: map[string]string{
+				"GITLAB_TOKEN":      "test-token",
+				"GITLAB_TOKEN_FILE": "/var/run/secrets/gitlab/token",
+			},
+			wantErr: "are mutually exclusive",
+		},
+		{
+			name: "vault without address and token",
+			env: map[string]string{
+				"VAULT_SECRET_PATH": "token-exporter",
+				"VAULT_KV_VERSION":  "3",
+			},
+			wantErr: "vault.address (VAULT_ADDR) is required",
+		},
+		{
+			name: "vault with both tokens",
+			env: map[string]string{
+				"VAULT_ADDR":        "http://127.0.0.1:8200",
+				"VAULT_TOKEN":       "root",
+				"VAULT_TOKEN_FILE":  "/var/run/secrets/vault/token",
+				"VAULT_SECRET_PATH": "token-exporter",
+			},
+			wantErr: "exactly one of vault.token (VAULT_TOKEN) or vault.token_file (VAULT_TOKEN_FILE) is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, reflect.TypeOf(Config{}))
+			for key, value := range base {
+				t.Setenv(key, value)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				if cfg.Vault.Mount != "secret" || cfg.Vault.SecretKey != "token" || cfg.Vault.KVVersion != 2 {
+					t.Errorf("Vault defaults = %q %q %d, want secret token 2", cfg.Vault.Mount, cfg.Vault.SecretKey, cfg.Vault.KVVersion)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
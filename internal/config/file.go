@@ -0,0 +1,42 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeFile читает YAML- или JSON-файл конфигурации поверх значений cfg.
+// Неизвестные поля считаются ошибкой, чтобы опечатки в именах не оставались незамеченными.
+func decodeFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// overrideFromEnv копирует из env в dst поля, чьи переменные окружения заданы явно
+func overrideFromEnv(dst, env reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			overrideFromEnv(dst.Field(i), env.Field(i))
+			continue
+		}
+		if _, ok := os.LookupEnv(field.Tag.Get("envconfig")); ok {
+			dst.Field(i).Set(env.Field(i))
+		}
+	}
+}
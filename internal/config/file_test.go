@@ -0,0 +1,350 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv удаляет все переменные конфигурации на время теста
+func clearEnv(t *testing.T, v reflect.Type) {
+	t.Helper()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			clearEnv(t, field.Type)
+			continue
+		}
+		name := field.Tag.Get("envconfig")
+		if name == "" {
+			continue
+		}
+		// t.Setenv восстановит исходное значение после теста
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	const yamlConfig = `
+gitlab:
+  token: file-token
+  base_url: https://gitlab.example.com
+  project_ids: [1, 2]
+  request_timeout: 10s
+scraper:
+  interval: 1m
+  never_expires_policy: violation
+discovery:
+  skip_archived: false
+`
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Config)
+	}{
+		{
+			name:    "yaml file with defaults",
+			file:    "config.yaml",
+			content: yamlConfig,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Gitlab.Token != "file-token" || cfg.Gitlab.BaseURL != "https://gitlab.example.com" {
+					t.Errorf("Gitlab = %q %q, want values from file", cfg.Gitlab.Token, cfg.Gitlab.BaseURL)
+				}
+				if !reflect.DeepEqual([]int(cfg.Gitlab.ProjectIDs), []int{1, 2}) {
+					t.Errorf("Gitlab.ProjectIDs = %v, want [1 2]", cfg.Gitlab.ProjectIDs)
+				}
+				if cfg.Gitlab.RequestTimeout != 10*time.Second || cfg.Scraper.Interval != time.Minute {
+					t.Errorf("durations = %s %s, want 10s 1m", cfg.Gitlab.RequestTimeout, cfg.Scraper.Interval)
+				}
+				if cfg.Discovery.SkipArchived {
+					t.Error("Discovery.SkipArchived = true, want false from file")
+				}
+				// Поля, отсутствующие в файле, получают значения по умолчанию
+				if cfg.Gitlab.PerPage != 100 || cfg.Server.Port != 8080 || !cfg.Scraper.DeployTokens {
+					t.Errorf("defaults = %d %d %v, want 100 8080 true", cfg.Gitlab.PerPage, cfg.Server.Port, cfg.Scraper.DeployTokens)
+				}
+			},
+		},
+		{
+			name:    "environment overrides file",
+			file:    "config.yaml",
+			content: yamlConfig,
+			env: map[string]string{
+				"GITLAB_TOKEN":                 "env-token",
+				"GITLAB_PROJECT_IDS":           "3",
+				"SCRAPER_NEVER_EXPIRES_POLICY": "allow",
+				"DISCOVERY_SKIP_ARCHIVED":      "true",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Gitlab.Token != "env-token" {
+					t.Errorf("Gitlab.Token = %q, want %q", cfg.Gitlab.Token, "env-token")
+				}
+				if !reflect.DeepEqual([]int(cfg.Gitlab.ProjectIDs), []int{3}) {
+					t.Errorf("Gitlab.ProjectIDs = %v, want [3]", cfg.Gitlab.ProjectIDs)
+				}
+				if cfg.Scraper.NeverExpiresPolicy != "allow" || !cfg.Discovery.SkipArchived {
+					t.Errorf("overrides = %q %v, want allow true", cfg.Scraper.NeverExpiresPolicy, cfg.Discovery.SkipArchived)
+				}
+				// Значения без переменных окружения остаются из файла
+				if cfg.Gitlab.BaseURL != "https://gitlab.example.com" || cfg.Scraper.Interval != time.Minute {
+					t.Errorf("file values = %q %s, want values from file", cfg.Gitlab.BaseURL, cfg.Scraper.Interval)
+				}
+			},
+		},
+		{
+			name:    "json file",
+			file:    "config.json",
+			content: `{"gitlab": {"token": "json-token", "base_url": "https://gitlab.com", "group_ids": [5], "admin_mode": true}}`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Gitlab.Token != "json-token" || !cfg.Gitlab.AdminMode {
+					t.Errorf("Gitlab = %q %v, want values from file", cfg.Gitlab.Token, cfg.Gitlab.AdminMode)
+				}
+				if !reflect.DeepEqual([]int(cfg.Gitlab.GroupIDs), []int{5}) {
+					t.Errorf("Gitlab.GroupIDs = %v, want [5]", cfg.Gitlab.GroupIDs)
+				}
+			},
+		},
+		{
+			// Пробный режим включен по умолчанию и выключается явно
+			name: "rotation settings",
+			file: "config.yaml",
+			content: yamlConfig + `
+rotation:
+  rules_file: /etc/token-exporter/rotation.yaml
+  max_per_run: 3
+`,
+			env: map[string]string{"ROTATION_DRY_RUN": "false"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Rotation.RulesFile != "/etc/token-exporter/rotation.yaml" || cfg.Rotation.MaxPerRun != 3 {
+					t.Errorf("Rotation = %q %d, want values from file", cfg.Rotation.RulesFile, cfg.Rotation.MaxPerRun)
+				}
+				if cfg.Rotation.DryRun || cfg.Rotation.RetryInterval != time.Hour {
+					t.Errorf("Rotation = %v %s, want false 1h", cfg.Rotation.DryRun, cfg.Rotation.RetryInterval)
+				}
+			},
+		},
+		{
+			name:    "empty file",
+			file:    "config.yaml",
+			content: "",
+			env: map[string]string{
+				"GITLAB_TOKEN":       "env-token",
+				"GITLAB_BASE_URL":    "https://gitlab.com",
+				"GITLAB_PROJECT_IDS": "1",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Gitlab.Token != "env-token" || cfg.Gitlab.PerPage != 100 {
+					t.Errorf("Gitlab = %q %d, want env token and default per_page", cfg.Gitlab.Token, cfg.Gitlab.PerPage)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, reflect.TypeOf(Config{}))
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := LoadFile(writeConfigFile(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadFile_Instances(t *testing.T) {
+	const yamlConfig = `
+instances:
+  - name: gitlab-com
+    base_url: https://gitlab.com
+    token_file: /run/secrets/gitlab-com
+    project_ids: [1, 2]
+  - name: self-managed
+    base_url: https://gitlab.example.com
+    vault_secret_path: gitlab/exporter
+    admin_mode: true
+    interval: 5m
+scraper:
+  interval: 1m
+discovery:
+  enabled: false
+vault:
+  address: https://vault.example.com
+  token: vault-token
+`
+
+	clearEnv(t, reflect.TypeOf(Config{}))
+	cfg, err := LoadFile(writeConfigFile(t, "config.yaml", yamlConfig))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	instances := cfg.GitLabInstances()
+	if len(instances) != 2 {
+		t.Fatalf("got %d instances, want 2", len(instances))
+	}
+	if instances[0].Name != "gitlab-com" || instances[0].TokenFile != "/run/secrets/gitlab-com" {
+		t.Errorf("instances[0] = %+v, want values from file", instances[0])
+	}
+	if !reflect.DeepEqual([]int(instances[0].ProjectIDs), []int{1, 2}) {
+		t.Errorf("instances[0].ProjectIDs = %v, want [1 2]", instances[0].ProjectIDs)
+	}
+	// Интервал инстанса по умолчанию берется из scraper.interval
+	if instances[0].Interval != time.Minute || instances[1].Interval != 5*time.Minute {
+		t.Errorf("intervals = %s %s, want 1m 5m", instances[0].Interval, instances[1].Interval)
+	}
+	if !instances[1].AdminMode || instances[1].VaultSecretPath != "gitlab/exporter" {
+		t.Errorf("instances[1] = %+v, want admin mode and vault secret", instances[1])
+	}
+}
+
+func TestConfig_GitLabInstances_Single(t *testing.T) {
+	var cfg Config
+	cfg.Gitlab.Token = "token"
+	cfg.Gitlab.BaseURL = "https://gitlab.com"
+	cfg.Gitlab.ProjectIDs = ProjectIDsSlice{1}
+	cfg.Scraper.Interval = 10 * time.Second
+
+	// Без списка instances секция gitlab описывает единственный инстанс без имени
+	instances := cfg.GitLabInstances()
+	if len(instances) != 1 {
+		t.Fatalf("got %d instances, want 1", len(instances))
+	}
+	if instances[0].Name != "" || instances[0].Token != "token" || instances[0].Interval != 10*time.Second {
+		t.Errorf("instance = %+v, want values from gitlab section", instances[0])
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr []string
+	}{
+		{
+			name:    "unknown field",
+			content: "gitlab:\n  tokn: typo\n",
+			wantErr: []string{"field tokn not found"},
+		},
+		{
+			name:    "invalid value type",
+			content: "scraper:\n  concurrency: many\n",
+			wantErr: []string{"cannot unmarshal"},
+		},
+		{
+			// Все ошибки валидации сообщаются сразу с путями полей
+			name: "all validation errors",
+			content: `
+gitlab:
+  base_url: https://gitlab.com
+  project_ids: [1]
+  per_page: 500
+scraper:
+  concurrency: 0
+  never_expires_policy: deny
+`,
+			wantErr: []string{
+				"one of gitlab.token (GITLAB_TOKEN), gitlab.token_file (GITLAB_TOKEN_FILE) or vault.secret_path (VAULT_SECRET_PATH) is required",
+				"gitlab.per_page (GITLAB_PER_PAGE) must be between 1 and 100, got 500",
+				"scraper.concurrency (SCRAPER_CONCURRENCY) must be at least 1, got 0",
+				`scraper.never_expires_policy (SCRAPER_NEVER_EXPIRES_POLICY) must be "allow" or "violation", got "deny"`,
+			},
+		},
+		{
+			name: "invalid instances",
+			content: `
+gitlab:
+  base_url: https://gitlab.com
+instances:
+  - name: main
+    base_url: https://gitlab.com
+    token: a
+    token_file: /run/secrets/token
+    project_ids: [1]
+  - name: main
+    project_ids: [2]
+    interval: -1s
+`,
+			wantErr: []string{
+				"must be set per instance when instances are configured",
+				"instances[0].token, instances[0].token_file and instances[0].vault_secret_path are mutually exclusive",
+				`instances[1].name "main" is not unique`,
+				"one of instances[1].token, instances[1].token_file or instances[1].vault_secret_path is required",
+				"instances[1].base_url is required",
+				"instances[1].interval must not be negative",
+			},
+		},
+		{
+			// Ограничения ротации проверяются только при заданном файле правил
+			name: "rotation safeguards",
+			content: `
+gitlab:
+  token: test-token
+  base_url: https://gitlab.com
+  project_ids: [1]
+rotation:
+  rules_file: /etc/token-exporter/rotation.yaml
+  max_per_run: 0
+  retry_interval: 0s
+`,
+			wantErr: []string{
+				"rotation.max_per_run (ROTATION_MAX_PER_RUN) must be at least 1, got 0",
+				"rotation.retry_interval (ROTATION_RETRY_INTERVAL) must be positive, got 0s",
+			},
+		},
+		{
+			name: "instance vault settings",
+			content: `
+instances:
+  - name: main
+    base_url: https://gitlab.com
+    vault_secret_path: gitlab/exporter
+`,
+			wantErr: []string{
+				"vault.address (VAULT_ADDR) is required",
+				"instances[0].project_ids is required",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, reflect.TypeOf(Config{}))
+
+			_, err := LoadFile(writeConfigFile(t, "config.yaml", tt.content))
+			if err == nil {
+				t.Fatal("LoadFile() error = nil, want error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("LoadFile() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+
+	clearEnv(t, reflect.TypeOf(Config{}))
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadFile() for missing file error = nil, want error")
+	}
+}
@@ -0,0 +1,42 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// TokenSource возвращает актуальный токен GitLab. Вызывается перед каждым запросом,
+// поэтому реализация должна кэшировать значение.
+type TokenSource interface {
+	Secret(ctx context.Context) (string, error)
+}
+
+// WithTokenSource задает источник токена вместо статического значения,
+// чтобы ротация токена не требовала пересоздания клиента
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = source
+	}
+}
+
+// tokenAuthSource передает токен из TokenSource в заголовок PRIVATE-TOKEN
+type tokenAuthSource struct {
+	source TokenSource
+}
+
+// Убеждаемся, что tokenAuthSource реализует gitlab.AuthSource
+var _ gitlab.AuthSource = tokenAuthSource{}
+
+func (tokenAuthSource) Init(context.Context, *gitlab.Client) error {
+	return nil
+}
+
+func (as tokenAuthSource) Header(ctx context.Context) (string, string, error) {
+	token, err := as.source.Secret(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get gitlab token: %w", err)
+	}
+	return "PRIVATE-TOKEN", token, nil
+}
@@ -0,0 +1,72 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// rotatingToken - источник токена, значение которого меняется в тесте
+type rotatingToken struct {
+	mu    sync.Mutex
+	token string
+	err   error
+}
+
+func (r *rotatingToken) Secret(context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.token, r.err
+}
+
+func (r *rotatingToken) set(token string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.token, r.err = token, err
+}
+
+func TestClient_WithTokenSource(t *testing.T) {
+	var gotTokens []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/1", func(w http.ResponseWriter, r *http.Request) {
+		gotTokens = append(gotTokens, r.Header.Get("PRIVATE-TOKEN"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 1, "name": "api"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	source := &rotatingToken{token: "first"}
+	// Статический токен не нужен, если задан источник
+	client, err := NewClient("", server.URL, WithTokenSource(source), WithRetry(0, 1, 1))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.GetProjectName(context.Background(), 1); err != nil {
+		t.Fatalf("GetProjectName() error = %v", err)
+	}
+
+	// Новый токен используется без пересоздания клиента
+	source.set("second", nil)
+	if _, err := client.GetProjectName(context.Background(), 1); err != nil {
+		t.Fatalf("GetProjectName() error = %v", err)
+	}
+
+	if want := []string{"first", "second"}; fmt.Sprint(gotTokens) != fmt.Sprint(want) {
+		t.Errorf("PRIVATE-TOKEN headers = %v, want %v", gotTokens, want)
+	}
+
+	sourceErr := errors.New("secret unavailable")
+	source.set("", sourceErr)
+	if _, err := client.GetProjectName(context.Background(), 1); !errors.Is(err, sourceErr) {
+		t.Errorf("GetProjectName() error = %v, want %v", err, sourceErr)
+	}
+	if len(gotTokens) != 2 {
+		t.Errorf("request must not be sent without a token, got %d requests", len(gotTokens))
+	}
+}
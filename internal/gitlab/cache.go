@@ -0,0 +1,153 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// Типы владельцев в кэше имен
+const (
+	CacheKindProject = "project"
+	CacheKindGroup   = "group"
+	CacheKindUser    = "user"
+)
+
+// CacheObserver получает события попаданий и промахов кэша имен
+type CacheObserver interface {
+	ObserveNameCache(kind string, hit bool)
+}
+
+type nopCacheObserver struct{}
+
+func (nopCacheObserver) ObserveNameCache(string, bool) {}
+
+// nameRecorder принимает имена владельцев, попутно полученные клиентом
+type nameRecorder interface {
+	rememberName(kind string, id int, name string)
+}
+
+type nameKey struct {
+	kind string
+	id   int
+}
+
+type nameEntry struct {
+	name    string
+	err     error
+	expires time.Time
+}
+
+// CacheOption - функциональная опция для настройки CachedClient
+type CacheOption func(*CachedClient)
+
+// WithNegativeTTL задает время хранения ответов 404 (0 - не кэшировать)
+func WithNegativeTTL(ttl time.Duration) CacheOption {
+	return func(c *CachedClient) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithCacheObserver задает получателя событий кэша
+func WithCacheObserver(observer CacheObserver) CacheOption {
+	return func(c *CachedClient) {
+		c.observer = observer
+	}
+}
+
+// CachedClient кэширует имена проектов, групп и пользователей между проходами скрейпера.
+// Остальные методы передаются обернутому клиенту без изменений.
+type CachedClient struct {
+	GitLabClientInterface
+
+	ttl         time.Duration
+	negativeTTL time.Duration
+	observer    CacheObserver
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[nameKey]nameEntry
+}
+
+// Убеждаемся, что CachedClient реализует GitLabClientInterface
+var _ GitLabClientInterface = (*CachedClient)(nil)
+
+// NewCachedClient оборачивает клиент кэшем имен с временем жизни ttl.
+// Если inner - *Client, он также сохраняет в кэш имена, попутно полученные из ответов API.
+func NewCachedClient(inner GitLabClientInterface, ttl time.Duration, opts ...CacheOption) *CachedClient {
+	c := &CachedClient{
+		GitLabClientInterface: inner,
+		ttl:                   ttl,
+		observer:              nopCacheObserver{},
+		now:                   time.Now,
+		entries:               make(map[nameKey]nameEntry),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if client, ok := inner.(*Client); ok {
+		client.names = c
+	}
+
+	return c
+}
+
+func (c *CachedClient) GetProjectName(ctx context.Context, projectID int) (string, error) {
+	return c.lookup(ctx, CacheKindProject, projectID, c.GitLabClientInterface.GetProjectName)
+}
+
+func (c *CachedClient) GetGroupName(ctx context.Context, groupID int) (string, error) {
+	return c.lookup(ctx, CacheKindGroup, groupID, c.GitLabClientInterface.GetGroupName)
+}
+
+func (c *CachedClient) GetUserName(ctx context.Context, userID int) (string, error) {
+	return c.lookup(ctx, CacheKindUser, userID, c.GitLabClientInterface.GetUserName)
+}
+
+// lookup возвращает имя из кэша или запрашивает его у обернутого клиента
+func (c *CachedClient) lookup(ctx context.Context, kind string, id int, fetch func(context.Context, int) (string, error)) (string, error) {
+	key := nameKey{kind: kind, id: id}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+
+	c.observer.ObserveNameCache(kind, ok)
+	if ok {
+		return entry.name, entry.err
+	}
+
+	name, err := fetch(ctx, id)
+	switch {
+	case err == nil:
+		c.store(key, nameEntry{name: name}, c.ttl)
+	case errors.Is(err, gitlab.ErrNotFound):
+		// Удаленные владельцы не запрашиваются повторно до истечения negativeTTL
+		c.store(key, nameEntry{err: err}, c.negativeTTL)
+	}
+
+	return name, err
+}
+
+func (c *CachedClient) store(key nameKey, entry nameEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.expires = c.now().Add(ttl)
+	c.entries[key] = entry
+}
+
+func (c *CachedClient) rememberName(kind string, id int, name string) {
+	c.store(nameKey{kind: kind, id: id}, nameEntry{name: name}, c.ttl)
+}
@@ -0,0 +1,174 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// countingClient считает запросы имен и возвращает ErrNotFound для отсутствующих ID
+type countingClient struct {
+	GitLabClientInterface
+	mu      sync.Mutex
+	calls   map[string]int
+	missing map[int]bool
+}
+
+func newCountingClient() *countingClient {
+	return &countingClient{calls: make(map[string]int), missing: make(map[int]bool)}
+}
+
+func (c *countingClient) name(kind string, id int) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[kind]++
+	if c.missing[id] {
+		return "", fmt.Errorf("failed to get %s name: %w", kind, gitlab.ErrNotFound)
+	}
+	return fmt.Sprintf("%s-%d", kind, id), nil
+}
+
+func (c *countingClient) GetProjectName(_ context.Context, id int) (string, error) {
+	return c.name(CacheKindProject, id)
+}
+
+func (c *countingClient) GetGroupName(_ context.Context, id int) (string, error) {
+	return c.name(CacheKindGroup, id)
+}
+
+func (c *countingClient) GetUserName(_ context.Context, id int) (string, error) {
+	return c.name(CacheKindUser, id)
+}
+
+// cacheCounter считает попадания и промахи кэша
+type cacheCounter struct {
+	hits, misses int
+}
+
+func (o *cacheCounter) ObserveNameCache(_ string, hit bool) {
+	if hit {
+		o.hits++
+	} else {
+		o.misses++
+	}
+}
+
+func TestCachedClient_TTL(t *testing.T) {
+	inner := newCountingClient()
+	observer := &cacheCounter{}
+	cache := NewCachedClient(inner, time.Hour, WithCacheObserver(observer))
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		name, err := cache.GetProjectName(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("GetProjectName() error = %v", err)
+		}
+		if name != "project-1" {
+			t.Errorf("GetProjectName() = %q, want %q", name, "project-1")
+		}
+	}
+	if inner.calls[CacheKindProject] != 1 {
+		t.Errorf("got %d project name requests, want 1", inner.calls[CacheKindProject])
+	}
+	if observer.hits != 2 || observer.misses != 1 {
+		t.Errorf("hits = %d, misses = %d, want 2 and 1", observer.hits, observer.misses)
+	}
+
+	// Ключи разных типов владельцев не пересекаются
+	if name, _ := cache.GetGroupName(context.Background(), 1); name != "group-1" {
+		t.Errorf("GetGroupName() = %q, want %q", name, "group-1")
+	}
+
+	// После истечения TTL имя запрашивается повторно
+	now = now.Add(time.Hour)
+	cache.GetProjectName(context.Background(), 1)
+	if inner.calls[CacheKindProject] != 2 {
+		t.Errorf("got %d project name requests after TTL, want 2", inner.calls[CacheKindProject])
+	}
+}
+
+func TestCachedClient_NegativeCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		negativeTTL time.Duration
+		wantCalls   int
+	}{
+		{name: "not found is cached", negativeTTL: time.Minute, wantCalls: 1},
+		{name: "negative cache disabled", negativeTTL: 0, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newCountingClient()
+			inner.missing[42] = true
+			cache := NewCachedClient(inner, time.Hour, WithNegativeTTL(tt.negativeTTL))
+
+			for i := 0; i < 3; i++ {
+				if _, err := cache.GetUserName(context.Background(), 42); !errors.Is(err, gitlab.ErrNotFound) {
+					t.Fatalf("GetUserName() error = %v, want %v", err, gitlab.ErrNotFound)
+				}
+			}
+			if inner.calls[CacheKindUser] != tt.wantCalls {
+				t.Errorf("got %d user name requests, want %d", inner.calls[CacheKindUser], tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCachedClient_ReusesOwnerInfo(t *testing.T) {
+	requests := make(map[string]int)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups/7", func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 7, "name": "platform", "projects": [{"id": 1, "name": "api"}, {"id": 2, "name": "web"}]}`)
+	})
+	mux.HandleFunc("/api/v4/projects/3", func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 3, "name": "tools", "namespace": {"id": 8, "name": "infra", "kind": "group"}}`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	cache := NewCachedClient(client, time.Hour)
+	ctx := context.Background()
+
+	// Имена проектов берутся из ответа на запрос группы
+	if _, err := cache.GetGroupName(ctx, 7); err != nil {
+		t.Fatalf("GetGroupName() error = %v", err)
+	}
+	if name, err := cache.GetProjectName(ctx, 2); err != nil || name != "web" {
+		t.Errorf("GetProjectName() = %q, %v, want %q", name, err, "web")
+	}
+
+	// Имя группы берется из namespace проекта
+	if _, err := cache.GetProjectName(ctx, 3); err != nil {
+		t.Fatalf("GetProjectName() error = %v", err)
+	}
+	if name, err := cache.GetGroupName(ctx, 8); err != nil || name != "infra" {
+		t.Errorf("GetGroupName() = %q, %v, want %q", name, err, "infra")
+	}
+
+	if requests["/api/v4/groups/7"] != 1 || requests["/api/v4/projects/3"] != 1 {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
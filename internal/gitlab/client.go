@@ -1,47 +1,181 @@
 package gitlab
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 // GitLabClientInterface - интерфейс для клиента GitLab
 type GitLabClientInterface interface {
-	GetProjectAccessTokens(projectID int) ([]*gitlab.ProjectAccessToken, error)
-	GetProjectName(projectID int) (string, error)
-	GetUserAccessTokens() ([]*gitlab.PersonalAccessToken, error)
-	GetUserName(userID int) (string, error)
-	GetGroupAccessTokens(groupID int) ([]*gitlab.GroupAccessToken, error)
-	GetGroupName(groupID int) (string, error)
+	GetProjectAccessTokens(ctx context.Context, projectID int) ([]*gitlab.ProjectAccessToken, error)
+	GetProjectName(ctx context.Context, projectID int) (string, error)
+	GetUserAccessTokens(ctx context.Context) ([]*gitlab.PersonalAccessToken, error)
+	GetUserAccessTokensForUser(ctx context.Context, userID int) ([]*gitlab.PersonalAccessToken, error)
+	GetUserName(ctx context.Context, userID int) (string, error)
+	GetGroupAccessTokens(ctx context.Context, groupID int) ([]*gitlab.GroupAccessToken, error)
+	GetGroupName(ctx context.Context, groupID int) (string, error)
+	GetGroupProjects(ctx context.Context, groupID int, includeSubgroups, skipArchived bool) ([]*gitlab.Project, error)
+	GetDescendantGroups(ctx context.Context, groupID int) ([]*gitlab.Group, error)
+	GetAllProjects(ctx context.Context, skipArchived bool) ([]*gitlab.Project, error)
+	GetAllGroups(ctx context.Context) ([]*gitlab.Group, error)
+	GetProjectDeployTokens(ctx context.Context, projectID int) ([]*gitlab.DeployToken, error)
+	GetGroupDeployTokens(ctx context.Context, groupID int) ([]*gitlab.DeployToken, error)
+	GetPipelineTriggers(ctx context.Context, projectID int) ([]*gitlab.PipelineTrigger, error)
+	GetPipelineSchedules(ctx context.Context, projectID int) ([]*gitlab.PipelineSchedule, error)
+	GetProjectDeployKeys(ctx context.Context, projectID int) ([]*gitlab.ProjectDeployKey, error)
+	GetAllDeployKeys(ctx context.Context) ([]*gitlab.InstanceDeployKey, error)
+	GetAllUsers(ctx context.Context) ([]*gitlab.User, error)
+	GetProjectBotUserIDs(ctx context.Context) (map[int]bool, error)
+	GetUserSSHKeys(ctx context.Context, userID int) ([]*gitlab.SSHKey, error)
+	GetUserGPGKeys(ctx context.Context, userID int) ([]*gitlab.GPGKey, error)
+	GetProjectRunners(ctx context.Context, projectID int) ([]*gitlab.Runner, error)
+	GetGroupRunners(ctx context.Context, groupID int) ([]*gitlab.Runner, error)
+	GetAllRunners(ctx context.Context) ([]*gitlab.Runner, error)
+	GetRunnerDetails(ctx context.Context, runnerID int) (*RunnerDetails, error)
+	GetSelfAccessToken(ctx context.Context) (*gitlab.PersonalAccessToken, error)
+	RotateProjectAccessToken(ctx context.Context, projectID, tokenID int, expiresAt *time.Time) (*gitlab.ProjectAccessToken, error)
+	RotateGroupAccessToken(ctx context.Context, groupID, tokenID int, expiresAt *time.Time) (*gitlab.GroupAccessToken, error)
 	GetClient() *gitlab.Client
 }
 
 type Client struct {
-	client *gitlab.Client
+	client         *gitlab.Client
+	perPage        int
+	maxPages       int
+	requestTimeout time.Duration
+	tokenSource    TokenSource
+
+	retry          retryPolicy
+	rateLimit      float64
+	rateLimitBurst int
+
+	// names получает имена владельцев, попутно возвращенные API (см. NewCachedClient)
+	names nameRecorder
+}
+
+// Option - функциональная опция для настройки Client
+type Option func(*Client)
+
+// WithPerPage задает размер страницы для списочных запросов
+func WithPerPage(perPage int) Option {
+	return func(c *Client) {
+		c.perPage = perPage
+	}
+}
+
+// WithMaxPages ограничивает количество запрашиваемых страниц (0 - без ограничений)
+func WithMaxPages(maxPages int) Option {
+	return func(c *Client) {
+		c.maxPages = maxPages
+	}
+}
+
+// WithRequestTimeout ограничивает время выполнения одного запроса к API (0 - без ограничений)
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.requestTimeout = timeout
+	}
+}
+
+// WithRetry задает количество повторов и границы экспоненциальной задержки
+// для ответов 429, 5xx и сетевых ошибок (maxRetries = 0 отключает повторы)
+func WithRetry(maxRetries int, waitMin, waitMax time.Duration) Option {
+	return func(c *Client) {
+		c.retry.maxRetries = maxRetries
+		c.retry.waitMin = waitMin
+		c.retry.waitMax = waitMax
+	}
+}
+
+// WithRateLimit включает клиентский token bucket ограничитель частоты запросов
+// (requestsPerSecond <= 0 - без ограничений)
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(c *Client) {
+		c.rateLimit = requestsPerSecond
+		c.rateLimitBurst = burst
+	}
+}
+
+// WithObserver задает получателя событий ретраев и ожидания ограничителя
+func WithObserver(observer Observer) Option {
+	return func(c *Client) {
+		c.retry.observer = observer
+	}
 }
 
 // Убеждаемся, что Client реализует GitLabClientInterface
 var _ GitLabClientInterface = (*Client)(nil)
 
-func NewClient(token, baseURL string) (*Client, error) {
-	if token == "" {
+// NewClient создает клиент GitLab. token может быть пустым, если задан WithTokenSource.
+func NewClient(token, baseURL string, opts ...Option) (*Client, error) {
+	c := &Client{
+		retry: retryPolicy{
+			maxRetries: 5,
+			waitMin:    time.Second,
+			waitMax:    30 * time.Second,
+			observer:   nopObserver{},
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if token == "" && c.tokenSource == nil {
 		return nil, fmt.Errorf("gitlab token is required")
 	}
 	if baseURL == "" {
 		return nil, fmt.Errorf("gitlab base URL is required")
 	}
 
-	client, err := gitlab.NewClient(token, gitlab.WithBaseURL(baseURL))
+	clientOptions := []gitlab.ClientOptionFunc{
+		gitlab.WithBaseURL(baseURL),
+		gitlab.WithCustomRetry(c.retry.checkRetry),
+		gitlab.WithCustomBackoff(c.retry.backoff),
+		gitlab.WithCustomRetryMax(c.retry.maxRetries),
+		gitlab.WithCustomRetryWaitMinMax(c.retry.waitMin, c.retry.waitMax),
+	}
+	if c.rateLimit > 0 {
+		clientOptions = append(clientOptions, gitlab.WithCustomLimiter(newTokenBucketLimiter(c.rateLimit, c.rateLimitBurst, c.retry.observer)))
+	}
+
+	var client *gitlab.Client
+	var err error
+	if c.tokenSource != nil {
+		client, err = gitlab.NewAuthSourceClient(tokenAuthSource{source: c.tokenSource}, clientOptions...)
+	} else {
+		client, err = gitlab.NewClient(token, clientOptions...)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gitlab client: %w", err)
 	}
+	c.client = client
 
-	return &Client{client: client}, nil
+	return c, nil
 }
 
-func (c *Client) GetProjectAccessTokens(projectID int) ([]*gitlab.ProjectAccessToken, error) {
-	tokens, _, err := c.client.ProjectAccessTokens.ListProjectAccessTokens(projectID, nil)
+// requestContext возвращает контекст одного запроса с учетом таймаута клиента
+func (c *Client) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.requestTimeout > 0 {
+		return context.WithTimeout(ctx, c.requestTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func (c *Client) listOptions() gitlab.ListOptions {
+	return gitlab.ListOptions{PerPage: c.perPage}
+}
+
+func (c *Client) GetProjectAccessTokens(ctx context.Context, projectID int) ([]*gitlab.ProjectAccessToken, error) {
+	options := &gitlab.ListProjectAccessTokensOptions{
+		ListOptions: c.listOptions(),
+	}
+	tokens, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.ProjectAccessToken, *gitlab.Response, error) {
+		return c.client.ProjectAccessTokens.ListProjectAccessTokens(projectID, options, opts...)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list project access tokens: %w", err)
 	}
@@ -53,22 +187,38 @@ func (c *Client) GetClient() *gitlab.Client {
 	return c.client
 }
 
-func (c *Client) GetProjectName(projectID int) (string, error) {
-	project, _, err := c.client.Projects.GetProject(projectID, nil)
+func (c *Client) GetProjectName(ctx context.Context, projectID int) (string, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	project, _, err := c.client.Projects.GetProject(projectID, nil, gitlab.WithContext(ctx))
 	if err != nil {
 		return "", fmt.Errorf("failed to get project name: %w", err)
 	}
+
+	// Ответ уже содержит владельца проекта, сохраняем его имя для последующих запросов
+	if c.names != nil {
+		if project.Namespace != nil && project.Namespace.Kind == "group" {
+			c.names.rememberName(CacheKindGroup, project.Namespace.ID, project.Namespace.Name)
+		}
+		if project.Owner != nil {
+			c.names.rememberName(CacheKindUser, project.Owner.ID, project.Owner.Name)
+		}
+	}
 	return project.Name, nil
 }
 
-func (c *Client) GetUserAccessTokens() ([]*gitlab.PersonalAccessToken, error) {
+func (c *Client) GetUserAccessTokens(ctx context.Context) ([]*gitlab.PersonalAccessToken, error) {
 	state := "active"
 	revoked := false
 	options := &gitlab.ListPersonalAccessTokensOptions{
-		State:   &state,
-		Revoked: &revoked,
+		ListOptions: c.listOptions(),
+		State:       &state,
+		Revoked:     &revoked,
 	}
-	tokens, _, err := c.client.PersonalAccessTokens.ListPersonalAccessTokens(options)
+	tokens, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.PersonalAccessToken, *gitlab.Response, error) {
+		return c.client.PersonalAccessTokens.ListPersonalAccessTokens(options, opts...)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list user access tokens: %w", err)
 	}
@@ -76,22 +226,49 @@ func (c *Client) GetUserAccessTokens() ([]*gitlab.PersonalAccessToken, error) {
 	return tokens, nil
 }
 
-func (c *Client) GetUserName(userID int) (string, error) {
-	user, _, err := c.client.Users.GetUser(userID, gitlab.GetUsersOptions{})
+// GetUserAccessTokensForUser возвращает активные личные токены одного пользователя.
+// Токены других пользователей доступны только администратору.
+func (c *Client) GetUserAccessTokensForUser(ctx context.Context, userID int) ([]*gitlab.PersonalAccessToken, error) {
+	state := "active"
+	revoked := false
+	options := &gitlab.ListPersonalAccessTokensOptions{
+		ListOptions: c.listOptions(),
+		State:       &state,
+		Revoked:     &revoked,
+		UserID:      &userID,
+	}
+	tokens, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.PersonalAccessToken, *gitlab.Response, error) {
+		return c.client.PersonalAccessTokens.ListPersonalAccessTokens(options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens of user %d: %w", userID, err)
+	}
+
+	return tokens, nil
+}
+
+func (c *Client) GetUserName(ctx context.Context, userID int) (string, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	user, _, err := c.client.Users.GetUser(userID, gitlab.GetUsersOptions{}, gitlab.WithContext(ctx))
 	if err != nil {
 		return "", fmt.Errorf("failed to get user name: %w", err)
 	}
 	return user.Name, nil
 }
 
-func (c *Client) GetGroupAccessTokens(groupID int) ([]*gitlab.GroupAccessToken, error) {
+func (c *Client) GetGroupAccessTokens(ctx context.Context, groupID int) ([]*gitlab.GroupAccessToken, error) {
 	state := gitlab.AccessTokenStateActive
 	revoked := false
 	options := &gitlab.ListGroupAccessTokensOptions{
-		State:   &state,
-		Revoked: &revoked,
+		ListOptions: c.listOptions(),
+		State:       &state,
+		Revoked:     &revoked,
 	}
-	tokens, _, err := c.client.GroupAccessTokens.ListGroupAccessTokens(groupID, options)
+	tokens, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.GroupAccessToken, *gitlab.Response, error) {
+		return c.client.GroupAccessTokens.ListGroupAccessTokens(groupID, options, opts...)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list group access tokens: %w", err)
 	}
@@ -99,10 +276,305 @@ func (c *Client) GetGroupAccessTokens(groupID int) ([]*gitlab.GroupAccessToken,
 	return tokens, nil
 }
 
-func (c *Client) GetGroupName(groupID int) (string, error) {
-	group, _, err := c.client.Groups.GetGroup(groupID, nil)
+func (c *Client) GetGroupName(ctx context.Context, groupID int) (string, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	group, _, err := c.client.Groups.GetGroup(groupID, nil, gitlab.WithContext(ctx))
 	if err != nil {
 		return "", fmt.Errorf("failed to get group name: %w", err)
 	}
+
+	// Ответ содержит проекты группы, сохраняем их имена для последующих запросов
+	if c.names != nil {
+		for _, project := range group.Projects {
+			c.names.rememberName(CacheKindProject, project.ID, project.Name)
+		}
+	}
 	return group.Name, nil
 }
+
+// GetGroupProjects возвращает проекты группы, с includeSubgroups - также проекты всех подгрупп
+func (c *Client) GetGroupProjects(ctx context.Context, groupID int, includeSubgroups, skipArchived bool) ([]*gitlab.Project, error) {
+	withShared := false
+	options := &gitlab.ListGroupProjectsOptions{
+		ListOptions:      c.listOptions(),
+		IncludeSubGroups: &includeSubgroups,
+		WithShared:       &withShared,
+	}
+	if skipArchived {
+		archived := false
+		options.Archived = &archived
+	}
+	projects, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+		return c.client.Groups.ListGroupProjects(groupID, options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group projects: %w", err)
+	}
+
+	if c.names != nil {
+		for _, project := range projects {
+			c.names.rememberName(CacheKindProject, project.ID, project.Name)
+		}
+	}
+	return projects, nil
+}
+
+// GetDescendantGroups возвращает все подгруппы группы на любом уровне вложенности
+func (c *Client) GetDescendantGroups(ctx context.Context, groupID int) ([]*gitlab.Group, error) {
+	options := &gitlab.ListDescendantGroupsOptions{
+		ListOptions: c.listOptions(),
+	}
+	groups, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.Group, *gitlab.Response, error) {
+		return c.client.Groups.ListDescendantGroups(groupID, options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list descendant groups: %w", err)
+	}
+
+	if c.names != nil {
+		for _, group := range groups {
+			c.names.rememberName(CacheKindGroup, group.ID, group.Name)
+		}
+	}
+	return groups, nil
+}
+
+// GetAllProjects возвращает все проекты инстанса, доступные токену (для администратора - все).
+// Используется keyset-пагинация, которая не деградирует на больших инстансах.
+func (c *Client) GetAllProjects(ctx context.Context, skipArchived bool) ([]*gitlab.Project, error) {
+	listOptions := c.listOptions()
+	listOptions.Pagination = "keyset"
+	orderBy := "id"
+	sort := "asc"
+	simple := true
+	options := &gitlab.ListProjectsOptions{
+		ListOptions: listOptions,
+		OrderBy:     &orderBy,
+		Sort:        &sort,
+		Simple:      &simple,
+	}
+	if skipArchived {
+		archived := false
+		options.Archived = &archived
+	}
+	projects, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+		return c.client.Projects.ListProjects(options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	if c.names != nil {
+		for _, project := range projects {
+			c.names.rememberName(CacheKindProject, project.ID, project.Name)
+		}
+	}
+	return projects, nil
+}
+
+// GetAllGroups возвращает все группы инстанса, доступные токену (для администратора - все)
+func (c *Client) GetAllGroups(ctx context.Context) ([]*gitlab.Group, error) {
+	allAvailable := true
+	options := &gitlab.ListGroupsOptions{
+		ListOptions:  c.listOptions(),
+		AllAvailable: &allAvailable,
+	}
+	groups, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.Group, *gitlab.Response, error) {
+		return c.client.Groups.ListGroups(options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	if c.names != nil {
+		for _, group := range groups {
+			c.names.rememberName(CacheKindGroup, group.ID, group.Name)
+		}
+	}
+	return groups, nil
+}
+
+func (c *Client) GetProjectDeployTokens(ctx context.Context, projectID int) ([]*gitlab.DeployToken, error) {
+	options := gitlab.ListProjectDeployTokensOptions(c.listOptions())
+	tokens, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.DeployToken, *gitlab.Response, error) {
+		return c.client.DeployTokens.ListProjectDeployTokens(projectID, &options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project deploy tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (c *Client) GetGroupDeployTokens(ctx context.Context, groupID int) ([]*gitlab.DeployToken, error) {
+	options := gitlab.ListGroupDeployTokensOptions(c.listOptions())
+	tokens, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.DeployToken, *gitlab.Response, error) {
+		return c.client.DeployTokens.ListGroupDeployTokens(groupID, &options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group deploy tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (c *Client) GetPipelineTriggers(ctx context.Context, projectID int) ([]*gitlab.PipelineTrigger, error) {
+	options := gitlab.ListPipelineTriggersOptions(c.listOptions())
+	triggers, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.PipelineTrigger, *gitlab.Response, error) {
+		return c.client.PipelineTriggers.ListPipelineTriggers(projectID, &options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipeline triggers: %w", err)
+	}
+
+	return triggers, nil
+}
+
+func (c *Client) GetPipelineSchedules(ctx context.Context, projectID int) ([]*gitlab.PipelineSchedule, error) {
+	options := &gitlab.ListPipelineSchedulesOptions{
+		ListOptions: c.listOptions(),
+	}
+	schedules, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.PipelineSchedule, *gitlab.Response, error) {
+		return c.client.PipelineSchedules.ListPipelineSchedules(projectID, options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipeline schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (c *Client) GetProjectDeployKeys(ctx context.Context, projectID int) ([]*gitlab.ProjectDeployKey, error) {
+	options := gitlab.ListProjectDeployKeysOptions(c.listOptions())
+	keys, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.ProjectDeployKey, *gitlab.Response, error) {
+		return c.client.DeployKeys.ListProjectDeployKeys(projectID, &options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project deploy keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// GetAllDeployKeys возвращает все deploy-ключи инстанса вместе с проектами, к которым они подключены.
+// Требует токен администратора.
+func (c *Client) GetAllDeployKeys(ctx context.Context) ([]*gitlab.InstanceDeployKey, error) {
+	options := &gitlab.ListInstanceDeployKeysOptions{
+		ListOptions: c.listOptions(),
+	}
+	keys, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.InstanceDeployKey, *gitlab.Response, error) {
+		return c.client.DeployKeys.ListAllDeployKeys(options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deploy keys: %w", err)
+	}
+
+	if c.names != nil {
+		for _, key := range keys {
+			for _, project := range slices.Concat(key.ProjectsWithWriteAccess, key.ProjectsWithReadonlyAccess) {
+				c.names.rememberName(CacheKindProject, project.ID, project.Name)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// GetAllUsers возвращает всех активных пользователей инстанса, кроме ботов проектов и групп
+func (c *Client) GetAllUsers(ctx context.Context) ([]*gitlab.User, error) {
+	listOptions := c.listOptions()
+	listOptions.Pagination = "keyset"
+	orderBy := "id"
+	sort := "asc"
+	active := true
+	withoutBots := true
+	options := &gitlab.ListUsersOptions{
+		ListOptions:        listOptions,
+		OrderBy:            &orderBy,
+		Sort:               &sort,
+		Active:             &active,
+		WithoutProjectBots: &withoutBots,
+	}
+	users, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.User, *gitlab.Response, error) {
+		return c.client.Users.ListUsers(options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	if c.names != nil {
+		for _, user := range users {
+			c.names.rememberName(CacheKindUser, user.ID, user.Name)
+		}
+	}
+	return users, nil
+}
+
+// GetProjectBotUserIDs возвращает ID пользователей-ботов токенов проектов и групп. GitLab не
+// позволяет запросить только их, поэтому из списка ботов исключаются те, что остаются при
+// without_project_bots (служебные учетные записи и внутренние боты).
+func (c *Client) GetProjectBotUserIDs(ctx context.Context) (map[int]bool, error) {
+	bots, err := c.listBotUsers(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	otherBots, err := c.listBotUsers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[int]bool, len(bots))
+	for _, user := range bots {
+		ids[user.ID] = true
+	}
+	for _, user := range otherBots {
+		delete(ids, user.ID)
+	}
+	return ids, nil
+}
+
+func (c *Client) listBotUsers(ctx context.Context, withoutProjectBots bool) ([]*gitlab.User, error) {
+	listOptions := c.listOptions()
+	listOptions.Pagination = "keyset"
+	orderBy := "id"
+	sort := "asc"
+	excludeHumans := true
+	options := &gitlab.ListUsersOptions{
+		ListOptions:        listOptions,
+		OrderBy:            &orderBy,
+		Sort:               &sort,
+		ExcludeHumans:      &excludeHumans,
+		WithoutProjectBots: &withoutProjectBots,
+	}
+	users, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.User, *gitlab.Response, error) {
+		return c.client.Users.ListUsers(options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bot users: %w", err)
+	}
+	return users, nil
+}
+
+func (c *Client) GetUserSSHKeys(ctx context.Context, userID int) ([]*gitlab.SSHKey, error) {
+	options := gitlab.ListSSHKeysForUserOptions(c.listOptions())
+	keys, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.SSHKey, *gitlab.Response, error) {
+		return c.client.Users.ListSSHKeysForUser(userID, &options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user SSH keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (c *Client) GetUserGPGKeys(ctx context.Context, userID int) ([]*gitlab.GPGKey, error) {
+	keys, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.GPGKey, *gitlab.Response, error) {
+		return c.client.Users.ListGPGKeysForUser(userID, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user GPG keys: %w", err)
+	}
+
+	return keys, nil
+}
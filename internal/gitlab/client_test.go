@@ -1,7 +1,13 @@
 package gitlab
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -67,3 +73,348 @@ func TestClient_GetClient(t *testing.T) {
 		t.Error("GetClient() returned nil")
 	}
 }
+
+// newHangingServer имитирует зависший GitLab, который не отвечает до закрытия сервера
+func newHangingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	return server
+}
+
+func TestClient_RequestTimeout(t *testing.T) {
+	server := newHangingServer(t)
+
+	client, err := NewClient("test-token", server.URL, WithRequestTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.GetProjectName(context.Background(), 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetProjectName() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %v, expected to be aborted by timeout", elapsed)
+	}
+}
+
+func TestClient_ContextCancel(t *testing.T) {
+	server := newHangingServer(t)
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = client.GetProjectAccessTokens(ctx, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetProjectAccessTokens() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %v, expected to be aborted by cancellation", elapsed)
+	}
+}
+
+func TestClient_GetGroupProjects(t *testing.T) {
+	tests := []struct {
+		name             string
+		includeSubgroups bool
+		skipArchived     bool
+		wantQuery        map[string]string
+	}{
+		{
+			name:             "subgroups without archived",
+			includeSubgroups: true,
+			skipArchived:     true,
+			wantQuery:        map[string]string{"include_subgroups": "true", "archived": "false", "with_shared": "false"},
+		},
+		{
+			name:      "direct projects with archived",
+			wantQuery: map[string]string{"include_subgroups": "false", "archived": "", "with_shared": "false"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v4/groups/10/projects" {
+					t.Errorf("unexpected request %s", r.URL.Path)
+				}
+				for key, want := range tt.wantQuery {
+					if got := r.URL.Query().Get(key); got != want {
+						t.Errorf("%s = %q, want %q", key, got, want)
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[{"id": 1, "name": "api"}, {"id": 2, "name": "web"}]`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient("test-token", server.URL)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			projects, err := client.GetGroupProjects(context.Background(), 10, tt.includeSubgroups, tt.skipArchived)
+			if err != nil {
+				t.Fatalf("GetGroupProjects() error = %v", err)
+			}
+			if len(projects) != 2 {
+				t.Errorf("got %d projects, want 2", len(projects))
+			}
+		})
+	}
+}
+
+func TestClient_GetAllProjects_Keyset(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("pagination") != "keyset" || query.Get("order_by") != "id" || query.Get("archived") != "false" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if query.Get("id_after") == "" {
+			w.Header().Set("Link", `<`+server.URL+`/api/v4/projects?archived=false&id_after=1&order_by=id&pagination=keyset&per_page=1&simple=true&sort=asc>; rel="next"`)
+			w.Write([]byte(`[{"id": 1, "name": "api"}]`))
+			return
+		}
+		w.Write([]byte(`[{"id": 2, "name": "web"}]`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL, WithPerPage(1))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	projects, err := client.GetAllProjects(context.Background(), true)
+	if err != nil {
+		t.Fatalf("GetAllProjects() error = %v", err)
+	}
+	if len(projects) != 2 {
+		t.Errorf("got %d projects, want 2", len(projects))
+	}
+}
+
+func TestClient_GetDeployTokens(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/1/deploy_tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 10, "name": "registry", "username": "gitlab+deploy-token-10", "expires_at": "2030-01-01T00:00:00.000Z", "scopes": ["read_registry"]}]`))
+	})
+	mux.HandleFunc("/api/v4/groups/5/deploy_tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 11, "name": "old", "username": "deployer", "revoked": true}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	projectTokens, err := client.GetProjectDeployTokens(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetProjectDeployTokens() error = %v", err)
+	}
+	if len(projectTokens) != 1 || projectTokens[0].ExpiresAt == nil || projectTokens[0].Username != "gitlab+deploy-token-10" {
+		t.Errorf("unexpected project deploy tokens: %+v", projectTokens)
+	}
+
+	groupTokens, err := client.GetGroupDeployTokens(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("GetGroupDeployTokens() error = %v", err)
+	}
+	if len(groupTokens) != 1 || !groupTokens[0].Revoked {
+		t.Errorf("unexpected group deploy tokens: %+v", groupTokens)
+	}
+}
+
+func TestClient_GetUserAccessTokensForUser(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/personal_access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("user_id"); got != "42" {
+			t.Errorf("user_id = %q, want %q", got, "42")
+		}
+		if got := r.URL.Query().Get("state"); got != "active" {
+			t.Errorf("state = %q, want %q", got, "active")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 7, "name": "ci", "user_id": 42}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	tokens, err := client.GetUserAccessTokensForUser(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("GetUserAccessTokensForUser() error = %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].UserID != 42 {
+		t.Errorf("unexpected tokens: %+v", tokens)
+	}
+}
+
+func TestClient_GetProjectBotUserIDs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/users", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("exclude_humans") != "true" || query.Get("pagination") != "keyset" {
+			t.Errorf("unexpected users query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// Без ботов проектов и групп остается служебная учетная запись
+		if query.Get("without_project_bots") == "true" {
+			w.Write([]byte(`[{"id": 30, "username": "service_account", "bot": true}]`))
+			return
+		}
+		w.Write([]byte(`[{"id": 10, "username": "project_1_bot_abc", "bot": true}, {"id": 20, "username": "group_5_bot_def", "bot": true}, {"id": 30, "username": "service_account", "bot": true}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ids, err := client.GetProjectBotUserIDs(context.Background())
+	if err != nil {
+		t.Fatalf("GetProjectBotUserIDs() error = %v", err)
+	}
+	if want := map[int]bool{10: true, 20: true}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("GetProjectBotUserIDs() = %v, want %v", ids, want)
+	}
+}
+
+func TestClient_GetPipelineResources(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/1/triggers", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 10, "description": "deploy", "last_used": "2025-01-01T00:00:00.000Z", "owner": {"id": 3, "username": "alice", "state": "blocked"}}]`))
+	})
+	mux.HandleFunc("/api/v4/projects/1/pipeline_schedules", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 20, "description": "nightly", "active": true, "owner": {"id": 4, "username": "bob", "state": "active"}}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	triggers, err := client.GetPipelineTriggers(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetPipelineTriggers() error = %v", err)
+	}
+	if len(triggers) != 1 || triggers[0].LastUsed == nil || triggers[0].Owner == nil || triggers[0].Owner.State != "blocked" {
+		t.Errorf("unexpected pipeline triggers: %+v", triggers)
+	}
+
+	schedules, err := client.GetPipelineSchedules(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetPipelineSchedules() error = %v", err)
+	}
+	if len(schedules) != 1 || !schedules[0].Active || schedules[0].Owner == nil || schedules[0].Owner.Username != "bob" {
+		t.Errorf("unexpected pipeline schedules: %+v", schedules)
+	}
+}
+
+func TestClient_GetKeys(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/1/deploy_keys", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 10, "title": "ci", "fingerprint_sha256": "SHA256:abc", "expires_at": "2030-01-01T00:00:00.000Z"}]`))
+	})
+	mux.HandleFunc("/api/v4/deploy_keys", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 11, "title": "shared", "projects_with_write_access": [{"id": 1, "name": "api"}]}]`))
+	})
+	mux.HandleFunc("/api/v4/users", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("active") != "true" || query.Get("without_project_bots") != "true" || query.Get("pagination") != "keyset" {
+			t.Errorf("unexpected users query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 42, "username": "alice", "name": "Alice"}]`))
+	})
+	mux.HandleFunc("/api/v4/users/42/keys", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 12, "title": "laptop", "key": "ssh-ed25519 AAAA", "expires_at": "2030-01-01T00:00:00.000Z"}]`))
+	})
+	mux.HandleFunc("/api/v4/users/42/gpg_keys", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 13, "key": "-----BEGIN PGP PUBLIC KEY BLOCK-----"}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ctx := context.Background()
+
+	deployKeys, err := client.GetProjectDeployKeys(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetProjectDeployKeys() error = %v", err)
+	}
+	if len(deployKeys) != 1 || deployKeys[0].FingerprintSHA256 != "SHA256:abc" || deployKeys[0].ExpiresAt == nil {
+		t.Errorf("unexpected project deploy keys: %+v", deployKeys)
+	}
+
+	instanceKeys, err := client.GetAllDeployKeys(ctx)
+	if err != nil {
+		t.Fatalf("GetAllDeployKeys() error = %v", err)
+	}
+	if len(instanceKeys) != 1 || len(instanceKeys[0].ProjectsWithWriteAccess) != 1 {
+		t.Errorf("unexpected instance deploy keys: %+v", instanceKeys)
+	}
+
+	users, err := client.GetAllUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetAllUsers() error = %v", err)
+	}
+	if len(users) != 1 || users[0].ID != 42 {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+
+	sshKeys, err := client.GetUserSSHKeys(ctx, 42)
+	if err != nil {
+		t.Fatalf("GetUserSSHKeys() error = %v", err)
+	}
+	if len(sshKeys) != 1 || sshKeys[0].ExpiresAt == nil {
+		t.Errorf("unexpected SSH keys: %+v", sshKeys)
+	}
+
+	gpgKeys, err := client.GetUserGPGKeys(ctx, 42)
+	if err != nil {
+		t.Fatalf("GetUserGPGKeys() error = %v", err)
+	}
+	if len(gpgKeys) != 1 || gpgKeys[0].ID != 13 {
+		t.Errorf("unexpected GPG keys: %+v", gpgKeys)
+	}
+}
@@ -0,0 +1,43 @@
+package gitlab
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// tokenBucketLimiter ограничивает частоту запросов к API и сообщает о времени ожидания
+type tokenBucketLimiter struct {
+	limiter  *rate.Limiter
+	observer Observer
+}
+
+func newTokenBucketLimiter(requestsPerSecond float64, burst int, observer Observer) *tokenBucketLimiter {
+	return &tokenBucketLimiter{
+		limiter:  rate.NewLimiter(rate.Limit(requestsPerSecond), max(burst, 1)),
+		observer: observer,
+	}
+}
+
+// Wait блокирует вызов до получения токена или отмены контекста
+func (l *tokenBucketLimiter) Wait(ctx context.Context) error {
+	reservation := l.limiter.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	l.observer.ObserveThrottle(delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	}
+}
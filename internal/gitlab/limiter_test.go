@@ -0,0 +1,43 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketLimiter_Wait(t *testing.T) {
+	observer := newRecordingObserver()
+	limiter := newTokenBucketLimiter(50, 1, observer)
+
+	// Первый запрос проходит сразу, остальные ждут пополнения корзины
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("5 requests at 50 rps took %v, want at least 60ms", elapsed)
+	}
+	if len(observer.throttles) != 4 {
+		t.Errorf("got %d throttle waits, want 4", len(observer.throttles))
+	}
+}
+
+func TestTokenBucketLimiter_WaitCancelled(t *testing.T) {
+	limiter := newTokenBucketLimiter(0.1, 1, nopObserver{})
+
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
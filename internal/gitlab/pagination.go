@@ -0,0 +1,47 @@
+package gitlab
+
+import (
+	"context"
+	"log"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// pageFetcher - функция, запрашивающая одну страницу результатов
+type pageFetcher[T any] func(options ...gitlab.RequestOptionFunc) ([]T, *gitlab.Response, error)
+
+// collectPages обходит все страницы выдачи, поддерживая как offset-пагинацию
+// (заголовок X-Next-Page), так и keyset-пагинацию (заголовок Link).
+// Каждая страница запрашивается с собственным таймаутом клиента.
+func collectPages[T any](ctx context.Context, c *Client, fetch pageFetcher[T]) ([]T, error) {
+	var items []T
+	var options []gitlab.RequestOptionFunc
+
+	for page := 1; ; page++ {
+		reqCtx, cancel := c.requestContext(ctx)
+		pageItems, resp, err := fetch(append(options, gitlab.WithContext(reqCtx))...)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, pageItems...)
+
+		if resp == nil {
+			return items, nil
+		}
+
+		switch {
+		case resp.NextLink != "":
+			options = []gitlab.RequestOptionFunc{gitlab.WithKeysetPaginationParameters(resp.NextLink)}
+		case resp.NextPage != 0:
+			options = []gitlab.RequestOptionFunc{gitlab.WithOffsetPaginationParameters(resp.NextPage)}
+		default:
+			return items, nil
+		}
+
+		if c.maxPages > 0 && page >= c.maxPages {
+			log.Printf("Pagination limit of %d pages reached, remaining results are skipped", c.maxPages)
+			return items, nil
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// newPagedServer отдает totalPages страниц по одному токену с offset-пагинацией
+func newPagedServer(t *testing.T, path string, totalPages int) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.URL.Query().Get("per_page"); got != "1" {
+			t.Errorf("per_page = %q, want %q", got, "1")
+		}
+
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			page, _ = strconv.Atoi(p)
+		}
+		if page < totalPages {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `[{"id": %d, "name": "token-%d"}]`, page, page)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestClient_GetProjectAccessTokens_Pagination(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxPages     int
+		wantTokens   int
+		wantRequests int
+	}{
+		{name: "all pages", maxPages: 0, wantTokens: 3, wantRequests: 3},
+		{name: "limited pages", maxPages: 2, wantTokens: 2, wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newPagedServer(t, "/api/v4/projects/1/access_tokens", 3)
+
+			client, err := NewClient("test-token", server.URL, WithPerPage(1), WithMaxPages(tt.maxPages))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			tokens, err := client.GetProjectAccessTokens(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("GetProjectAccessTokens() error = %v", err)
+			}
+			if len(tokens) != tt.wantTokens {
+				t.Errorf("got %d tokens, want %d", len(tokens), tt.wantTokens)
+			}
+			if *requests != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", *requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestClient_GetGroupAccessTokens_Pagination(t *testing.T) {
+	server, _ := newPagedServer(t, "/api/v4/groups/7/access_tokens", 4)
+
+	client, err := NewClient("test-token", server.URL, WithPerPage(1))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	tokens, err := client.GetGroupAccessTokens(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetGroupAccessTokens() error = %v", err)
+	}
+	if len(tokens) != 4 {
+		t.Errorf("got %d tokens, want 4", len(tokens))
+	}
+}
+
+func TestClient_GetUserAccessTokens_KeysetPagination(t *testing.T) {
+	var server *httptest.Server
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/personal_access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v4/personal_access_tokens?cursor=second&per_page=1>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"id": 1, "name": "first", "user_id": 10}]`)
+		case "second":
+			fmt.Fprint(w, `[{"id": 2, "name": "second", "user_id": 11}]`)
+		default:
+			t.Errorf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL, WithPerPage(1))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	tokens, err := client.GetUserAccessTokens(context.Background())
+	if err != nil {
+		t.Fatalf("GetUserAccessTokens() error = %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("got %d tokens, want 2", len(tokens))
+	}
+	if tokens[1].Name != "second" {
+		t.Errorf("tokens[1].Name = %q, want %q", tokens[1].Name, "second")
+	}
+}
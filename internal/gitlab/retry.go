@@ -0,0 +1,110 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+)
+
+// Причины повторных запросов для метрик
+const (
+	RetryReasonRateLimited = "rate_limited"
+	RetryReasonServerError = "server_error"
+	RetryReasonNetworkErr  = "network_error"
+)
+
+// Observer получает события ретраев и ожидания ограничителя частоты запросов
+type Observer interface {
+	ObserveRetry(reason string)
+	ObserveThrottle(wait time.Duration)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) ObserveRetry(string)           {}
+func (nopObserver) ObserveThrottle(time.Duration) {}
+
+// retryPolicy - политика повторных запросов с экспоненциальной задержкой
+type retryPolicy struct {
+	maxRetries int
+	waitMin    time.Duration
+	waitMax    time.Duration
+	observer   Observer
+}
+
+// checkRetry повторяет запросы при 429, 5xx и сетевых ошибках, но не после отмены контекста
+func (p *retryPolicy) checkRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return false, err
+	}
+	return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+}
+
+// backoff вычисляет задержку перед повтором. Заголовки Retry-After и RateLimit-Reset
+// имеют приоритет, иначе используется экспоненциальная задержка с полным джиттером.
+func (p *retryPolicy) backoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	p.observer.ObserveRetry(retryReason(resp))
+
+	if wait, ok := headerWait(resp, time.Now()); ok {
+		// Небольшой джиттер, чтобы параллельные воркеры не вернулись одновременно
+		return wait + jitter(min)
+	}
+
+	wait := min << attemptNum
+	if wait <= 0 || wait > max {
+		wait = max
+	}
+	return jitter(wait)
+}
+
+// retryReason определяет причину повтора по ответу сервера
+func retryReason(resp *http.Response) string {
+	switch {
+	case resp == nil:
+		return RetryReasonNetworkErr
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return RetryReasonRateLimited
+	default:
+		return RetryReasonServerError
+	}
+}
+
+// headerWait извлекает время ожидания из заголовков Retry-After и RateLimit-Reset
+func headerWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if date, err := http.ParseTime(v); err == nil {
+			return max(date.Sub(now), 0), true
+		}
+	}
+
+	if v := resp.Header.Get("RateLimit-Reset"); v != "" {
+		if reset, err := strconv.ParseInt(v, 10, 64); err == nil && reset > 0 {
+			return max(time.Unix(reset, 0).Sub(now), 0), true
+		}
+	}
+
+	return 0, false
+}
+
+// jitter возвращает случайную длительность из [0, d)
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
@@ -0,0 +1,173 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingObserver запоминает события ретраев и ожидания ограничителя
+type recordingObserver struct {
+	mu        sync.Mutex
+	retries   map[string]int
+	throttles []time.Duration
+}
+
+func newRecordingObserver() *recordingObserver {
+	return &recordingObserver{retries: make(map[string]int)}
+}
+
+func (o *recordingObserver) ObserveRetry(reason string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.retries[reason]++
+}
+
+func (o *recordingObserver) ObserveThrottle(wait time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.throttles = append(o.throttles, wait)
+}
+
+func TestHeaderWait(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantWait time.Duration
+		wantOK   bool
+	}{
+		{name: "no headers", wantOK: false},
+		{name: "retry after seconds", headers: map[string]string{"Retry-After": "7"}, wantWait: 7 * time.Second, wantOK: true},
+		{name: "retry after date", headers: map[string]string{"Retry-After": now.Add(3 * time.Second).Format(http.TimeFormat)}, wantWait: 3 * time.Second, wantOK: true},
+		{name: "retry after in the past", headers: map[string]string{"Retry-After": now.Add(-time.Minute).Format(http.TimeFormat)}, wantWait: 0, wantOK: true},
+		{name: "ratelimit reset", headers: map[string]string{"RateLimit-Reset": strconv.FormatInt(now.Add(10*time.Second).Unix(), 10)}, wantWait: 10 * time.Second, wantOK: true},
+		{name: "retry after takes precedence", headers: map[string]string{"Retry-After": "2", "RateLimit-Reset": strconv.FormatInt(now.Add(time.Minute).Unix(), 10)}, wantWait: 2 * time.Second, wantOK: true},
+		{name: "invalid values", headers: map[string]string{"Retry-After": "soon", "RateLimit-Reset": "later"}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			for key, value := range tt.headers {
+				resp.Header.Set(key, value)
+			}
+
+			wait, ok := headerWait(resp, now)
+			if ok != tt.wantOK {
+				t.Fatalf("headerWait() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if wait != tt.wantWait {
+				t.Errorf("headerWait() = %v, want %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	observer := newRecordingObserver()
+	policy := &retryPolicy{observer: observer}
+	waitMin, waitMax := 100*time.Millisecond, time.Second
+
+	// Экспоненциальная задержка с джиттером не превышает min*2^attempt и max
+	for attempt := 0; attempt < 10; attempt++ {
+		limit := min(waitMin<<attempt, waitMax)
+		resp := &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}
+		if wait := policy.backoff(waitMin, waitMax, attempt, resp); wait < 0 || wait >= limit {
+			t.Errorf("attempt %d: backoff = %v, want in [0, %v)", attempt, wait, limit)
+		}
+	}
+
+	// Заголовок Retry-After имеет приоритет над экспоненциальной задержкой
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"5"}}}
+	if wait := policy.backoff(waitMin, waitMax, 0, resp); wait < 5*time.Second || wait >= 5*time.Second+waitMin {
+		t.Errorf("backoff with Retry-After = %v, want about 5s", wait)
+	}
+
+	policy.backoff(waitMin, waitMax, 0, nil)
+
+	if observer.retries[RetryReasonServerError] != 10 || observer.retries[RetryReasonRateLimited] != 1 || observer.retries[RetryReasonNetworkErr] != 1 {
+		t.Errorf("unexpected retry reasons: %v", observer.retries)
+	}
+}
+
+func TestClient_RetriesTransientErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		header     map[string]string
+		wantReason string
+	}{
+		{name: "rate limited", status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "0"}, wantReason: RetryReasonRateLimited},
+		{name: "server error", status: http.StatusServiceUnavailable, wantReason: RetryReasonServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				// Первые два запроса завершаются временной ошибкой
+				if requests <= 2 {
+					for key, value := range tt.header {
+						w.Header().Set(key, value)
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"id": 1, "name": "project"}`))
+			}))
+			defer server.Close()
+
+			observer := newRecordingObserver()
+			client, err := NewClient("test-token", server.URL,
+				WithRetry(3, time.Millisecond, 10*time.Millisecond),
+				WithObserver(observer),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			name, err := client.GetProjectName(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("GetProjectName() error = %v", err)
+			}
+			if name != "project" {
+				t.Errorf("GetProjectName() = %q, want %q", name, "project")
+			}
+			if requests != 3 {
+				t.Errorf("got %d requests, want 3", requests)
+			}
+			if got := observer.retries[tt.wantReason]; got != 2 {
+				t.Errorf("retries[%s] = %d, want 2", tt.wantReason, got)
+			}
+		})
+	}
+}
+
+func TestClient_RetriesExhausted(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL, WithRetry(2, time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.GetProjectName(context.Background(), 1); err == nil {
+		t.Error("GetProjectName() expected error after exhausted retries")
+	}
+	if requests != 3 {
+		t.Errorf("got %d requests, want 3 (1 + 2 retries)", requests)
+	}
+}
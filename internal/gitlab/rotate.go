@@ -0,0 +1,60 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// RotateProjectAccessToken отзывает токен проекта и создает ему замену с новым секретом.
+// expiresAt - срок действия нового токена (nil - срок по умолчанию GitLab, одна неделя).
+func (c *Client) RotateProjectAccessToken(ctx context.Context, projectID, tokenID int, expiresAt *time.Time) (*gitlab.ProjectAccessToken, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	options := &gitlab.RotateProjectAccessTokenOptions{ExpiresAt: isoTime(expiresAt)}
+	token, _, err := c.client.ProjectAccessTokens.RotateProjectAccessToken(projectID, tokenID, options,
+		gitlab.WithContext(ctx), gitlab.WithRequestRetry(c.retry.checkRotateRetry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate project access token: %w", err)
+	}
+	return token, nil
+}
+
+// RotateGroupAccessToken отзывает токен группы и создает ему замену с новым секретом.
+// expiresAt - срок действия нового токена (nil - срок по умолчанию GitLab, одна неделя).
+func (c *Client) RotateGroupAccessToken(ctx context.Context, groupID, tokenID int, expiresAt *time.Time) (*gitlab.GroupAccessToken, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	options := &gitlab.RotateGroupAccessTokenOptions{ExpiresAt: isoTime(expiresAt)}
+	token, _, err := c.client.GroupAccessTokens.RotateGroupAccessToken(groupID, tokenID, options,
+		gitlab.WithContext(ctx), gitlab.WithRequestRetry(c.retry.checkRotateRetry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate group access token: %w", err)
+	}
+	return token, nil
+}
+
+// checkRotateRetry повторяет ротацию только после 429: такой запрос GitLab не выполнял.
+// После 5xx или сетевой ошибки старый токен мог быть уже отозван, а повтор потерял бы новый секрет.
+func (p *retryPolicy) checkRotateRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err == nil && resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true, nil
+	}
+	return false, nil
+}
+
+func isoTime(t *time.Time) *gitlab.ISOTime {
+	if t == nil {
+		return nil
+	}
+	date := gitlab.ISOTime(*t)
+	return &date
+}
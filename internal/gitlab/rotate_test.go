@@ -0,0 +1,94 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_RotateAccessToken(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	handle := func(path string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				t.Errorf("method = %s, want POST", r.Method)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("Failed to decode request: %v", err)
+			}
+			if body["expires_at"] != "2030-01-01" {
+				t.Errorf("expires_at = %q, want %q", body["expires_at"], "2030-01-01")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": 8, "name": "deploy", "token": "glpat-new", "expires_at": "2030-01-01"}`))
+		})
+	}
+	handle("/api/v4/projects/1/access_tokens/7/rotate")
+	handle("/api/v4/groups/2/access_tokens/7/rotate")
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	projectToken, err := client.RotateProjectAccessToken(context.Background(), 1, 7, &expiresAt)
+	if err != nil {
+		t.Fatalf("RotateProjectAccessToken() error = %v", err)
+	}
+	if projectToken.ID != 8 || projectToken.Token != "glpat-new" {
+		t.Errorf("unexpected project token: %+v", projectToken)
+	}
+
+	groupToken, err := client.RotateGroupAccessToken(context.Background(), 2, 7, &expiresAt)
+	if err != nil {
+		t.Fatalf("RotateGroupAccessToken() error = %v", err)
+	}
+	if groupToken.ID != 8 || groupToken.Token != "glpat-new" {
+		t.Errorf("unexpected group token: %+v", groupToken)
+	}
+}
+
+func TestClient_RotateAccessToken_Retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int32
+	}{
+		// 429 GitLab отклоняет до выполнения запроса, поэтому повтор безопасен
+		{name: "rate limited", status: http.StatusTooManyRequests, wantCalls: 3},
+		// После 5xx старый токен мог быть уже отозван
+		{name: "server error", status: http.StatusInternalServerError, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client, err := NewClient("test-token", server.URL, WithRetry(2, time.Millisecond, time.Millisecond))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			if _, err := client.RotateProjectAccessToken(context.Background(), 1, 7, nil); err == nil {
+				t.Error("expected rotation error")
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("requests = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// Типы раннеров для фильтрации списков
+const (
+	runnerTypeProject = "project_type"
+	runnerTypeGroup   = "group_type"
+)
+
+// RunnerDetails - подробности раннера вместе со сроком действия токена аутентификации,
+// которого нет в gitlab.RunnerDetails
+type RunnerDetails struct {
+	gitlab.RunnerDetails
+	TokenExpiresAt *time.Time `json:"token_expires_at"`
+}
+
+// GetProjectRunners возвращает раннеры, зарегистрированные в проекте (без общих и групповых)
+func (c *Client) GetProjectRunners(ctx context.Context, projectID int) ([]*gitlab.Runner, error) {
+	runnerType := runnerTypeProject
+	options := &gitlab.ListProjectRunnersOptions{
+		ListOptions: c.listOptions(),
+		Type:        &runnerType,
+	}
+	runners, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+		return c.client.Runners.ListProjectRunners(projectID, options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project runners: %w", err)
+	}
+
+	return runners, nil
+}
+
+// GetGroupRunners возвращает групповые раннеры группы и ее родительских групп
+func (c *Client) GetGroupRunners(ctx context.Context, groupID int) ([]*gitlab.Runner, error) {
+	runnerType := runnerTypeGroup
+	options := &gitlab.ListGroupsRunnersOptions{
+		ListOptions: c.listOptions(),
+		Type:        &runnerType,
+	}
+	runners, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+		return c.client.Runners.ListGroupsRunners(groupID, options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list group runners: %w", err)
+	}
+
+	return runners, nil
+}
+
+// GetAllRunners возвращает все раннеры инстанса. Требует токен администратора.
+func (c *Client) GetAllRunners(ctx context.Context) ([]*gitlab.Runner, error) {
+	options := &gitlab.ListRunnersOptions{
+		ListOptions: c.listOptions(),
+	}
+	runners, err := collectPages(ctx, c, func(opts ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+		return c.client.Runners.ListAllRunners(options, opts...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runners: %w", err)
+	}
+
+	return runners, nil
+}
+
+// GetRunnerDetails возвращает время последнего контакта и срок действия токена раннера,
+// которые не входят в ответы списков
+func (c *Client) GetRunnerDetails(ctx context.Context, runnerID int) (*RunnerDetails, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	req, err := c.client.NewRequest(http.MethodGet, fmt.Sprintf("runners/%d", runnerID), nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner request: %w", err)
+	}
+
+	details := new(RunnerDetails)
+	if _, err := c.client.Do(req, details); err != nil {
+		return nil, fmt.Errorf("failed to get runner details: %w", err)
+	}
+	return details, nil
+}
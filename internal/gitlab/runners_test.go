@@ -0,0 +1,94 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_GetRunners(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/1/runners", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("type"); got != "project_type" {
+			t.Errorf("type = %q, want %q", got, "project_type")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 10, "description": "project runner", "runner_type": "project_type", "online": true, "status": "online"}]`))
+	})
+	mux.HandleFunc("/api/v4/groups/5/runners", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("type"); got != "group_type" {
+			t.Errorf("type = %q, want %q", got, "group_type")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 11, "description": "group runner", "runner_type": "group_type", "status": "stale"}]`))
+	})
+	mux.HandleFunc("/api/v4/runners/all", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": 10}, {"id": 11}, {"id": 12}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ctx := context.Background()
+
+	projectRunners, err := client.GetProjectRunners(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetProjectRunners() error = %v", err)
+	}
+	if len(projectRunners) != 1 || !projectRunners[0].Online {
+		t.Errorf("unexpected project runners: %+v", projectRunners)
+	}
+
+	groupRunners, err := client.GetGroupRunners(ctx, 5)
+	if err != nil {
+		t.Fatalf("GetGroupRunners() error = %v", err)
+	}
+	if len(groupRunners) != 1 || groupRunners[0].Status != "stale" {
+		t.Errorf("unexpected group runners: %+v", groupRunners)
+	}
+
+	allRunners, err := client.GetAllRunners(ctx)
+	if err != nil {
+		t.Fatalf("GetAllRunners() error = %v", err)
+	}
+	if len(allRunners) != 3 {
+		t.Errorf("got %d runners, want 3", len(allRunners))
+	}
+}
+
+func TestClient_GetRunnerDetails(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/runners/10", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 10, "description": "runner", "status": "online", "contacted_at": "2025-05-01T10:00:00.000Z", "token_expires_at": "2025-06-01T00:00:00.000Z"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient("test-token", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	details, err := client.GetRunnerDetails(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("GetRunnerDetails() error = %v", err)
+	}
+	if details.ID != 10 || details.Status != "online" {
+		t.Errorf("unexpected runner details: %+v", details.RunnerDetails)
+	}
+	wantContacted := time.Date(2025, time.May, 1, 10, 0, 0, 0, time.UTC)
+	if details.ContactedAt == nil || !details.ContactedAt.Equal(wantContacted) {
+		t.Errorf("ContactedAt = %v, want %v", details.ContactedAt, wantContacted)
+	}
+	wantExpires := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	if details.TokenExpiresAt == nil || !details.TokenExpiresAt.Equal(wantExpires) {
+		t.Errorf("TokenExpiresAt = %v, want %v", details.TokenExpiresAt, wantExpires)
+	}
+}
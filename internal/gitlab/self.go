@@ -0,0 +1,30 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// ErrUnauthorized - GitLab отклонил токен экспортера: токен недействителен, истек или отозван
+var ErrUnauthorized = errors.New("gitlab token is invalid, expired or revoked")
+
+// GetSelfAccessToken возвращает сведения о токене, которым аутентифицирован клиент.
+// Подходит для личных токенов и токенов ботов проектов и групп (GitLab 15.5+).
+func (c *Client) GetSelfAccessToken(ctx context.Context) (*gitlab.PersonalAccessToken, error) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	token, _, err := c.client.PersonalAccessTokens.GetSinglePersonalAccessToken(gitlab.WithContext(ctx))
+	if err != nil {
+		var errorResponse *gitlab.ErrorResponse
+		if errors.As(err, &errorResponse) && errorResponse.Response.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("failed to get exporter token: %w: %w", ErrUnauthorized, err)
+		}
+		return nil, fmt.Errorf("failed to get exporter token: %w", err)
+	}
+	return token, nil
+}
@@ -0,0 +1,68 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_GetSelfAccessToken(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           int
+		body             string
+		wantErr          bool
+		wantUnauthorized bool
+	}{
+		{
+			name:   "valid token",
+			status: http.StatusOK,
+			body:   `{"id": 5, "name": "exporter", "scopes": ["read_api"], "expires_at": "2030-01-01", "active": true}`,
+		},
+		{
+			name:             "revoked token",
+			status:           http.StatusUnauthorized,
+			body:             `{"message": "401 Unauthorized"}`,
+			wantErr:          true,
+			wantUnauthorized: true,
+		},
+		{
+			// Старые версии GitLab не поддерживают /personal_access_tokens/self
+			name:    "not supported",
+			status:  http.StatusNotFound,
+			body:    `{"message": "404 Not Found"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v4/personal_access_tokens/self", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client, err := NewClient("test-token", server.URL)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			token, err := client.GetSelfAccessToken(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSelfAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, ErrUnauthorized); got != tt.wantUnauthorized {
+				t.Errorf("errors.Is(err, ErrUnauthorized) = %v, want %v", got, tt.wantUnauthorized)
+			}
+			if !tt.wantErr && (token.ID != 5 || token.ExpiresAt == nil || len(token.Scopes) != 1) {
+				t.Errorf("unexpected token: %+v", token)
+			}
+		})
+	}
+}
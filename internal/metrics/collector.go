@@ -0,0 +1,252 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Token - состояние одного токена в снапшоте скрейпера
+type Token struct {
+	Labels TokenLabels
+	// ExpiresAt - время истечения токена, nil для бессрочных токенов
+	ExpiresAt *time.Time
+	// NeverExpiresViolation - бессрочный токен нарушает настроенную политику
+	NeverExpiresViolation bool
+	// CreatedAt и LastUsedAt - время создания и последнего использования, nil если неизвестно
+	CreatedAt  *time.Time
+	LastUsedAt *time.Time
+	// Unused - токен не использовался дольше настроенного срока
+	Unused bool
+}
+
+// Snapshot - результат одного прохода скрейпера
+type Snapshot struct {
+	Tokens       []Token
+	DeployTokens []DeployToken
+	Pipelines    []PipelineResource
+	Keys         []Key
+	Runners      []Runner
+	// ScopeCounts и AccessLevelCounts - количество токенов каждого владельца по scopes и уровням доступа
+	ScopeCounts       []InventoryCount
+	AccessLevelCounts []InventoryCount
+	PolicyViolations  []PolicyViolation
+	// Credential - токен экспортера (nil - не проверялся, например в пробах)
+	Credential *Credential
+}
+
+// kindDescs - описания метрик для одного типа владельца токена
+type kindDescs struct {
+	expiresAt    *prometheus.Desc
+	isExpired    *prometheus.Desc
+	neverExpires *prometheus.Desc
+	total        *prometheus.Desc
+}
+
+// tokenCollector вычисляет метрики токенов из последнего снапшота в момент запроса /metrics
+type tokenCollector struct {
+	mu       sync.RWMutex
+	snapshot *Snapshot
+	now      func() time.Time
+
+	legacyName      bool
+	legacyExpiresAt bool
+
+	kinds                 map[string]kindDescs
+	expiryTimestamp       *prometheus.Desc
+	neverExpiresViolation *prometheus.Desc
+	lastUsedTimestamp     *prometheus.Desc
+	createdTimestamp      *prometheus.Desc
+	unused                *prometheus.Desc
+	info                  *prometheus.Desc
+	policyViolation       *prometheus.Desc
+	inventory             inventoryDescs
+	deployTokens          deployTokenDescs
+	pipelines             pipelineDescs
+	keys                  keyDescs
+	runners               runnerDescs
+	credential            credentialDescs
+}
+
+func newTokenCollector(legacyName, legacyExpiresAt bool) *tokenCollector {
+	labels := tokenLabelNames(legacyName)
+
+	newKindDescs := func(prefix, kind, total string) kindDescs {
+		return kindDescs{
+			expiresAt: prometheus.NewDesc(
+				prefix+"_expires_at",
+				"Hours until "+kind+"token expires (deprecated, use gitlab_access_token_expiry_timestamp_seconds)",
+				labels, nil,
+			),
+			isExpired: prometheus.NewDesc(
+				prefix+"_is_expired",
+				"Whether "+kind+"token is expired (1) or not (0)",
+				labels, nil,
+			),
+			neverExpires: prometheus.NewDesc(
+				prefix+"_never_expires",
+				"Whether "+kind+"token has no expiration date (1) or not (0)",
+				labels, nil,
+			),
+			total: prometheus.NewDesc(
+				total,
+				"Total number of "+kind+"tokens",
+				nil, nil,
+			),
+		}
+	}
+
+	return &tokenCollector{
+		snapshot:        &Snapshot{},
+		now:             time.Now,
+		legacyName:      legacyName,
+		legacyExpiresAt: legacyExpiresAt,
+		kinds: map[string]kindDescs{
+			KindProject: newKindDescs("gitlab_token", "", "gitlab_tokens_total"),
+			KindUser:    newKindDescs("gitlab_user_token", "user ", "gitlab_user_tokens_total"),
+			KindGroup:   newKindDescs("gitlab_group_token", "group ", "gitlab_group_tokens_total"),
+		},
+		expiryTimestamp: prometheus.NewDesc(
+			"gitlab_access_token_expiry_timestamp_seconds",
+			"Unix timestamp when the access token expires",
+			labels, nil,
+		),
+		neverExpiresViolation: prometheus.NewDesc(
+			"gitlab_token_never_expires_violation",
+			"Token without expiration date violating the configured policy (always 1)",
+			labels, nil,
+		),
+		lastUsedTimestamp: prometheus.NewDesc(
+			"gitlab_access_token_last_used_timestamp_seconds",
+			"Unix timestamp when the access token was last used",
+			labels, nil,
+		),
+		createdTimestamp: prometheus.NewDesc(
+			"gitlab_access_token_created_timestamp_seconds",
+			"Unix timestamp when the access token was created",
+			labels, nil,
+		),
+		unused: prometheus.NewDesc(
+			"gitlab_access_token_unused",
+			"Token not used for longer than the configured period (always 1)",
+			labels, nil,
+		),
+		info: prometheus.NewDesc(
+			"gitlab_access_token_info",
+			"Access token scopes and access level (always 1)",
+			labels, nil,
+		),
+		policyViolation: prometheus.NewDesc(
+			"gitlab_token_policy_violation",
+			"Token violating the policy rule (always 1)",
+			append([]string{LabelRule}, labels...), nil,
+		),
+		inventory:    newInventoryDescs(),
+		deployTokens: newDeployTokenDescs(),
+		pipelines:    newPipelineDescs(),
+		keys:         newKeyDescs(),
+		runners:      newRunnerDescs(),
+		credential:   newCredentialDescs(),
+	}
+}
+
+// update атомарно заменяет снапшот
+func (c *tokenCollector) update(snapshot Snapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshot = &snapshot
+}
+
+func (c *tokenCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, descs := range c.kinds {
+		if c.legacyExpiresAt {
+			ch <- descs.expiresAt
+		}
+		ch <- descs.isExpired
+		ch <- descs.neverExpires
+		ch <- descs.total
+	}
+	ch <- c.expiryTimestamp
+	ch <- c.neverExpiresViolation
+	ch <- c.lastUsedTimestamp
+	ch <- c.createdTimestamp
+	ch <- c.unused
+	ch <- c.info
+	ch <- c.policyViolation
+	c.inventory.describe(ch)
+	c.deployTokens.describe(ch)
+	c.pipelines.describe(ch)
+	c.keys.describe(ch)
+	c.runners.describe(ch)
+	c.credential.describe(ch)
+}
+
+func (c *tokenCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	snapshot := c.snapshot
+	c.mu.RUnlock()
+
+	now := c.now()
+	totals := make(map[string]int, len(c.kinds))
+
+	for _, token := range snapshot.Tokens {
+		descs, ok := c.kinds[token.Labels.OwnerKind]
+		if !ok {
+			continue
+		}
+		totals[token.Labels.OwnerKind]++
+		labelValues := token.Labels.values(c.legacyName)
+
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, labelValues...)
+		if token.CreatedAt != nil {
+			ch <- prometheus.MustNewConstMetric(c.createdTimestamp, prometheus.GaugeValue, float64(token.CreatedAt.Unix()), labelValues...)
+		}
+		if token.LastUsedAt != nil {
+			ch <- prometheus.MustNewConstMetric(c.lastUsedTimestamp, prometheus.GaugeValue, float64(token.LastUsedAt.Unix()), labelValues...)
+		}
+		if token.Unused {
+			ch <- prometheus.MustNewConstMetric(c.unused, prometheus.GaugeValue, 1, labelValues...)
+		}
+
+		if token.ExpiresAt == nil {
+			ch <- prometheus.MustNewConstMetric(descs.isExpired, prometheus.GaugeValue, 0, labelValues...)
+			ch <- prometheus.MustNewConstMetric(descs.neverExpires, prometheus.GaugeValue, 1, labelValues...)
+			if token.NeverExpiresViolation {
+				ch <- prometheus.MustNewConstMetric(c.neverExpiresViolation, prometheus.GaugeValue, 1, labelValues...)
+			}
+			continue
+		}
+
+		expiresAt := *token.ExpiresAt
+		ch <- prometheus.MustNewConstMetric(descs.isExpired, prometheus.GaugeValue, boolToFloat(expiresAt.Before(now)), labelValues...)
+		ch <- prometheus.MustNewConstMetric(descs.neverExpires, prometheus.GaugeValue, 0, labelValues...)
+		ch <- prometheus.MustNewConstMetric(c.expiryTimestamp, prometheus.GaugeValue, float64(expiresAt.Unix()), labelValues...)
+		if c.legacyExpiresAt {
+			ch <- prometheus.MustNewConstMetric(descs.expiresAt, prometheus.GaugeValue, expiresAt.Sub(now).Hours(), labelValues...)
+		}
+	}
+
+	for kind, descs := range c.kinds {
+		ch <- prometheus.MustNewConstMetric(descs.total, prometheus.GaugeValue, float64(totals[kind]))
+	}
+
+	for _, violation := range snapshot.PolicyViolations {
+		labelValues := append([]string{violation.Rule}, violation.Labels.values(c.legacyName)...)
+		ch <- prometheus.MustNewConstMetric(c.policyViolation, prometheus.GaugeValue, 1, labelValues...)
+	}
+
+	c.inventory.collect(ch, snapshot.ScopeCounts, snapshot.AccessLevelCounts)
+	c.deployTokens.collect(ch, snapshot.DeployTokens, now)
+	c.pipelines.collect(ch, snapshot.Pipelines)
+	c.keys.collect(ch, snapshot.Keys, now)
+	c.runners.collect(ch, snapshot.Runners, now)
+	c.credential.collect(ch, snapshot.Credential)
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1.0
+	}
+	return 0.0
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gatherValues собирает значения метрик коллектора в виде "имя метрики" -> "token_name" -> значение
+func gatherValues(t *testing.T, collector prometheus.Collector) map[string]map[string]float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	values := make(map[string]map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = make(map[string]float64)
+		for _, metric := range family.GetMetric() {
+			values[family.GetName()][labelValue(metric, LabelTokenName)] = metric.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestTokenCollector_Collect(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	future := now.Add(48 * time.Hour)
+	past := now.Add(-time.Hour)
+
+	user := testToken("user")
+	user.OwnerKind = KindUser
+	user.TokenID = 3
+
+	collector := newTokenCollector(false, true)
+	collector.now = func() time.Time { return now }
+	collector.update(Snapshot{Tokens: []Token{
+		{Labels: testToken("active"), ExpiresAt: &future},
+		{Labels: TokenLabels{OwnerKind: KindProject, OwnerID: 1, TokenID: 2, TokenName: "expired"}, ExpiresAt: &past},
+		{Labels: user, NeverExpiresViolation: true},
+	}})
+
+	values := gatherValues(t, collector)
+
+	tests := []struct {
+		metric string
+		token  string
+		want   float64
+	}{
+		{"gitlab_token_is_expired", "active", 0},
+		{"gitlab_token_is_expired", "expired", 1},
+		{"gitlab_token_expires_at", "active", 48},
+		{"gitlab_access_token_expiry_timestamp_seconds", "active", float64(future.Unix())},
+		{"gitlab_token_never_expires", "active", 0},
+		{"gitlab_user_token_never_expires", "user", 1},
+		{"gitlab_user_token_is_expired", "user", 0},
+		{"gitlab_token_never_expires_violation", "user", 1},
+		{"gitlab_tokens_total", "", 2},
+		{"gitlab_user_tokens_total", "", 1},
+		{"gitlab_group_tokens_total", "", 0},
+	}
+
+	for _, tt := range tests {
+		got, ok := values[tt.metric][tt.token]
+		if !ok {
+			t.Errorf("%s{token_name=%q} not found", tt.metric, tt.token)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s{token_name=%q} = %v, want %v", tt.metric, tt.token, got, tt.want)
+		}
+	}
+
+	if _, ok := values["gitlab_access_token_expiry_timestamp_seconds"]["user"]; ok {
+		t.Error("expiry timestamp must not be exported for a token without expiration date")
+	}
+}
+
+func TestTokenCollector_Usage(t *testing.T) {
+	created := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lastUsed := time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	collector := newTokenCollector(false, false)
+	collector.update(Snapshot{Tokens: []Token{
+		{Labels: testToken("used"), CreatedAt: &created, LastUsedAt: &lastUsed},
+		{Labels: TokenLabels{OwnerKind: KindProject, OwnerID: 1, TokenID: 2, TokenName: "dormant"}, CreatedAt: &created, Unused: true},
+	}})
+
+	values := gatherValues(t, collector)
+
+	if got := values["gitlab_access_token_created_timestamp_seconds"]["used"]; got != float64(created.Unix()) {
+		t.Errorf("created timestamp = %v, want %v", got, created.Unix())
+	}
+	if got := values["gitlab_access_token_last_used_timestamp_seconds"]["used"]; got != float64(lastUsed.Unix()) {
+		t.Errorf("last used timestamp = %v, want %v", got, lastUsed.Unix())
+	}
+	if _, ok := values["gitlab_access_token_last_used_timestamp_seconds"]["dormant"]; ok {
+		t.Error("last used timestamp must not be exported for a token that was never used")
+	}
+	if got, ok := values["gitlab_access_token_unused"]["dormant"]; !ok || got != 1 {
+		t.Errorf("gitlab_access_token_unused{dormant} = %v (found %v), want 1", got, ok)
+	}
+	if _, ok := values["gitlab_access_token_unused"]["used"]; ok {
+		t.Error("used token must not be reported as unused")
+	}
+}
+
+func TestTokenCollector_IsExpiredComputedAtCollect(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Minute)
+
+	collector := newTokenCollector(false, false)
+	collector.now = func() time.Time { return now }
+	collector.update(Snapshot{Tokens: []Token{{Labels: testToken("token"), ExpiresAt: &expiresAt}}})
+
+	if got := gatherValues(t, collector)["gitlab_token_is_expired"]["token"]; got != 0 {
+		t.Errorf("is_expired before expiry = %v, want 0", got)
+	}
+
+	// Без нового скрейпинга значение меняется, как только наступает время истечения
+	collector.now = func() time.Time { return now.Add(2 * time.Minute) }
+	if got := gatherValues(t, collector)["gitlab_token_is_expired"]["token"]; got != 1 {
+		t.Errorf("is_expired after expiry = %v, want 1", got)
+	}
+}
+
+func TestTokenCollector_LegacyOptions(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	snapshot := Snapshot{Tokens: []Token{{Labels: testToken("token"), ExpiresAt: &expiresAt}}}
+
+	for _, legacy := range []bool{false, true} {
+		collector := newTokenCollector(legacy, legacy)
+		collector.update(snapshot)
+
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(collector)
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Failed to gather metrics: %v", err)
+		}
+
+		hasExpiresAt := false
+		hasName := false
+		for _, family := range families {
+			if family.GetName() == "gitlab_token_expires_at" {
+				hasExpiresAt = true
+			}
+			if family.GetName() == "gitlab_token_is_expired" {
+				hasName = labelValue(family.GetMetric()[0], LabelLegacyName) == "Project 1 token"
+			}
+		}
+
+		if hasExpiresAt != legacy {
+			t.Errorf("legacy=%v: gitlab_token_expires_at exported = %v", legacy, hasExpiresAt)
+		}
+		if hasName != legacy {
+			t.Errorf("legacy=%v: name label exported = %v", legacy, hasName)
+		}
+	}
+}
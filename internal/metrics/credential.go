@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Credential - токен, которым экспортер обращается к GitLab API
+type Credential struct {
+	// Valid - GitLab принял токен при последней проверке
+	Valid bool
+	// TokenID - ID токена, 0 если сведения о токене еще не получены
+	TokenID   int
+	TokenName string
+	Scopes    []string
+	// ExpiresAt - время истечения токена, nil для бессрочных токенов
+	ExpiresAt *time.Time
+	// LastUsedAt - время последнего использования, nil если неизвестно
+	LastUsedAt *time.Time
+}
+
+// values возвращает значения меток токена экспортера
+func (c Credential) values() []string {
+	return []string{strconv.Itoa(c.TokenID), c.TokenName}
+}
+
+// credentialDescs - описания метрик токена экспортера
+type credentialDescs struct {
+	valid           *prometheus.Desc
+	info            *prometheus.Desc
+	expiryTimestamp *prometheus.Desc
+	neverExpires    *prometheus.Desc
+	lastUsed        *prometheus.Desc
+}
+
+func newCredentialDescs() credentialDescs {
+	labels := []string{LabelTokenID, LabelTokenName}
+	return credentialDescs{
+		valid: prometheus.NewDesc(
+			"gitlab_token_exporter_credential_valid",
+			"Whether GitLab accepted the exporter token at the last check (1) or rejected it (0)",
+			nil, nil,
+		),
+		info: prometheus.NewDesc(
+			"gitlab_token_exporter_credential_info",
+			"Exporter token details, the scopes label lists its scopes (always 1)",
+			append(labels, LabelScopes), nil,
+		),
+		expiryTimestamp: prometheus.NewDesc(
+			"gitlab_token_exporter_credential_expiry_timestamp_seconds",
+			"Unix timestamp when the exporter token expires",
+			labels, nil,
+		),
+		neverExpires: prometheus.NewDesc(
+			"gitlab_token_exporter_credential_never_expires",
+			"Whether the exporter token has no expiration date (1) or not (0)",
+			labels, nil,
+		),
+		lastUsed: prometheus.NewDesc(
+			"gitlab_token_exporter_credential_last_used_timestamp_seconds",
+			"Unix timestamp when the exporter token was last used",
+			labels, nil,
+		),
+	}
+}
+
+func (d credentialDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- d.valid
+	ch <- d.info
+	ch <- d.expiryTimestamp
+	ch <- d.neverExpires
+	ch <- d.lastUsed
+}
+
+func (d credentialDescs) collect(ch chan<- prometheus.Metric, credential *Credential) {
+	if credential == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(d.valid, prometheus.GaugeValue, boolToFloat(credential.Valid))
+	if credential.TokenID == 0 {
+		return
+	}
+
+	labelValues := credential.values()
+	scopes := TokenLabels{Scopes: credential.Scopes}.ScopesValue()
+	ch <- prometheus.MustNewConstMetric(d.info, prometheus.GaugeValue, 1, append(labelValues, scopes)...)
+	ch <- prometheus.MustNewConstMetric(d.neverExpires, prometheus.GaugeValue, boolToFloat(credential.ExpiresAt == nil), labelValues...)
+	if credential.ExpiresAt != nil {
+		ch <- prometheus.MustNewConstMetric(d.expiryTimestamp, prometheus.GaugeValue, float64(credential.ExpiresAt.Unix()), labelValues...)
+	}
+	if credential.LastUsedAt != nil {
+		ch <- prometheus.MustNewConstMetric(d.lastUsed, prometheus.GaugeValue, float64(credential.LastUsedAt.Unix()), labelValues...)
+	}
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenCollector_Credential(t *testing.T) {
+	expiresAt := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lastUsed := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		credential *Credential
+		want       map[string]float64
+		notWant    []string
+	}{
+		{
+			name: "valid token with expiry",
+			credential: &Credential{
+				Valid: true, TokenID: 7, TokenName: "exporter", Scopes: []string{"read_api"},
+				ExpiresAt: &expiresAt, LastUsedAt: &lastUsed,
+			},
+			want: map[string]float64{
+				"gitlab_token_exporter_credential_valid":                       1,
+				"gitlab_token_exporter_credential_info":                        1,
+				"gitlab_token_exporter_credential_never_expires":               0,
+				"gitlab_token_exporter_credential_expiry_timestamp_seconds":    float64(expiresAt.Unix()),
+				"gitlab_token_exporter_credential_last_used_timestamp_seconds": float64(lastUsed.Unix()),
+			},
+		},
+		{
+			name:       "never expiring token",
+			credential: &Credential{Valid: true, TokenID: 7, TokenName: "exporter"},
+			want: map[string]float64{
+				"gitlab_token_exporter_credential_never_expires": 1,
+			},
+			notWant: []string{"gitlab_token_exporter_credential_expiry_timestamp_seconds"},
+		},
+		{
+			// Токен отклонен до первой успешной проверки: известен только результат
+			name:       "rejected unknown token",
+			credential: &Credential{},
+			want: map[string]float64{
+				"gitlab_token_exporter_credential_valid": 0,
+			},
+			notWant: []string{"gitlab_token_exporter_credential_info"},
+		},
+		{
+			name:    "not checked",
+			notWant: []string{"gitlab_token_exporter_credential_valid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := newTokenCollector(false, false)
+			collector.update(Snapshot{Credential: tt.credential})
+			values := gatherByLabels(t, collector, LabelTokenName)
+
+			for metric, want := range tt.want {
+				got, ok := values[metric]
+				if !ok {
+					t.Errorf("%s not found", metric)
+					continue
+				}
+				for _, value := range got {
+					if value != want {
+						t.Errorf("%s = %v, want %v", metric, value, want)
+					}
+				}
+			}
+			for _, metric := range tt.notWant {
+				if _, ok := values[metric]; ok {
+					t.Errorf("unexpected %s", metric)
+				}
+			}
+		})
+	}
+}
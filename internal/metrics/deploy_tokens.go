@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DeployToken - состояние deploy-токена проекта или группы в снапшоте скрейпера
+type DeployToken struct {
+	// Labels - владелец и токен; AccessLevel для deploy-токенов не используется
+	Labels TokenLabels
+	// Username - имя пользователя, под которым токен используется в docker login и git clone
+	Username string
+	// ExpiresAt - время истечения токена, nil для бессрочных токенов
+	ExpiresAt *time.Time
+	Revoked   bool
+}
+
+// deployTokenDescs - описания метрик deploy-токенов
+type deployTokenDescs struct {
+	expiryTimestamp *prometheus.Desc
+	isExpired       *prometheus.Desc
+	neverExpires    *prometheus.Desc
+	revoked         *prometheus.Desc
+	total           *prometheus.Desc
+}
+
+// deployTokenLabelNames возвращает список имен меток deploy-токена
+func deployTokenLabelNames() []string {
+	return []string{
+		LabelOwnerKind,
+		LabelOwnerID,
+		LabelOwnerName,
+		LabelTokenID,
+		LabelTokenName,
+		LabelScopes,
+		LabelUsername,
+	}
+}
+
+// values возвращает значения меток в порядке deployTokenLabelNames
+func (t DeployToken) values() []string {
+	return []string{
+		t.Labels.OwnerKind,
+		strconv.Itoa(t.Labels.OwnerID),
+		t.Labels.OwnerName,
+		strconv.Itoa(t.Labels.TokenID),
+		t.Labels.TokenName,
+		t.Labels.ScopesValue(),
+		t.Username,
+	}
+}
+
+func newDeployTokenDescs() deployTokenDescs {
+	labels := deployTokenLabelNames()
+	return deployTokenDescs{
+		expiryTimestamp: prometheus.NewDesc(
+			"gitlab_deploy_token_expiry_timestamp_seconds",
+			"Unix timestamp when the deploy token expires",
+			labels, nil,
+		),
+		isExpired: prometheus.NewDesc(
+			"gitlab_deploy_token_is_expired",
+			"Whether deploy token is expired (1) or not (0)",
+			labels, nil,
+		),
+		neverExpires: prometheus.NewDesc(
+			"gitlab_deploy_token_never_expires",
+			"Whether deploy token has no expiration date (1) or not (0)",
+			labels, nil,
+		),
+		revoked: prometheus.NewDesc(
+			"gitlab_deploy_token_revoked",
+			"Whether deploy token is revoked (1) or not (0)",
+			labels, nil,
+		),
+		total: prometheus.NewDesc(
+			"gitlab_deploy_tokens_total",
+			"Total number of deploy tokens",
+			[]string{LabelOwnerKind}, nil,
+		),
+	}
+}
+
+func (d deployTokenDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- d.expiryTimestamp
+	ch <- d.isExpired
+	ch <- d.neverExpires
+	ch <- d.revoked
+	ch <- d.total
+}
+
+func (d deployTokenDescs) collect(ch chan<- prometheus.Metric, tokens []DeployToken, now time.Time) {
+	totals := map[string]int{KindProject: 0, KindGroup: 0}
+
+	for _, token := range tokens {
+		totals[token.Labels.OwnerKind]++
+		labelValues := token.values()
+
+		ch <- prometheus.MustNewConstMetric(d.revoked, prometheus.GaugeValue, boolToFloat(token.Revoked), labelValues...)
+		if token.ExpiresAt == nil {
+			ch <- prometheus.MustNewConstMetric(d.isExpired, prometheus.GaugeValue, 0, labelValues...)
+			ch <- prometheus.MustNewConstMetric(d.neverExpires, prometheus.GaugeValue, 1, labelValues...)
+			continue
+		}
+
+		expiresAt := *token.ExpiresAt
+		ch <- prometheus.MustNewConstMetric(d.isExpired, prometheus.GaugeValue, boolToFloat(expiresAt.Before(now)), labelValues...)
+		ch <- prometheus.MustNewConstMetric(d.neverExpires, prometheus.GaugeValue, 0, labelValues...)
+		ch <- prometheus.MustNewConstMetric(d.expiryTimestamp, prometheus.GaugeValue, float64(expiresAt.Unix()), labelValues...)
+	}
+
+	for kind, total := range totals {
+		ch <- prometheus.MustNewConstMetric(d.total, prometheus.GaugeValue, float64(total), kind)
+	}
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenCollector_DeployTokens(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	future := now.Add(24 * time.Hour)
+	past := now.Add(-time.Hour)
+
+	collector := newTokenCollector(false, false)
+	collector.now = func() time.Time { return now }
+	collector.update(Snapshot{DeployTokens: []DeployToken{
+		{
+			Labels:    TokenLabels{OwnerKind: KindProject, OwnerID: 1, OwnerName: "api", TokenID: 10, TokenName: "registry", Scopes: []string{"read_registry", "read_repository"}},
+			Username:  "gitlab+deploy-token-10",
+			ExpiresAt: &future,
+		},
+		{
+			Labels:    TokenLabels{OwnerKind: KindGroup, OwnerID: 2, OwnerName: "platform", TokenID: 11, TokenName: "old"},
+			ExpiresAt: &past,
+			Revoked:   true,
+		},
+		{
+			Labels: TokenLabels{OwnerKind: KindProject, OwnerID: 1, OwnerName: "api", TokenID: 12, TokenName: "forever"},
+		},
+	}})
+
+	values := gatherValues(t, collector)
+
+	tests := []struct {
+		metric string
+		token  string
+		want   float64
+	}{
+		{"gitlab_deploy_token_expiry_timestamp_seconds", "registry", float64(future.Unix())},
+		{"gitlab_deploy_token_is_expired", "registry", 0},
+		{"gitlab_deploy_token_is_expired", "old", 1},
+		{"gitlab_deploy_token_revoked", "registry", 0},
+		{"gitlab_deploy_token_revoked", "old", 1},
+		{"gitlab_deploy_token_never_expires", "forever", 1},
+		{"gitlab_deploy_token_never_expires", "registry", 0},
+	}
+
+	for _, tt := range tests {
+		got, ok := values[tt.metric][tt.token]
+		if !ok {
+			t.Errorf("%s{token_name=%q} not found", tt.metric, tt.token)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s{token_name=%q} = %v, want %v", tt.metric, tt.token, got, tt.want)
+		}
+	}
+
+	if _, ok := values["gitlab_deploy_token_expiry_timestamp_seconds"]["forever"]; ok {
+		t.Error("expiry timestamp must not be exported for a deploy token without expiration date")
+	}
+}
+
+func TestDeployToken_Values(t *testing.T) {
+	token := DeployToken{
+		Labels:   TokenLabels{OwnerKind: KindProject, OwnerID: 1, OwnerName: "api", TokenID: 10, TokenName: "registry", Scopes: []string{"read_repository", "read_registry"}},
+		Username: "deployer",
+	}
+
+	values := token.values()
+	names := deployTokenLabelNames()
+	if len(values) != len(names) {
+		t.Fatalf("got %d values for %d labels", len(values), len(names))
+	}
+
+	want := map[string]string{
+		LabelOwnerKind: "project",
+		LabelScopes:    "read_registry,read_repository",
+		LabelUsername:  "deployer",
+	}
+	for i, name := range names {
+		if expected, ok := want[name]; ok && values[i] != expected {
+			t.Errorf("%s = %q, want %q", name, values[i], expected)
+		}
+	}
+}
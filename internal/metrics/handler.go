@@ -5,48 +5,88 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 )
 
 type Handler struct {
-	tokenExpiresAt *prometheus.GaugeVec
-	tokenIsExpired *prometheus.GaugeVec
-	tokensTotal    prometheus.Gauge
+	registry       *prometheus.Registry
+	tokens         *tokenCollector
 	scrapeDuration prometheus.Histogram
 	scrapeErrors   prometheus.Counter
 	lastScrapeTime prometheus.Gauge
-	// Метрики для пользовательских токенов
-	userTokenExpiresAt *prometheus.GaugeVec
-	userTokenIsExpired *prometheus.GaugeVec
-	userTokensTotal    prometheus.Gauge
-	// Метрики для групповых токенов
-	groupTokenExpiresAt *prometheus.GaugeVec
-	groupTokenIsExpired *prometheus.GaugeVec
-	groupTokensTotal    prometheus.Gauge
+	apiRetries     *prometheus.CounterVec
+	throttleWait   prometheus.Histogram
+	nameCache      *prometheus.CounterVec
+	discovered     *prometheus.GaugeVec
+	scrapeTargets  *prometheus.GaugeVec
+	scrapeProgress *prometheus.GaugeVec
+	rotations      *prometheus.CounterVec
 }
 
-func NewHandler() *Handler {
+type options struct {
+	legacyName      bool
+	legacyExpiresAt bool
+	registry        *prometheus.Registry
+	instance        string
+}
+
+// Option - функциональная опция для настройки Handler
+type Option func(*options)
+
+// WithLegacyNameLabel включает режим совместимости с устаревшей меткой "name"
+func WithLegacyNameLabel(enabled bool) Option {
+	return func(o *options) {
+		o.legacyName = enabled
+	}
+}
+
+// WithLegacyExpiresAt включает устаревшие метрики *_expires_at (часы до истечения)
+func WithLegacyExpiresAt(enabled bool) Option {
+	return func(o *options) {
+		o.legacyExpiresAt = enabled
+	}
+}
+
+// WithRegistry регистрирует метрики в общем реестре вместо собственного.
+// Так несколько Handler отдают метрики через один /metrics.
+func WithRegistry(registry *prometheus.Registry) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// WithInstance добавляет метку instance с именем инстанса GitLab ко всем метрикам Handler
+func WithInstance(name string) Option {
+	return func(o *options) {
+		o.instance = name
+	}
+}
+
+// NewRegistry создает реестр с метриками процесса и среды выполнения Go
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// NewHandler создает Handler с собственным реестром метрик или в реестре из WithRegistry
+func NewHandler(opts ...Option) *Handler {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.registry == nil {
+		o.registry = NewRegistry()
+	}
+
 	h := &Handler{
-		tokenExpiresAt: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "gitlab_token_expires_at",
-				Help: "Hours until token expires",
-			},
-			[]string{"name"},
-		),
-		tokenIsExpired: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "gitlab_token_is_expired",
-				Help: "Whether token is expired (1) or not (0)",
-			},
-			[]string{"name"},
-		),
-		tokensTotal: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "gitlab_tokens_total",
-				Help: "Total number of tokens",
-			},
-		),
+		registry: o.registry,
+		tokens:   newTokenCollector(o.legacyName, o.legacyExpiresAt),
 		scrapeDuration: prometheus.NewHistogram(
 			prometheus.HistogramOpts{
 				Name:    "gitlab_token_scrape_duration_seconds",
@@ -66,95 +106,90 @@ func NewHandler() *Handler {
 				Help: "Timestamp of last successful scrape",
 			},
 		),
-		// Метрики для пользовательских токенов
-		userTokenExpiresAt: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "gitlab_user_token_expires_at",
-				Help: "Hours until user token expires",
+		apiRetries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gitlab_api_retries_total",
+				Help: "Total number of retried GitLab API requests",
 			},
-			[]string{"name"},
+			[]string{"reason"},
 		),
-		userTokenIsExpired: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "gitlab_user_token_is_expired",
-				Help: "Whether user token is expired (1) or not (0)",
+		throttleWait: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    "gitlab_api_throttle_wait_seconds",
+				Help:    "Time spent waiting for the client-side rate limiter",
+				Buckets: prometheus.DefBuckets,
 			},
-			[]string{"name"},
 		),
-		userTokensTotal: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Name: "gitlab_user_tokens_total",
-				Help: "Total number of user tokens",
+		nameCache: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gitlab_name_cache_requests_total",
+				Help: "Total number of owner name cache lookups by result (hit or miss)",
 			},
+			[]string{"kind", "result"},
 		),
-		// Метрики для групповых токенов
-		groupTokenExpiresAt: prometheus.NewGaugeVec(
+		discovered: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "gitlab_group_token_expires_at",
-				Help: "Hours until group token expires",
+				Name: "gitlab_discovered_targets",
+				Help: "Number of monitored projects and groups, including discovered ones",
 			},
-			[]string{"name"},
+			[]string{"kind"},
 		),
-		groupTokenIsExpired: prometheus.NewGaugeVec(
+		scrapeTargets: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "gitlab_group_token_is_expired",
-				Help: "Whether group token is expired (1) or not (0)",
+				Name: "gitlab_scrape_targets",
+				Help: "Number of projects, groups and user tokens to process in the current scrape",
 			},
-			[]string{"name"},
+			[]string{"kind"},
 		),
-		groupTokensTotal: prometheus.NewGauge(
+		scrapeProgress: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "gitlab_group_tokens_total",
-				Help: "Total number of group tokens",
+				Name: "gitlab_scrape_targets_processed",
+				Help: "Number of projects, groups and user tokens already processed in the current scrape",
 			},
+			[]string{"kind"},
+		),
+		rotations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gitlab_token_rotations_total",
+				Help: "Total number of token rotations by rule, owner kind and result",
+			},
+			[]string{"rule", LabelOwnerKind, "result"},
 		),
 	}
 
-	prometheus.MustRegister(
-		h.tokenExpiresAt,
-		h.tokenIsExpired,
-		h.tokensTotal,
+	var registerer prometheus.Registerer = h.registry
+	if o.instance != "" {
+		registerer = prometheus.WrapRegistererWith(prometheus.Labels{LabelInstance: o.instance}, h.registry)
+	}
+	registerer.MustRegister(
+		h.tokens,
 		h.scrapeDuration,
 		h.scrapeErrors,
 		h.lastScrapeTime,
-		h.userTokenExpiresAt,
-		h.userTokenIsExpired,
-		h.userTokensTotal,
-		h.groupTokenExpiresAt,
-		h.groupTokenIsExpired,
-		h.groupTokensTotal,
+		h.apiRetries,
+		h.throttleWait,
+		h.nameCache,
+		h.discovered,
+		h.scrapeTargets,
+		h.scrapeProgress,
+		h.rotations,
 	)
 
 	return h
 }
 
 func (h *Handler) Handler() http.Handler {
-	return promhttp.Handler()
+	return promhttp.HandlerFor(h.registry, promhttp.HandlerOpts{})
 }
 
-func (h *Handler) ResetMetrics() {
-	h.tokenExpiresAt.Reset()
-	h.tokenIsExpired.Reset()
-	h.userTokenExpiresAt.Reset()
-	h.userTokenIsExpired.Reset()
-	h.groupTokenExpiresAt.Reset()
-	h.groupTokenIsExpired.Reset()
+// Registry возвращает реестр, в котором регистрируются метрики экспортера
+func (h *Handler) Registry() *prometheus.Registry {
+	return h.registry
 }
 
-func (h *Handler) SetTotalTokens(total int) {
-	h.tokensTotal.Set(float64(total))
-}
-
-func (h *Handler) SetTokenExpiresAt(name string, expiresAt time.Time) {
-	h.tokenExpiresAt.WithLabelValues(name).Set(time.Until(expiresAt).Hours())
-}
-
-func (h *Handler) SetTokenIsExpired(name string, isExpired bool) {
-	value := 0.0
-	if isExpired {
-		value = 1.0
-	}
-	h.tokenIsExpired.WithLabelValues(name).Set(value)
+// Update атомарно заменяет снапшот токенов, из которого строятся метрики
+func (h *Handler) Update(snapshot Snapshot) {
+	h.tokens.update(snapshot)
 }
 
 func (h *Handler) RecordScrapeDuration(duration time.Duration) {
@@ -165,55 +200,56 @@ func (h *Handler) IncrementScrapeErrors() {
 	h.scrapeErrors.Inc()
 }
 
-func (h *Handler) SetLastScrapeTime(timestamp time.Time) {
-	h.lastScrapeTime.Set(float64(timestamp.Unix()))
+// ScrapeErrors возвращает количество ошибок сбора с момента создания Handler
+func (h *Handler) ScrapeErrors() int {
+	var metric dto.Metric
+	if err := h.scrapeErrors.Write(&metric); err != nil {
+		return 0
+	}
+	return int(metric.GetCounter().GetValue())
 }
 
-func (h *Handler) DeleteTokenMetrics(name string) {
-	h.tokenExpiresAt.DeleteLabelValues(name)
-	h.tokenIsExpired.DeleteLabelValues(name)
+func (h *Handler) SetLastScrapeTime(timestamp time.Time) {
+	h.lastScrapeTime.Set(float64(timestamp.Unix()))
 }
 
-// Методы для пользовательских токенов
-func (h *Handler) SetTotalUserTokens(total int) {
-	h.userTokensTotal.Set(float64(total))
+// ObserveRetry учитывает повторный запрос к GitLab API с указанной причиной
+func (h *Handler) ObserveRetry(reason string) {
+	h.apiRetries.WithLabelValues(reason).Inc()
 }
 
-func (h *Handler) SetUserTokenExpiresAt(name string, expiresAt time.Time) {
-	h.userTokenExpiresAt.WithLabelValues(name).Set(time.Until(expiresAt).Hours())
+// ObserveThrottle учитывает ожидание клиентского ограничителя частоты запросов
+func (h *Handler) ObserveThrottle(wait time.Duration) {
+	h.throttleWait.Observe(wait.Seconds())
 }
 
-func (h *Handler) SetUserTokenIsExpired(name string, isExpired bool) {
-	value := 0.0
-	if isExpired {
-		value = 1.0
+// ObserveNameCache учитывает обращение к кэшу имен владельцев
+func (h *Handler) ObserveNameCache(kind string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
 	}
-	h.userTokenIsExpired.WithLabelValues(name).Set(value)
+	h.nameCache.WithLabelValues(kind, result).Inc()
 }
 
-func (h *Handler) DeleteUserTokenMetrics(name string) {
-	h.userTokenExpiresAt.DeleteLabelValues(name)
-	h.userTokenIsExpired.DeleteLabelValues(name)
+// SetDiscoveredTargets задает количество отслеживаемых проектов и групп после обнаружения
+func (h *Handler) SetDiscoveredTargets(projects, groups int) {
+	h.discovered.WithLabelValues(KindProject).Set(float64(projects))
+	h.discovered.WithLabelValues(KindGroup).Set(float64(groups))
 }
 
-// Методы для групповых токенов
-func (h *Handler) SetTotalGroupTokens(total int) {
-	h.groupTokensTotal.Set(float64(total))
+// SetScrapeTargets задает количество целей текущего прохода и сбрасывает прогресс
+func (h *Handler) SetScrapeTargets(kind string, total int) {
+	h.scrapeTargets.WithLabelValues(kind).Set(float64(total))
+	h.scrapeProgress.WithLabelValues(kind).Set(0)
 }
 
-func (h *Handler) SetGroupTokenExpiresAt(name string, expiresAt time.Time) {
-	h.groupTokenExpiresAt.WithLabelValues(name).Set(time.Until(expiresAt).Hours())
-}
-
-func (h *Handler) SetGroupTokenIsExpired(name string, isExpired bool) {
-	value := 0.0
-	if isExpired {
-		value = 1.0
-	}
-	h.groupTokenIsExpired.WithLabelValues(name).Set(value)
+// IncrementScrapeProgress отмечает обработку одной цели текущего прохода
+func (h *Handler) IncrementScrapeProgress(kind string) {
+	h.scrapeProgress.WithLabelValues(kind).Inc()
 }
 
-func (h *Handler) DeleteGroupTokenMetrics(name string) {
-	h.groupTokenExpiresAt.DeleteLabelValues(name)
-	h.groupTokenIsExpired.DeleteLabelValues(name)
+// ObserveRotation учитывает результат ротации токена по правилу rule
+func (h *Handler) ObserveRotation(rule, ownerKind, result string) {
+	h.rotations.WithLabelValues(rule, ownerKind, result).Inc()
 }
@@ -1,34 +1,60 @@
 package metrics
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
-
-	"github.com/prometheus/client_golang/prometheus"
 )
 
-func resetPrometheusRegistry() {
-	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+func testToken(name string) TokenLabels {
+	return TokenLabels{
+		OwnerKind: KindProject,
+		OwnerID:   1,
+		OwnerName: "Project",
+		TokenID:   1,
+		TokenName: name,
+		Scopes:    []string{"read_api", "api"},
+	}
+}
+
+// fetchMetrics возвращает текст ответа /metrics
+func fetchMetrics(t *testing.T, handler *Handler) string {
+	t.Helper()
+	server := httptest.NewServer(handler.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return string(body)
 }
 
 func TestNewHandler(t *testing.T) {
-	resetPrometheusRegistry()
 	handler := NewHandler()
 	if handler == nil {
 		t.Fatal("NewHandler() returned nil")
 	}
 
 	// Проверяем, что все метрики инициализированы
-	if handler.tokenExpiresAt == nil {
-		t.Error("tokenExpiresAt metric is nil")
+	if handler.registry == nil {
+		t.Error("registry is nil")
 	}
-	if handler.tokenIsExpired == nil {
-		t.Error("tokenIsExpired metric is nil")
-	}
-	if handler.tokensTotal == nil {
-		t.Error("tokensTotal metric is nil")
+	if handler.tokens == nil {
+		t.Error("tokens collector is nil")
 	}
 	if handler.scrapeDuration == nil {
 		t.Error("scrapeDuration metric is nil")
@@ -41,90 +67,77 @@ func TestNewHandler(t *testing.T) {
 	}
 }
 
-func TestHandler_Handler(t *testing.T) {
-	resetPrometheusRegistry()
-	handler := NewHandler()
-	httpHandler := handler.Handler()
-	if httpHandler == nil {
-		t.Fatal("Handler() returned nil")
-	}
+func TestNewHandler_Multiple(t *testing.T) {
+	// Каждый Handler использует собственный реестр, поэтому повторное создание не паникует
+	first := NewHandler()
+	second := NewHandler(WithLegacyNameLabel(true))
 
-	// Создаем тестовый HTTP сервер
-	server := httptest.NewServer(httpHandler)
-	defer server.Close()
-
-	// Делаем запрос к /metrics
-	resp, err := http.Get(server.URL)
-	if err != nil {
-		t.Fatalf("Failed to make request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	if first.Registry() == second.Registry() {
+		t.Error("handlers must not share a registry")
 	}
 }
 
-func TestHandler_ResetMetrics(t *testing.T) {
-	resetPrometheusRegistry()
-	handler := NewHandler()
-
-	// Устанавливаем некоторые значения для токенов проектов
-	handler.SetTotalTokens(5)
-	handler.SetTokenExpiresAt("test-token", time.Now().Add(time.Hour))
-	handler.SetTokenIsExpired("test-token", false)
+func TestNewHandler_SharedRegistry(t *testing.T) {
+	// Handler инстансов GitLab отдают метрики через общий реестр с меткой instance
+	registry := NewRegistry()
+	first := NewHandler(WithRegistry(registry), WithInstance("gitlab-com"))
+	second := NewHandler(WithRegistry(registry), WithInstance("self-managed"))
 
-	// Устанавливаем некоторые значения для пользовательских токенов
-	handler.SetTotalUserTokens(3)
-	handler.SetUserTokenExpiresAt("test-user-token", time.Now().Add(time.Hour))
-	handler.SetUserTokenIsExpired("test-user-token", false)
+	first.IncrementScrapeErrors()
+	expiresAt := time.Now().Add(time.Hour)
+	first.Update(Snapshot{Tokens: []Token{{Labels: testToken("first"), ExpiresAt: &expiresAt}}})
+	second.Update(Snapshot{Tokens: []Token{{Labels: testToken("second"), ExpiresAt: &expiresAt}}})
 
-	// Сбрасываем метрики
-	handler.ResetMetrics()
+	if first.Registry() != second.Registry() {
+		t.Fatal("handlers must share the registry")
+	}
 
-	// Проверяем, что метрики сброшены (это внутренняя проверка, так как мы не можем напрямую получить значения)
-	// В реальном приложении мы бы проверяли через HTTP endpoint
+	body := fetchMetrics(t, second)
+	for _, want := range []string{
+		`gitlab_token_scrape_errors_total{instance="gitlab-com"} 1`,
+		`gitlab_token_scrape_errors_total{instance="self-managed"} 0`,
+		`instance="gitlab-com",owner_id="1"`,
+		`instance="self-managed",owner_id="1"`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
 }
 
-func TestHandler_SetTotalTokens(t *testing.T) {
-	resetPrometheusRegistry()
+func TestHandler_Handler(t *testing.T) {
 	handler := NewHandler()
+	if handler.Handler() == nil {
+		t.Fatal("Handler() returned nil")
+	}
 
-	// Тестируем установку различных значений
-	testCases := []int{0, 1, 10, 100}
-
-	for _, total := range testCases {
-		handler.SetTotalTokens(total)
-		// В реальном приложении мы бы проверяли значение через HTTP endpoint
+	body := fetchMetrics(t, handler)
+	if !strings.Contains(body, "gitlab_tokens_total 0") {
+		t.Errorf("expected gitlab_tokens_total in response, got:\n%s", body)
 	}
 }
 
-func TestHandler_SetTokenExpiresAt(t *testing.T) {
-	resetPrometheusRegistry()
+func TestHandler_Update(t *testing.T) {
 	handler := NewHandler()
 
-	// Тестируем установку времени истечения
 	expiresAt := time.Now().Add(time.Hour)
-	handler.SetTokenExpiresAt("test-token", expiresAt)
-
-	// Тестируем истекший токен
-	expiredAt := time.Now().Add(-time.Hour)
-	handler.SetTokenExpiresAt("expired-token", expiredAt)
-}
+	handler.Update(Snapshot{Tokens: []Token{{Labels: testToken("test-token"), ExpiresAt: &expiresAt}}})
 
-func TestHandler_SetTokenIsExpired(t *testing.T) {
-	resetPrometheusRegistry()
-	handler := NewHandler()
-
-	// Тестируем активный токен
-	handler.SetTokenIsExpired("active-token", false)
+	body := fetchMetrics(t, handler)
+	if !strings.Contains(body, `token_name="test-token"`) {
+		t.Errorf("expected test-token in response, got:\n%s", body)
+	}
 
-	// Тестируем истекший токен
-	handler.SetTokenIsExpired("expired-token", true)
+	// Новый снапшот полностью заменяет предыдущий
+	handler.Update(Snapshot{})
+	body = fetchMetrics(t, handler)
+	if strings.Contains(body, `token_name="test-token"`) {
+		t.Errorf("expected test-token to be removed, got:\n%s", body)
+	}
 }
 
 func TestHandler_RecordScrapeDuration(t *testing.T) {
-	resetPrometheusRegistry()
 	handler := NewHandler()
 
 	// Тестируем запись различных длительностей
@@ -137,20 +150,31 @@ func TestHandler_RecordScrapeDuration(t *testing.T) {
 	for _, duration := range durations {
 		handler.RecordScrapeDuration(duration)
 	}
+
+	body := fetchMetrics(t, handler)
+	if !strings.Contains(body, "gitlab_token_scrape_duration_seconds_count 3") {
+		t.Errorf("expected 3 observations, got:\n%s", body)
+	}
 }
 
 func TestHandler_IncrementScrapeErrors(t *testing.T) {
-	resetPrometheusRegistry()
 	handler := NewHandler()
 
 	// Тестируем инкремент ошибок
 	for i := 0; i < 5; i++ {
 		handler.IncrementScrapeErrors()
 	}
+
+	body := fetchMetrics(t, handler)
+	if !strings.Contains(body, "gitlab_token_scrape_errors_total 5") {
+		t.Errorf("expected 5 errors, got:\n%s", body)
+	}
+	if got := handler.ScrapeErrors(); got != 5 {
+		t.Errorf("ScrapeErrors() = %d, want 5", got)
+	}
 }
 
 func TestHandler_SetLastScrapeTime(t *testing.T) {
-	resetPrometheusRegistry()
 	handler := NewHandler()
 
 	// Тестируем установку времени последнего scrape
@@ -162,75 +186,113 @@ func TestHandler_SetLastScrapeTime(t *testing.T) {
 	handler.SetLastScrapeTime(past)
 }
 
-func TestHandler_DeleteTokenMetrics(t *testing.T) {
-	resetPrometheusRegistry()
+func TestHandler_ObserveRetry(t *testing.T) {
 	handler := NewHandler()
 
-	// Создаем токен и устанавливаем его метрики
-	tokenName := "test-token"
-	expiresAt := time.Now().Add(time.Hour)
-
-	handler.SetTokenExpiresAt(tokenName, expiresAt)
-	handler.SetTokenIsExpired(tokenName, false)
-
-	// Удаляем метрики токена
-	handler.DeleteTokenMetrics(tokenName)
-
-	// В реальном приложении мы бы проверяли через HTTP endpoint, что метрики удалены
-	// Здесь мы просто проверяем, что метод выполняется без ошибок
+	handler.ObserveRetry("rate_limited")
+	handler.ObserveRetry("rate_limited")
+	handler.ObserveRetry("server_error")
+
+	body := fetchMetrics(t, handler)
+	for _, want := range []string{
+		`gitlab_api_retries_total{reason="rate_limited"} 2`,
+		`gitlab_api_retries_total{reason="server_error"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
 }
 
-func TestHandler_SetTotalUserTokens(t *testing.T) {
-	resetPrometheusRegistry()
+func TestHandler_ObserveThrottle(t *testing.T) {
 	handler := NewHandler()
 
-	// Тестируем установку различных значений
-	testCases := []int{0, 1, 10, 100}
-
-	for _, total := range testCases {
-		handler.SetTotalUserTokens(total)
-		// В реальном приложении мы бы проверяли значение через HTTP endpoint
+	handler.ObserveThrottle(250 * time.Millisecond)
+	handler.ObserveThrottle(750 * time.Millisecond)
+
+	body := fetchMetrics(t, handler)
+	for _, want := range []string{
+		"gitlab_api_throttle_wait_seconds_count 2",
+		"gitlab_api_throttle_wait_seconds_sum 1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
 	}
 }
 
-func TestHandler_SetUserTokenExpiresAt(t *testing.T) {
-	resetPrometheusRegistry()
+func TestHandler_ObserveNameCache(t *testing.T) {
 	handler := NewHandler()
 
-	// Тестируем установку времени истечения
-	expiresAt := time.Now().Add(time.Hour)
-	handler.SetUserTokenExpiresAt("test-user-token", expiresAt)
-
-	// Тестируем истекший токен
-	expiredAt := time.Now().Add(-time.Hour)
-	handler.SetUserTokenExpiresAt("expired-user-token", expiredAt)
+	handler.ObserveNameCache("project", false)
+	handler.ObserveNameCache("project", true)
+	handler.ObserveNameCache("project", true)
+
+	body := fetchMetrics(t, handler)
+	for _, want := range []string{
+		`gitlab_name_cache_requests_total{kind="project",result="hit"} 2`,
+		`gitlab_name_cache_requests_total{kind="project",result="miss"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
 }
 
-func TestHandler_SetUserTokenIsExpired(t *testing.T) {
-	resetPrometheusRegistry()
+func TestHandler_SetDiscoveredTargets(t *testing.T) {
 	handler := NewHandler()
 
-	// Тестируем активный токен
-	handler.SetUserTokenIsExpired("active-user-token", false)
+	handler.SetDiscoveredTargets(12, 3)
 
-	// Тестируем истекший токен
-	handler.SetUserTokenIsExpired("expired-user-token", true)
+	body := fetchMetrics(t, handler)
+	for _, want := range []string{
+		`gitlab_discovered_targets{kind="project"} 12`,
+		`gitlab_discovered_targets{kind="group"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
 }
 
-func TestHandler_DeleteUserTokenMetrics(t *testing.T) {
-	resetPrometheusRegistry()
+func TestHandler_ScrapeProgress(t *testing.T) {
 	handler := NewHandler()
 
-	// Создаем пользовательский токен и устанавливаем его метрики
-	tokenName := "test-user-token"
-	expiresAt := time.Now().Add(time.Hour)
+	handler.SetScrapeTargets(KindProject, 3)
+	handler.IncrementScrapeProgress(KindProject)
+	handler.IncrementScrapeProgress(KindProject)
+
+	body := fetchMetrics(t, handler)
+	for _, want := range []string{
+		`gitlab_scrape_targets{kind="project"} 3`,
+		`gitlab_scrape_targets_processed{kind="project"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
 
-	handler.SetUserTokenExpiresAt(tokenName, expiresAt)
-	handler.SetUserTokenIsExpired(tokenName, false)
+	// Новый проход сбрасывает прогресс
+	handler.SetScrapeTargets(KindProject, 5)
+	if body := fetchMetrics(t, handler); !strings.Contains(body, `gitlab_scrape_targets_processed{kind="project"} 0`) {
+		t.Error("expected progress to be reset by SetScrapeTargets")
+	}
+}
 
-	// Удаляем метрики пользовательского токена
-	handler.DeleteUserTokenMetrics(tokenName)
+func TestHandler_ObserveRotation(t *testing.T) {
+	handler := NewHandler()
 
-	// В реальном приложении мы бы проверяли через HTTP endpoint, что метрики удалены
-	// Здесь мы просто проверяем, что метод выполняется без ошибок
+	handler.ObserveRotation("ci", "project", "success")
+	handler.ObserveRotation("ci", "project", "success")
+	handler.ObserveRotation("ci", "group", "dry_run")
+
+	body := fetchMetrics(t, handler)
+	for _, want := range []string{
+		`gitlab_token_rotations_total{owner_kind="project",result="success",rule="ci"} 2`,
+		`gitlab_token_rotations_total{owner_kind="group",result="dry_run",rule="ci"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
 }
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LabelScope - имя метки с одним scope в агрегированных метриках
+const LabelScope = "scope"
+
+// InventoryCount - количество токенов владельца с одним scope или уровнем доступа
+type InventoryCount struct {
+	OwnerKind string
+	OwnerID   int
+	OwnerName string
+	// Value - scope или уровень доступа
+	Value string
+	Count int
+}
+
+// inventoryDescs - описания агрегированных метрик по scopes и уровням доступа
+type inventoryDescs struct {
+	byScope       *prometheus.Desc
+	byAccessLevel *prometheus.Desc
+}
+
+func newInventoryDescs() inventoryDescs {
+	return inventoryDescs{
+		byScope: prometheus.NewDesc(
+			"gitlab_access_tokens_by_scope",
+			"Number of access tokens of the owner carrying the scope",
+			[]string{LabelOwnerKind, LabelOwnerID, LabelOwnerName, LabelScope}, nil,
+		),
+		byAccessLevel: prometheus.NewDesc(
+			"gitlab_access_tokens_by_access_level",
+			"Number of project and group access tokens of the owner with the access level",
+			[]string{LabelOwnerKind, LabelOwnerID, LabelOwnerName, LabelAccessLevel}, nil,
+		),
+	}
+}
+
+func (d inventoryDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- d.byScope
+	ch <- d.byAccessLevel
+}
+
+func (d inventoryDescs) collect(ch chan<- prometheus.Metric, byScope, byAccessLevel []InventoryCount) {
+	for _, count := range byScope {
+		ch <- prometheus.MustNewConstMetric(d.byScope, prometheus.GaugeValue, float64(count.Count), count.values()...)
+	}
+	for _, count := range byAccessLevel {
+		ch <- prometheus.MustNewConstMetric(d.byAccessLevel, prometheus.GaugeValue, float64(count.Count), count.values()...)
+	}
+}
+
+func (c InventoryCount) values() []string {
+	return []string{c.OwnerKind, strconv.Itoa(c.OwnerID), c.OwnerName, c.Value}
+}
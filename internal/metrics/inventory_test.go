@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestTokenCollector_Inventory(t *testing.T) {
+	collector := newTokenCollector(false, false)
+	collector.update(Snapshot{
+		Tokens: []Token{
+			{Labels: TokenLabels{OwnerKind: KindProject, OwnerID: 1, OwnerName: "api", TokenID: 1, TokenName: "bot", Scopes: []string{"api"}, AccessLevel: "maintainer"}},
+		},
+		ScopeCounts: []InventoryCount{
+			{OwnerKind: KindProject, OwnerID: 1, OwnerName: "api", Value: "api", Count: 2},
+			{OwnerKind: KindProject, OwnerID: 1, OwnerName: "api", Value: "read_registry", Count: 1},
+		},
+		AccessLevelCounts: []InventoryCount{
+			{OwnerKind: KindProject, OwnerID: 1, OwnerName: "api", Value: "maintainer", Count: 2},
+		},
+	})
+
+	values := gatherByLabels(t, collector, LabelScope, LabelAccessLevel)
+
+	tests := []struct {
+		metric string
+		key    string
+		want   float64
+	}{
+		{"gitlab_access_token_info", "maintainer", 1},
+		{"gitlab_access_tokens_by_scope", "api", 2},
+		{"gitlab_access_tokens_by_scope", "read_registry", 1},
+		{"gitlab_access_tokens_by_access_level", "maintainer", 2},
+	}
+
+	for _, tt := range tests {
+		got, ok := values[tt.metric][tt.key]
+		if !ok {
+			t.Errorf("%s{%q} not found", tt.metric, tt.key)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s{%q} = %v, want %v", tt.metric, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Типы ключей (значения метки key_type)
+const (
+	KeyTypeDeploy = "deploy_key"
+	KeyTypeSSH    = "ssh_key"
+	KeyTypeGPG    = "gpg_key"
+)
+
+// Имена меток ключей
+const (
+	LabelKeyType     = "key_type"
+	LabelKeyID       = "key_id"
+	LabelTitle       = "title"
+	LabelFingerprint = "fingerprint"
+)
+
+// Key - deploy-ключ проекта или SSH/GPG-ключ пользователя в снапшоте скрейпера
+type Key struct {
+	Type string
+	// OwnerKind - project для deploy-ключей, user для ключей пользователей,
+	// instance для deploy-ключей, не подключенных ни к одному проекту
+	OwnerKind string
+	OwnerID   int
+	OwnerName string
+	ID        int
+	Title     string
+	// Fingerprint - SHA256-отпечаток SSH-ключа или отпечаток GPG-ключа
+	Fingerprint string
+	// ExpiresAt - время истечения ключа, nil для бессрочных ключей
+	ExpiresAt *time.Time
+}
+
+// keyDescs - описания метрик ключей
+type keyDescs struct {
+	expiryTimestamp *prometheus.Desc
+	isExpired       *prometheus.Desc
+	neverExpires    *prometheus.Desc
+	total           *prometheus.Desc
+}
+
+// keyLabelNames возвращает список имен меток ключа
+func keyLabelNames() []string {
+	return []string{
+		LabelKeyType,
+		LabelOwnerKind,
+		LabelOwnerID,
+		LabelOwnerName,
+		LabelKeyID,
+		LabelTitle,
+		LabelFingerprint,
+	}
+}
+
+// values возвращает значения меток в порядке keyLabelNames
+func (k Key) values() []string {
+	return []string{
+		k.Type,
+		k.OwnerKind,
+		strconv.Itoa(k.OwnerID),
+		k.OwnerName,
+		strconv.Itoa(k.ID),
+		k.Title,
+		k.Fingerprint,
+	}
+}
+
+func newKeyDescs() keyDescs {
+	labels := keyLabelNames()
+	return keyDescs{
+		expiryTimestamp: prometheus.NewDesc(
+			"gitlab_key_expiry_timestamp_seconds",
+			"Unix timestamp when the key expires",
+			labels, nil,
+		),
+		isExpired: prometheus.NewDesc(
+			"gitlab_key_is_expired",
+			"Whether key is expired (1) or not (0)",
+			labels, nil,
+		),
+		neverExpires: prometheus.NewDesc(
+			"gitlab_key_never_expires",
+			"Whether key has no expiration date (1) or not (0)",
+			labels, nil,
+		),
+		total: prometheus.NewDesc(
+			"gitlab_keys_total",
+			"Total number of keys",
+			[]string{LabelKeyType}, nil,
+		),
+	}
+}
+
+func (d keyDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- d.expiryTimestamp
+	ch <- d.isExpired
+	ch <- d.neverExpires
+	ch <- d.total
+}
+
+func (d keyDescs) collect(ch chan<- prometheus.Metric, keys []Key, now time.Time) {
+	totals := map[string]int{KeyTypeDeploy: 0, KeyTypeSSH: 0, KeyTypeGPG: 0}
+
+	for _, key := range keys {
+		totals[key.Type]++
+		labelValues := key.values()
+
+		if key.ExpiresAt == nil {
+			ch <- prometheus.MustNewConstMetric(d.isExpired, prometheus.GaugeValue, 0, labelValues...)
+			ch <- prometheus.MustNewConstMetric(d.neverExpires, prometheus.GaugeValue, 1, labelValues...)
+			continue
+		}
+
+		expiresAt := *key.ExpiresAt
+		ch <- prometheus.MustNewConstMetric(d.isExpired, prometheus.GaugeValue, boolToFloat(expiresAt.Before(now)), labelValues...)
+		ch <- prometheus.MustNewConstMetric(d.neverExpires, prometheus.GaugeValue, 0, labelValues...)
+		ch <- prometheus.MustNewConstMetric(d.expiryTimestamp, prometheus.GaugeValue, float64(expiresAt.Unix()), labelValues...)
+	}
+
+	for keyType, total := range totals {
+		ch <- prometheus.MustNewConstMetric(d.total, prometheus.GaugeValue, float64(total), keyType)
+	}
+}
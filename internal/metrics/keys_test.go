@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenCollector_Keys(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	future := now.Add(24 * time.Hour)
+	past := now.Add(-time.Hour)
+
+	collector := newTokenCollector(false, false)
+	collector.now = func() time.Time { return now }
+	collector.update(Snapshot{Keys: []Key{
+		{Type: KeyTypeDeploy, OwnerKind: KindProject, OwnerID: 1, OwnerName: "api", ID: 10, Title: "ci-deploy", Fingerprint: "SHA256:abc", ExpiresAt: &future},
+		{Type: KeyTypeSSH, OwnerKind: KindUser, OwnerID: 2, OwnerName: "Alice", ID: 11, Title: "laptop", ExpiresAt: &past},
+		{Type: KeyTypeGPG, OwnerKind: KindUser, OwnerID: 2, OwnerName: "Alice", ID: 12, Title: "Alice <alice@example.com>"},
+	}})
+
+	values := gatherByLabels(t, collector, LabelTitle, LabelKeyType)
+
+	tests := []struct {
+		metric string
+		key    string
+		want   float64
+	}{
+		{"gitlab_key_expiry_timestamp_seconds", "ci-deploy", float64(future.Unix())},
+		{"gitlab_key_is_expired", "ci-deploy", 0},
+		{"gitlab_key_is_expired", "laptop", 1},
+		{"gitlab_key_never_expires", "laptop", 0},
+		{"gitlab_key_never_expires", "Alice <alice@example.com>", 1},
+		{"gitlab_keys_total", KeyTypeDeploy, 1},
+		{"gitlab_keys_total", KeyTypeSSH, 1},
+		{"gitlab_keys_total", KeyTypeGPG, 1},
+	}
+
+	for _, tt := range tests {
+		got, ok := values[tt.metric][tt.key]
+		if !ok {
+			t.Errorf("%s{%q} not found", tt.metric, tt.key)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s{%q} = %v, want %v", tt.metric, tt.key, got, tt.want)
+		}
+	}
+
+	if _, ok := values["gitlab_key_expiry_timestamp_seconds"]["Alice <alice@example.com>"]; ok {
+		t.Error("expiry timestamp must not be exported for a key without expiration date")
+	}
+}
+
+func TestKey_Values(t *testing.T) {
+	key := Key{Type: KeyTypeSSH, OwnerKind: KindUser, OwnerID: 2, OwnerName: "Alice", ID: 11, Title: "laptop", Fingerprint: "SHA256:abc"}
+
+	values := key.values()
+	names := keyLabelNames()
+	if len(values) != len(names) {
+		t.Fatalf("got %d values for %d labels", len(values), len(names))
+	}
+
+	want := map[string]string{
+		LabelKeyType:     "ssh_key",
+		LabelOwnerID:     "2",
+		LabelFingerprint: "SHA256:abc",
+	}
+	for i, name := range names {
+		if expected, ok := want[name]; ok && values[i] != expected {
+			t.Errorf("label %s = %q, want %q", name, values[i], expected)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Типы владельцев токенов (значения метки owner_kind)
+const (
+	KindProject = "project"
+	KindUser    = "user"
+	KindGroup   = "group"
+	// KindInstance - ресурс уровня инстанса, не привязанный к проекту или группе
+	KindInstance = "instance"
+)
+
+// Имена меток, описывающих токен
+const (
+	LabelOwnerKind   = "owner_kind"
+	LabelOwnerID     = "owner_id"
+	LabelOwnerName   = "owner_name"
+	LabelTokenID     = "token_id"
+	LabelTokenName   = "token_name"
+	LabelScopes      = "scopes"
+	LabelAccessLevel = "access_level"
+	LabelUsername    = "username"
+	// LabelLegacyName - устаревшая метка "name" для режима совместимости
+	LabelLegacyName = "name"
+	// LabelInstance - имя инстанса GitLab в режиме нескольких инстансов
+	LabelInstance = "instance"
+)
+
+// TokenLabels - набор меток, идентифицирующих токен в метриках
+type TokenLabels struct {
+	OwnerKind   string
+	OwnerID     int
+	OwnerName   string
+	TokenID     int
+	TokenName   string
+	Scopes      []string
+	AccessLevel string
+}
+
+// tokenLabelNames возвращает список имен меток токена
+func tokenLabelNames(legacyName bool) []string {
+	names := []string{
+		LabelOwnerKind,
+		LabelOwnerID,
+		LabelOwnerName,
+		LabelTokenID,
+		LabelTokenName,
+		LabelScopes,
+		LabelAccessLevel,
+	}
+	if legacyName {
+		names = append(names, LabelLegacyName)
+	}
+	return names
+}
+
+// Key возвращает уникальный ключ токена (тип владельца, ID владельца и ID токена)
+func (t TokenLabels) Key() string {
+	return t.OwnerKind + "/" + strconv.Itoa(t.OwnerID) + "/" + strconv.Itoa(t.TokenID)
+}
+
+// Equal сообщает, совпадают ли все метки двух токенов
+func (t TokenLabels) Equal(other TokenLabels) bool {
+	return t.OwnerKind == other.OwnerKind &&
+		t.OwnerID == other.OwnerID &&
+		t.OwnerName == other.OwnerName &&
+		t.TokenID == other.TokenID &&
+		t.TokenName == other.TokenName &&
+		t.ScopesValue() == other.ScopesValue() &&
+		t.AccessLevel == other.AccessLevel
+}
+
+// LegacyName возвращает значение устаревшей метки "name" в формате "<владелец> <ID> <токен>"
+func (t TokenLabels) LegacyName() string {
+	return t.OwnerName + " " + strconv.Itoa(t.OwnerID) + " " + t.TokenName
+}
+
+// ScopesValue возвращает отсортированный список scopes через запятую
+func (t TokenLabels) ScopesValue() string {
+	scopes := append([]string(nil), t.Scopes...)
+	sort.Strings(scopes)
+	return strings.Join(scopes, ",")
+}
+
+// values возвращает значения меток в порядке tokenLabelNames
+func (t TokenLabels) values(legacyName bool) []string {
+	values := []string{
+		t.OwnerKind,
+		strconv.Itoa(t.OwnerID),
+		t.OwnerName,
+		strconv.Itoa(t.TokenID),
+		t.TokenName,
+		t.ScopesValue(),
+		t.AccessLevel,
+	}
+	if legacyName {
+		values = append(values, t.LegacyName())
+	}
+	return values
+}
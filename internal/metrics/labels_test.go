@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestTokenLabels_LegacyName(t *testing.T) {
+	token := TokenLabels{OwnerKind: KindProject, OwnerID: 42, OwnerName: "My Project", TokenID: 7, TokenName: "ci"}
+
+	if got, want := token.LegacyName(), "My Project 42 ci"; got != want {
+		t.Errorf("LegacyName() = %q, want %q", got, want)
+	}
+}
+
+func TestTokenLabels_Key(t *testing.T) {
+	first := TokenLabels{OwnerKind: KindProject, OwnerID: 1, OwnerName: "Project", TokenID: 1, TokenName: "same"}
+	second := TokenLabels{OwnerKind: KindProject, OwnerID: 1, OwnerName: "Project", TokenID: 2, TokenName: "same"}
+	group := TokenLabels{OwnerKind: KindGroup, OwnerID: 1, OwnerName: "Project", TokenID: 1, TokenName: "same"}
+
+	// Токены с одинаковым именем не должны иметь одинаковый ключ
+	if first.Key() == second.Key() {
+		t.Errorf("tokens with different IDs share key %q", first.Key())
+	}
+	if first.Key() == group.Key() {
+		t.Errorf("tokens of different owner kinds share key %q", first.Key())
+	}
+}
+
+func TestTokenLabels_ScopesValue(t *testing.T) {
+	token := TokenLabels{Scopes: []string{"write_repository", "api", "read_api"}}
+
+	if got, want := token.ScopesValue(), "api,read_api,write_repository"; got != want {
+		t.Errorf("ScopesValue() = %q, want %q", got, want)
+	}
+	// Исходный срез не должен изменяться
+	if token.Scopes[0] != "write_repository" {
+		t.Errorf("ScopesValue() modified Scopes: %v", token.Scopes)
+	}
+}
+
+func TestTokenLabels_Equal(t *testing.T) {
+	token := TokenLabels{OwnerKind: KindGroup, OwnerID: 1, OwnerName: "Group", TokenID: 2, TokenName: "bot", Scopes: []string{"api", "read_api"}}
+
+	reordered := token
+	reordered.Scopes = []string{"read_api", "api"}
+	if !token.Equal(reordered) {
+		t.Error("Equal() = false for tokens differing only in scopes order")
+	}
+
+	renamed := token
+	renamed.OwnerName = "Renamed"
+	if token.Equal(renamed) {
+		t.Error("Equal() = true for tokens with different owner names")
+	}
+}
+
+func TestTokenLabels_Values(t *testing.T) {
+	token := TokenLabels{OwnerKind: KindUser, OwnerID: 5, OwnerName: "John", TokenID: 9, TokenName: "pat", Scopes: []string{"read_user"}}
+
+	values := token.values(false)
+	if len(values) != len(tokenLabelNames(false)) {
+		t.Errorf("got %d values, want %d", len(values), len(tokenLabelNames(false)))
+	}
+
+	values = token.values(true)
+	if len(values) != len(tokenLabelNames(true)) {
+		t.Fatalf("got %d values, want %d", len(values), len(tokenLabelNames(true)))
+	}
+	// Устаревшая метка "name" всегда идет последней
+	if got, want := values[len(values)-1], "John 5 pat"; got != want {
+		t.Errorf("legacy name label = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Типы CI/CD ресурсов (значения метки kind)
+const (
+	PipelineKindTrigger  = "trigger"
+	PipelineKindSchedule = "schedule"
+)
+
+// Имена меток CI/CD ресурсов
+const (
+	LabelKind          = "kind"
+	LabelProjectID     = "project_id"
+	LabelProjectName   = "project_name"
+	LabelID            = "id"
+	LabelDescription   = "description"
+	LabelOwnerUsername = "owner_username"
+	LabelOwnerState    = "owner_state"
+)
+
+// OwnerStateMissing - значение owner_state для ресурса без владельца
+const OwnerStateMissing = "missing"
+
+// PipelineResource - триггер или расписание пайплайнов проекта в снапшоте скрейпера
+type PipelineResource struct {
+	Kind          string
+	ProjectID     int
+	ProjectName   string
+	ID            int
+	Description   string
+	OwnerUsername string
+	// OwnerState - состояние владельца (active, blocked, deactivated, ...) или OwnerStateMissing
+	OwnerState string
+	// LastUsed - время последнего использования триггера, nil если неизвестно
+	LastUsed *time.Time
+	// NextRunAt - время следующего запуска расписания
+	NextRunAt *time.Time
+	// Active - расписание включено
+	Active bool
+}
+
+// OwnerInactive сообщает, что владелец заблокирован, деактивирован или отсутствует,
+// и пайплайны от его имени перестанут запускаться
+func (r PipelineResource) OwnerInactive() bool {
+	return r.OwnerState != "active"
+}
+
+func (r PipelineResource) values() []string {
+	return []string{
+		r.Kind,
+		strconv.Itoa(r.ProjectID),
+		r.ProjectName,
+		strconv.Itoa(r.ID),
+		r.Description,
+		r.OwnerUsername,
+		r.OwnerState,
+	}
+}
+
+// pipelineDescs - описания метрик триггеров и расписаний пайплайнов
+type pipelineDescs struct {
+	lastUsed      *prometheus.Desc
+	nextRun       *prometheus.Desc
+	active        *prometheus.Desc
+	ownerInactive *prometheus.Desc
+	total         *prometheus.Desc
+}
+
+func newPipelineDescs() pipelineDescs {
+	labels := []string{
+		LabelKind,
+		LabelProjectID,
+		LabelProjectName,
+		LabelID,
+		LabelDescription,
+		LabelOwnerUsername,
+		LabelOwnerState,
+	}
+	return pipelineDescs{
+		lastUsed: prometheus.NewDesc(
+			"gitlab_pipeline_trigger_last_used_timestamp_seconds",
+			"Unix timestamp when the pipeline trigger token was last used",
+			labels, nil,
+		),
+		nextRun: prometheus.NewDesc(
+			"gitlab_pipeline_schedule_next_run_timestamp_seconds",
+			"Unix timestamp of the next pipeline schedule run",
+			labels, nil,
+		),
+		active: prometheus.NewDesc(
+			"gitlab_pipeline_schedule_active",
+			"Whether pipeline schedule is active (1) or not (0)",
+			labels, nil,
+		),
+		ownerInactive: prometheus.NewDesc(
+			"gitlab_pipeline_owner_inactive",
+			"Whether the owner of a pipeline trigger or schedule is blocked, deactivated or missing (1) or not (0)",
+			labels, nil,
+		),
+		total: prometheus.NewDesc(
+			"gitlab_pipeline_resources_total",
+			"Total number of pipeline triggers and schedules",
+			[]string{LabelKind}, nil,
+		),
+	}
+}
+
+func (d pipelineDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- d.lastUsed
+	ch <- d.nextRun
+	ch <- d.active
+	ch <- d.ownerInactive
+	ch <- d.total
+}
+
+func (d pipelineDescs) collect(ch chan<- prometheus.Metric, resources []PipelineResource) {
+	totals := map[string]int{PipelineKindTrigger: 0, PipelineKindSchedule: 0}
+
+	for _, resource := range resources {
+		totals[resource.Kind]++
+		labelValues := resource.values()
+
+		ch <- prometheus.MustNewConstMetric(d.ownerInactive, prometheus.GaugeValue, boolToFloat(resource.OwnerInactive()), labelValues...)
+		if resource.LastUsed != nil {
+			ch <- prometheus.MustNewConstMetric(d.lastUsed, prometheus.GaugeValue, float64(resource.LastUsed.Unix()), labelValues...)
+		}
+		if resource.Kind == PipelineKindSchedule {
+			ch <- prometheus.MustNewConstMetric(d.active, prometheus.GaugeValue, boolToFloat(resource.Active), labelValues...)
+			if resource.NextRunAt != nil {
+				ch <- prometheus.MustNewConstMetric(d.nextRun, prometheus.GaugeValue, float64(resource.NextRunAt.Unix()), labelValues...)
+			}
+		}
+	}
+
+	for kind, total := range totals {
+		ch <- prometheus.MustNewConstMetric(d.total, prometheus.GaugeValue, float64(total), kind)
+	}
+}
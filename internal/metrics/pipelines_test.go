@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gatherByLabels собирает значения метрик в виде "имя метрики" -> значение метки -> значение.
+// Для каждой метрики используется первая непустая метка из labels.
+func gatherByLabels(t *testing.T, collector prometheus.Collector, labels ...string) map[string]map[string]float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	values := make(map[string]map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = make(map[string]float64)
+		for _, metric := range family.GetMetric() {
+			var key string
+			for _, label := range labels {
+				if key = labelValue(metric, label); key != "" {
+					break
+				}
+			}
+			values[family.GetName()][key] = metric.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func TestPipelineResource_OwnerInactive(t *testing.T) {
+	tests := []struct {
+		state string
+		want  bool
+	}{
+		{"active", false},
+		{"blocked", true},
+		{"deactivated", true},
+		{"banned", true},
+		{OwnerStateMissing, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			if got := (PipelineResource{OwnerState: tt.state}).OwnerInactive(); got != tt.want {
+				t.Errorf("OwnerInactive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenCollector_Pipelines(t *testing.T) {
+	lastUsed := time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)
+	nextRun := time.Date(2025, time.June, 2, 3, 0, 0, 0, time.UTC)
+
+	collector := newTokenCollector(false, false)
+	collector.update(Snapshot{Pipelines: []PipelineResource{
+		{Kind: PipelineKindTrigger, ProjectID: 1, ID: 1, Description: "deploy", OwnerUsername: "alice", OwnerState: "active", LastUsed: &lastUsed},
+		{Kind: PipelineKindTrigger, ProjectID: 1, ID: 2, Description: "unused", OwnerUsername: "bob", OwnerState: "blocked"},
+		{Kind: PipelineKindSchedule, ProjectID: 1, ID: 3, Description: "nightly", OwnerUsername: "carol", OwnerState: "deactivated", NextRunAt: &nextRun, Active: true},
+	}})
+
+	values := gatherByLabels(t, collector, LabelDescription, LabelKind)
+
+	tests := []struct {
+		metric string
+		key    string
+		want   float64
+	}{
+		{"gitlab_pipeline_trigger_last_used_timestamp_seconds", "deploy", float64(lastUsed.Unix())},
+		{"gitlab_pipeline_owner_inactive", "deploy", 0},
+		{"gitlab_pipeline_owner_inactive", "unused", 1},
+		{"gitlab_pipeline_owner_inactive", "nightly", 1},
+		{"gitlab_pipeline_schedule_active", "nightly", 1},
+		{"gitlab_pipeline_schedule_next_run_timestamp_seconds", "nightly", float64(nextRun.Unix())},
+		{"gitlab_pipeline_resources_total", PipelineKindTrigger, 2},
+		{"gitlab_pipeline_resources_total", PipelineKindSchedule, 1},
+	}
+
+	for _, tt := range tests {
+		got, ok := values[tt.metric][tt.key]
+		if !ok {
+			t.Errorf("%s{%q} not found", tt.metric, tt.key)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s{%q} = %v, want %v", tt.metric, tt.key, got, tt.want)
+		}
+	}
+
+	if _, ok := values["gitlab_pipeline_trigger_last_used_timestamp_seconds"]["unused"]; ok {
+		t.Error("last used timestamp must not be exported for a trigger that was never used")
+	}
+}
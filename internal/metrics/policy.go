@@ -0,0 +1,12 @@
+package metrics
+
+// LabelRule - имя метки с названием нарушенного правила политики
+const LabelRule = "rule"
+
+// PolicyViolation - нарушение правила политики одним токеном
+type PolicyViolation struct {
+	Rule   string
+	Labels TokenLabels
+	// Reason - пояснение для логов, в метки не попадает
+	Reason string
+}
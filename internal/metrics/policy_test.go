@@ -0,0 +1,27 @@
+package metrics
+
+import "testing"
+
+func TestTokenCollector_PolicyViolations(t *testing.T) {
+	token := testToken("deploy")
+
+	collector := newTokenCollector(false, false)
+	collector.update(Snapshot{
+		Tokens: []Token{{Labels: token}},
+		// Один токен может нарушать несколько правил одновременно
+		PolicyViolations: []PolicyViolation{
+			{Rule: "require-expiry", Labels: token, Reason: "token never expires"},
+			{Rule: "no-owner", Labels: token, Reason: "forbidden access level"},
+		},
+	})
+
+	values := gatherByLabels(t, collector, LabelRule)["gitlab_token_policy_violation"]
+	if len(values) != 2 {
+		t.Fatalf("got %d policy violations, want 2: %v", len(values), values)
+	}
+	for _, rule := range []string{"require-expiry", "no-owner"} {
+		if got, ok := values[rule]; !ok || got != 1 {
+			t.Errorf("gitlab_token_policy_violation{rule=%q} = %v (found %v), want 1", rule, got, ok)
+		}
+	}
+}
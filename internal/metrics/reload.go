@@ -0,0 +1,56 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// ConfigReloads - метрики перезагрузки конфигурации. Они относятся ко всему процессу,
+// поэтому регистрируются отдельно от Handler и не получают метку instance.
+type ConfigReloads struct {
+	reloads        *prometheus.CounterVec
+	lastReloadOK   prometheus.Gauge
+	lastReloadTime prometheus.Gauge
+}
+
+// NewConfigReloads создает метрики перезагрузки и регистрирует их в registerer
+func NewConfigReloads(registerer prometheus.Registerer) *ConfigReloads {
+	r := &ConfigReloads{
+		reloads: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gitlab_token_exporter_config_reloads_total",
+				Help: "Total number of configuration reloads by result (success or failure)",
+			},
+			[]string{"result"},
+		),
+		lastReloadOK: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "gitlab_token_exporter_config_last_reload_successful",
+				Help: "Whether the last configuration reload succeeded (1 - yes, 0 - no)",
+			},
+		),
+		lastReloadTime: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "gitlab_token_exporter_config_last_reload_success_timestamp_seconds",
+				Help: "Timestamp of the last successful configuration load",
+			},
+		),
+	}
+
+	// Метрики создаются после успешной загрузки конфигурации при запуске
+	r.lastReloadOK.Set(1)
+	r.lastReloadTime.SetToCurrentTime()
+
+	registerer.MustRegister(r.reloads, r.lastReloadOK, r.lastReloadTime)
+	return r
+}
+
+// ObserveConfigReload учитывает результат перезагрузки конфигурации
+func (r *ConfigReloads) ObserveConfigReload(success bool) {
+	if !success {
+		r.reloads.WithLabelValues("failure").Inc()
+		r.lastReloadOK.Set(0)
+		return
+	}
+
+	r.reloads.WithLabelValues("success").Inc()
+	r.lastReloadOK.Set(1)
+	r.lastReloadTime.SetToCurrentTime()
+}
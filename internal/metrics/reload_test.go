@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigReloads_ObserveConfigReload(t *testing.T) {
+	handler := NewHandler()
+	reloads := NewConfigReloads(handler.Registry())
+
+	// Конфигурация при запуске считается успешно загруженной
+	if body := fetchMetrics(t, handler); !strings.Contains(body, "gitlab_token_exporter_config_last_reload_successful 1") {
+		t.Error("expected successful config load after start")
+	}
+
+	reloads.ObserveConfigReload(true)
+	reloads.ObserveConfigReload(false)
+
+	body := fetchMetrics(t, handler)
+	for _, want := range []string{
+		`gitlab_token_exporter_config_reloads_total{result="success"} 1`,
+		`gitlab_token_exporter_config_reloads_total{result="failure"} 1`,
+		"gitlab_token_exporter_config_last_reload_successful 0",
+		"gitlab_token_exporter_config_last_reload_success_timestamp_seconds",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output", want)
+		}
+	}
+}
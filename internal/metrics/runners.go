@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Имена меток раннеров
+const (
+	LabelRunnerID   = "runner_id"
+	LabelRunnerType = "runner_type"
+)
+
+// RunnerStatusStale - статус раннера, который давно не связывался с GitLab
+const RunnerStatusStale = "stale"
+
+// Runner - раннер и его токен аутентификации в снапшоте скрейпера
+type Runner struct {
+	ID          int
+	Description string
+	// Type - instance_type, group_type или project_type
+	Type   string
+	Status string
+	Online bool
+	Paused bool
+	// ContactedAt - время последнего контакта раннера с GitLab, nil если раннер не подключался
+	ContactedAt *time.Time
+	// TokenExpiresAt - время истечения токена аутентификации, nil для бессрочных токенов
+	TokenExpiresAt *time.Time
+	// TokenExpiresSoon - токен истекает в пределах окна предупреждения или уже истек
+	TokenExpiresSoon bool
+}
+
+// runnerDescs - описания метрик раннеров
+type runnerDescs struct {
+	tokenExpiry      *prometheus.Desc
+	tokenExpired     *prometheus.Desc
+	tokenExpiresSoon *prometheus.Desc
+	online           *prometheus.Desc
+	stale            *prometheus.Desc
+	paused           *prometheus.Desc
+	lastContact      *prometheus.Desc
+	total            *prometheus.Desc
+}
+
+// runnerLabelNames возвращает список имен меток раннера
+func runnerLabelNames() []string {
+	return []string{
+		LabelRunnerID,
+		LabelDescription,
+		LabelRunnerType,
+	}
+}
+
+// values возвращает значения меток в порядке runnerLabelNames
+func (r Runner) values() []string {
+	return []string{
+		strconv.Itoa(r.ID),
+		r.Description,
+		r.Type,
+	}
+}
+
+func newRunnerDescs() runnerDescs {
+	labels := runnerLabelNames()
+	return runnerDescs{
+		tokenExpiry: prometheus.NewDesc(
+			"gitlab_runner_token_expiry_timestamp_seconds",
+			"Unix timestamp when the runner authentication token expires",
+			labels, nil,
+		),
+		tokenExpired: prometheus.NewDesc(
+			"gitlab_runner_token_is_expired",
+			"Whether runner authentication token is expired (1) or not (0)",
+			labels, nil,
+		),
+		tokenExpiresSoon: prometheus.NewDesc(
+			"gitlab_runner_token_expires_soon",
+			"Whether runner authentication token expires within the warning window (1) or not (0)",
+			labels, nil,
+		),
+		online: prometheus.NewDesc(
+			"gitlab_runner_online",
+			"Whether runner is online (1) or not (0)",
+			labels, nil,
+		),
+		stale: prometheus.NewDesc(
+			"gitlab_runner_stale",
+			"Whether runner is stale (1) or not (0)",
+			labels, nil,
+		),
+		paused: prometheus.NewDesc(
+			"gitlab_runner_paused",
+			"Whether runner is paused (1) or not (0)",
+			labels, nil,
+		),
+		lastContact: prometheus.NewDesc(
+			"gitlab_runner_last_contact_timestamp_seconds",
+			"Unix timestamp of the last runner contact with GitLab",
+			labels, nil,
+		),
+		total: prometheus.NewDesc(
+			"gitlab_runners_total",
+			"Total number of runners",
+			[]string{LabelRunnerType}, nil,
+		),
+	}
+}
+
+func (d runnerDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- d.tokenExpiry
+	ch <- d.tokenExpired
+	ch <- d.tokenExpiresSoon
+	ch <- d.online
+	ch <- d.stale
+	ch <- d.paused
+	ch <- d.lastContact
+	ch <- d.total
+}
+
+func (d runnerDescs) collect(ch chan<- prometheus.Metric, runners []Runner, now time.Time) {
+	totals := make(map[string]int)
+
+	for _, runner := range runners {
+		totals[runner.Type]++
+		labelValues := runner.values()
+
+		ch <- prometheus.MustNewConstMetric(d.online, prometheus.GaugeValue, boolToFloat(runner.Online), labelValues...)
+		ch <- prometheus.MustNewConstMetric(d.stale, prometheus.GaugeValue, boolToFloat(runner.Status == RunnerStatusStale), labelValues...)
+		ch <- prometheus.MustNewConstMetric(d.paused, prometheus.GaugeValue, boolToFloat(runner.Paused), labelValues...)
+		ch <- prometheus.MustNewConstMetric(d.tokenExpiresSoon, prometheus.GaugeValue, boolToFloat(runner.TokenExpiresSoon), labelValues...)
+		if runner.ContactedAt != nil {
+			ch <- prometheus.MustNewConstMetric(d.lastContact, prometheus.GaugeValue, float64(runner.ContactedAt.Unix()), labelValues...)
+		}
+
+		if runner.TokenExpiresAt == nil {
+			ch <- prometheus.MustNewConstMetric(d.tokenExpired, prometheus.GaugeValue, 0, labelValues...)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(d.tokenExpired, prometheus.GaugeValue, boolToFloat(runner.TokenExpiresAt.Before(now)), labelValues...)
+		ch <- prometheus.MustNewConstMetric(d.tokenExpiry, prometheus.GaugeValue, float64(runner.TokenExpiresAt.Unix()), labelValues...)
+	}
+
+	for runnerType, total := range totals {
+		ch <- prometheus.MustNewConstMetric(d.total, prometheus.GaugeValue, float64(total), runnerType)
+	}
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenCollector_Runners(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	contacted := now.Add(-time.Minute)
+	soon := now.Add(72 * time.Hour)
+	past := now.Add(-time.Hour)
+
+	collector := newTokenCollector(false, false)
+	collector.now = func() time.Time { return now }
+	collector.update(Snapshot{Runners: []Runner{
+		{ID: 1, Description: "docker", Type: "instance_type", Status: "online", Online: true, ContactedAt: &contacted, TokenExpiresAt: &soon, TokenExpiresSoon: true},
+		{ID: 2, Description: "legacy", Type: "project_type", Status: RunnerStatusStale, Paused: true, TokenExpiresAt: &past, TokenExpiresSoon: true},
+		{ID: 3, Description: "forever", Type: "group_type", Status: "offline"},
+	}})
+
+	values := gatherByLabels(t, collector, LabelDescription, LabelRunnerType)
+
+	tests := []struct {
+		metric string
+		key    string
+		want   float64
+	}{
+		{"gitlab_runner_online", "docker", 1},
+		{"gitlab_runner_online", "legacy", 0},
+		{"gitlab_runner_stale", "legacy", 1},
+		{"gitlab_runner_stale", "docker", 0},
+		{"gitlab_runner_paused", "legacy", 1},
+		{"gitlab_runner_last_contact_timestamp_seconds", "docker", float64(contacted.Unix())},
+		{"gitlab_runner_token_expiry_timestamp_seconds", "docker", float64(soon.Unix())},
+		{"gitlab_runner_token_expires_soon", "docker", 1},
+		{"gitlab_runner_token_expires_soon", "forever", 0},
+		{"gitlab_runner_token_is_expired", "docker", 0},
+		{"gitlab_runner_token_is_expired", "legacy", 1},
+		{"gitlab_runner_token_is_expired", "forever", 0},
+		{"gitlab_runners_total", "project_type", 1},
+	}
+
+	for _, tt := range tests {
+		got, ok := values[tt.metric][tt.key]
+		if !ok {
+			t.Errorf("%s{%q} not found", tt.metric, tt.key)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s{%q} = %v, want %v", tt.metric, tt.key, got, tt.want)
+		}
+	}
+
+	if _, ok := values["gitlab_runner_token_expiry_timestamp_seconds"]["forever"]; ok {
+		t.Error("token expiry must not be exported for a runner without token expiration")
+	}
+	if _, ok := values["gitlab_runner_last_contact_timestamp_seconds"]["legacy"]; ok {
+		t.Error("last contact must not be exported for a runner that never contacted GitLab")
+	}
+}
@@ -0,0 +1,223 @@
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// Типы правил
+const (
+	// RuleMaxLifetime - срок жизни токена (от создания до истечения) не больше MaxLifetimeDays
+	RuleMaxLifetime = "max_lifetime"
+	// RuleForbiddenScopes - токен не должен иметь ни одного scope из Scopes
+	RuleForbiddenScopes = "forbidden_scopes"
+	// RuleRequireExpiry - у токена должна быть дата истечения
+	RuleRequireExpiry = "require_expiry"
+	// RuleNamePattern - имя токена должно соответствовать регулярному выражению Pattern
+	RuleNamePattern = "name_pattern"
+	// RuleMaxTokens - у владельца не больше MaxTokens токенов
+	RuleMaxTokens = "max_tokens"
+	// RuleForbiddenAccessLevels - токен не должен иметь уровень доступа из AccessLevels
+	RuleForbiddenAccessLevels = "forbidden_access_levels"
+)
+
+// Rule - правило гигиены токенов из файла политики
+type Rule struct {
+	// Name - имя правила, значение метки rule
+	Name string `yaml:"name" json:"name"`
+	Type string `yaml:"type" json:"type"`
+	// OwnerKinds - типы владельцев, к токенам которых применяется правило (пусто - ко всем)
+	OwnerKinds      []string `yaml:"owner_kinds" json:"owner_kinds"`
+	MaxLifetimeDays int      `yaml:"max_lifetime_days" json:"max_lifetime_days"`
+	Scopes          []string `yaml:"scopes" json:"scopes"`
+	Pattern         string   `yaml:"pattern" json:"pattern"`
+	MaxTokens       int      `yaml:"max_tokens" json:"max_tokens"`
+	AccessLevels    []string `yaml:"access_levels" json:"access_levels"`
+
+	pattern *regexp.Regexp
+}
+
+// Policy - набор правил, проверяемых после каждого прохода скрейпера
+type Policy struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// LoadFile читает политику из YAML-файла (JSON также поддерживается как подмножество YAML)
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	// Неизвестные поля считаются ошибкой: из-за опечатки правило применялось бы ко всем токенам или ничего не проверяло
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	if err := policy.Compile(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// Compile проверяет правила и подготавливает регулярные выражения.
+// Возвращает все найденные ошибки, а не только первую.
+func (p *Policy) Compile() error {
+	var errs []error
+	names := make(map[string]bool)
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name == "" {
+			errs = append(errs, fmt.Errorf("rule %d: name is required", i+1))
+		} else if names[rule.Name] {
+			errs = append(errs, fmt.Errorf("rule %q: duplicate name", rule.Name))
+		}
+		names[rule.Name] = true
+
+		if err := rule.compile(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %q: %w", rule.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Rule) compile() error {
+	for _, kind := range r.OwnerKinds {
+		if kind != metrics.KindProject && kind != metrics.KindGroup && kind != metrics.KindUser {
+			return fmt.Errorf("unknown owner kind %q", kind)
+		}
+	}
+
+	switch r.Type {
+	case RuleMaxLifetime:
+		if r.MaxLifetimeDays <= 0 {
+			return fmt.Errorf("max_lifetime_days must be positive")
+		}
+	case RuleForbiddenScopes:
+		if len(r.Scopes) == 0 {
+			return fmt.Errorf("scopes must not be empty")
+		}
+	case RuleRequireExpiry:
+	case RuleNamePattern:
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		r.pattern = pattern
+	case RuleMaxTokens:
+		if r.MaxTokens <= 0 {
+			return fmt.Errorf("max_tokens must be positive")
+		}
+	case RuleForbiddenAccessLevels:
+		if len(r.AccessLevels) == 0 {
+			return fmt.Errorf("access_levels must not be empty")
+		}
+	default:
+		return fmt.Errorf("unknown rule type %q", r.Type)
+	}
+	return nil
+}
+
+// Evaluate проверяет токены по всем правилам и возвращает нарушения
+func (p *Policy) Evaluate(tokens []metrics.Token) []metrics.PolicyViolation {
+	var violations []metrics.PolicyViolation
+	for i := range p.Rules {
+		violations = append(violations, p.Rules[i].evaluate(tokens)...)
+	}
+	return violations
+}
+
+func (r *Rule) evaluate(tokens []metrics.Token) []metrics.PolicyViolation {
+	var violations []metrics.PolicyViolation
+	violate := func(token metrics.Token, reason string) {
+		violations = append(violations, metrics.PolicyViolation{Rule: r.Name, Labels: token.Labels, Reason: reason})
+	}
+
+	if r.Type == RuleMaxTokens {
+		// Нарушением считаются все токены владельца, у которого их больше лимита
+		byOwner := make(map[string][]metrics.Token)
+		var owners []string
+		for _, token := range tokens {
+			if !r.applies(token) {
+				continue
+			}
+			owner := token.Labels.OwnerKind + "/" + strconv.Itoa(token.Labels.OwnerID)
+			if _, ok := byOwner[owner]; !ok {
+				owners = append(owners, owner)
+			}
+			byOwner[owner] = append(byOwner[owner], token)
+		}
+		for _, owner := range owners {
+			if len(byOwner[owner]) <= r.MaxTokens {
+				continue
+			}
+			for _, token := range byOwner[owner] {
+				violate(token, fmt.Sprintf("owner has %d tokens, limit is %d", len(byOwner[owner]), r.MaxTokens))
+			}
+		}
+		return violations
+	}
+
+	for _, token := range tokens {
+		if !r.applies(token) {
+			continue
+		}
+		if reason, ok := r.check(token); !ok {
+			violate(token, reason)
+		}
+	}
+	return violations
+}
+
+// check проверяет один токен и возвращает причину нарушения
+func (r *Rule) check(token metrics.Token) (string, bool) {
+	switch r.Type {
+	case RuleMaxLifetime:
+		maxLifetime := time.Duration(r.MaxLifetimeDays) * 24 * time.Hour
+		if token.ExpiresAt == nil {
+			return "token never expires", false
+		}
+		if token.CreatedAt != nil && token.ExpiresAt.Sub(*token.CreatedAt) > maxLifetime {
+			return fmt.Sprintf("lifetime exceeds %d days", r.MaxLifetimeDays), false
+		}
+	case RuleForbiddenScopes:
+		for _, scope := range token.Labels.Scopes {
+			if slices.Contains(r.Scopes, scope) {
+				return fmt.Sprintf("forbidden scope %q", scope), false
+			}
+		}
+	case RuleRequireExpiry:
+		if token.ExpiresAt == nil {
+			return "token never expires", false
+		}
+	case RuleNamePattern:
+		if !r.pattern.MatchString(token.Labels.TokenName) {
+			return fmt.Sprintf("name does not match %q", r.Pattern), false
+		}
+	case RuleForbiddenAccessLevels:
+		if slices.Contains(r.AccessLevels, token.Labels.AccessLevel) {
+			return fmt.Sprintf("forbidden access level %q", token.Labels.AccessLevel), false
+		}
+	}
+	return "", true
+}
+
+// applies сообщает, относится ли правило к владельцу токена
+func (r *Rule) applies(token metrics.Token) bool {
+	return len(r.OwnerKinds) == 0 || slices.Contains(r.OwnerKinds, token.Labels.OwnerKind)
+}
@@ -0,0 +1,267 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+func testToken(kind string, ownerID, id int, name string) metrics.Token {
+	return metrics.Token{Labels: metrics.TokenLabels{OwnerKind: kind, OwnerID: ownerID, TokenID: id, TokenName: name}}
+}
+
+// violatedTokens возвращает имена токенов, нарушивших правила, в порядке обнаружения
+func violatedTokens(violations []metrics.PolicyViolation) []string {
+	var names []string
+	for _, violation := range violations {
+		names = append(names, violation.Labels.TokenName)
+	}
+	return names
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	created := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	shortExpiry := created.Add(30 * 24 * time.Hour)
+	longExpiry := created.Add(400 * 24 * time.Hour)
+
+	withExpiry := func(token metrics.Token, expiresAt *time.Time) metrics.Token {
+		token.CreatedAt = &created
+		token.ExpiresAt = expiresAt
+		return token
+	}
+	withScopes := func(token metrics.Token, scopes ...string) metrics.Token {
+		token.Labels.Scopes = scopes
+		return token
+	}
+	withAccessLevel := func(token metrics.Token, level string) metrics.Token {
+		token.Labels.AccessLevel = level
+		return token
+	}
+
+	tests := []struct {
+		name   string
+		rule   Rule
+		tokens []metrics.Token
+		want   []string
+	}{
+		{
+			name: "max lifetime",
+			rule: Rule{Name: "r", Type: RuleMaxLifetime, MaxLifetimeDays: 90},
+			tokens: []metrics.Token{
+				withExpiry(testToken(metrics.KindProject, 1, 1, "short"), &shortExpiry),
+				withExpiry(testToken(metrics.KindProject, 1, 2, "long"), &longExpiry),
+				// Бессрочный токен всегда превышает максимальный срок жизни
+				withExpiry(testToken(metrics.KindProject, 1, 3, "forever"), nil),
+			},
+			want: []string{"long", "forever"},
+		},
+		{
+			name: "forbidden scopes for owner kind",
+			rule: Rule{Name: "r", Type: RuleForbiddenScopes, OwnerKinds: []string{metrics.KindGroup}, Scopes: []string{"api", "sudo"}},
+			tokens: []metrics.Token{
+				withScopes(testToken(metrics.KindGroup, 5, 1, "group-api"), "read_api", "api"),
+				withScopes(testToken(metrics.KindGroup, 5, 2, "group-read"), "read_api"),
+				// Правило не относится к токенам проектов
+				withScopes(testToken(metrics.KindProject, 1, 3, "project-api"), "api"),
+			},
+			want: []string{"group-api"},
+		},
+		{
+			name: "require expiry",
+			rule: Rule{Name: "r", Type: RuleRequireExpiry},
+			tokens: []metrics.Token{
+				withExpiry(testToken(metrics.KindUser, 7, 1, "expiring"), &shortExpiry),
+				withExpiry(testToken(metrics.KindUser, 7, 2, "forever"), nil),
+			},
+			want: []string{"forever"},
+		},
+		{
+			name: "name pattern",
+			rule: Rule{Name: "r", Type: RuleNamePattern, Pattern: `^(ci|deploy)-[a-z0-9-]+$`},
+			tokens: []metrics.Token{
+				testToken(metrics.KindProject, 1, 1, "ci-build"),
+				testToken(metrics.KindProject, 1, 2, "my token"),
+			},
+			want: []string{"my token"},
+		},
+		{
+			name: "max tokens per owner",
+			rule: Rule{Name: "r", Type: RuleMaxTokens, MaxTokens: 2},
+			tokens: []metrics.Token{
+				testToken(metrics.KindProject, 1, 1, "a"),
+				testToken(metrics.KindProject, 1, 2, "b"),
+				testToken(metrics.KindProject, 1, 3, "c"),
+				testToken(metrics.KindProject, 2, 4, "d"),
+				// Тот же ID, но другой тип владельца
+				testToken(metrics.KindGroup, 1, 5, "e"),
+			},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "forbidden access levels",
+			rule: Rule{Name: "r", Type: RuleForbiddenAccessLevels, AccessLevels: []string{"owner"}},
+			tokens: []metrics.Token{
+				withAccessLevel(testToken(metrics.KindProject, 1, 1, "owner-bot"), "owner"),
+				withAccessLevel(testToken(metrics.KindProject, 1, 2, "maintainer-bot"), "maintainer"),
+			},
+			want: []string{"owner-bot"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &Policy{Rules: []Rule{tt.rule}}
+			if err := policy.Compile(); err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			violations := policy.Evaluate(tt.tokens)
+			if got := violatedTokens(violations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("violated tokens = %v, want %v", got, tt.want)
+			}
+			for _, violation := range violations {
+				if violation.Rule != "r" || violation.Reason == "" {
+					t.Errorf("violation = %+v, want rule %q with reason", violation, "r")
+				}
+			}
+		})
+	}
+}
+
+func TestPolicy_Compile(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []Rule
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			rules: []Rule{
+				{Name: "lifetime", Type: RuleMaxLifetime, MaxLifetimeDays: 365},
+				{Name: "expiry", Type: RuleRequireExpiry, OwnerKinds: []string{metrics.KindUser}},
+			},
+		},
+		{
+			name:    "unknown type",
+			rules:   []Rule{{Name: "r", Type: "unknown"}},
+			wantErr: []string{`unknown rule type "unknown"`},
+		},
+		{
+			name:    "unknown owner kind",
+			rules:   []Rule{{Name: "r", Type: RuleRequireExpiry, OwnerKinds: []string{"instance"}}},
+			wantErr: []string{`unknown owner kind "instance"`},
+		},
+		{
+			name:    "invalid pattern",
+			rules:   []Rule{{Name: "r", Type: RuleNamePattern, Pattern: "("}},
+			wantErr: []string{"invalid pattern"},
+		},
+		{
+			// Сообщаются все ошибки, а не только первая
+			name: "multiple errors",
+			rules: []Rule{
+				{Type: RuleRequireExpiry},
+				{Name: "dup", Type: RuleMaxTokens},
+				{Name: "dup", Type: RuleForbiddenScopes},
+				{Name: "levels", Type: RuleForbiddenAccessLevels},
+			},
+			wantErr: []string{
+				"rule 1: name is required",
+				"max_tokens must be positive",
+				`rule "dup": duplicate name`,
+				"scopes must not be empty",
+				"access_levels must not be empty",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Policy{Rules: tt.rules}).Compile()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("Compile() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Compile() error = nil, want error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Compile() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantRules int
+		wantErr   bool
+	}{
+		{
+			name: "yaml",
+			content: `rules:
+  - name: no-owner
+    type: forbidden_access_levels
+    access_levels: [owner]
+  - name: naming
+    type: name_pattern
+    owner_kinds: [project, group]
+    pattern: "^ci-"
+`,
+			wantRules: 2,
+		},
+		{
+			name:      "json",
+			content:   `{"rules": [{"name": "expiry", "type": "require_expiry"}]}`,
+			wantRules: 1,
+		},
+		{
+			name:    "invalid syntax",
+			content: "rules: [",
+			wantErr: true,
+		},
+		{
+			name:    "invalid rule",
+			content: "rules:\n  - name: lifetime\n    type: max_lifetime\n",
+			wantErr: true,
+		},
+		{
+			// Опечатка в условии отбора не должна применять правило ко всем владельцам
+			name:    "unknown field",
+			content: "rules:\n  - name: no-api\n    type: forbidden_scopes\n    owner_kind: [group]\n    scopes: [api]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write policy file: %v", err)
+			}
+
+			policy, err := LoadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(policy.Rules) != tt.wantRules {
+				t.Errorf("got %d rules, want %d", len(policy.Rules), tt.wantRules)
+			}
+		})
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadFile() for missing file error = nil, want error")
+	}
+}
@@ -0,0 +1,175 @@
+package reload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Источники перезагрузки для логов
+const (
+	SourceSignal = "signal"
+	SourceFile   = "file"
+	SourceHTTP   = "http"
+)
+
+// Func загружает и применяет новую конфигурацию. При ошибке текущая конфигурация не меняется.
+type Func func() error
+
+// Observer получает результаты перезагрузок
+type Observer interface {
+	ObserveConfigReload(success bool)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) ObserveConfigReload(bool) {}
+
+// Option - функциональная опция для настройки Reloader
+type Option func(*Reloader)
+
+// WithObserver задает получателя результатов перезагрузок
+func WithObserver(observer Observer) Option {
+	return func(r *Reloader) {
+		r.observer = observer
+	}
+}
+
+// WithFileWatch включает перезагрузку при изменении содержимого файла path,
+// которое проверяется каждые interval (0 - выключено)
+func WithFileWatch(path string, interval time.Duration) Option {
+	return func(r *Reloader) {
+		r.watchPath = path
+		r.watchInterval = interval
+	}
+}
+
+// Reloader перезагружает конфигурацию по SIGHUP, изменению файла и HTTP-запросу.
+// Перезагрузки выполняются последовательно.
+type Reloader struct {
+	reload        Func
+	observer      Observer
+	watchPath     string
+	watchInterval time.Duration
+
+	mu       sync.Mutex
+	fileHash []byte
+}
+
+// New создает Reloader, вызывающий reload при каждой перезагрузке
+func New(reload Func, opts ...Option) *Reloader {
+	r := &Reloader{
+		reload:   reload,
+		observer: nopObserver{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.watchPath != "" && r.watchInterval > 0 {
+		// Исходное содержимое уже загружено при запуске
+		r.fileHash, _ = fileHash(r.watchPath)
+	}
+
+	return r
+}
+
+// Reload выполняет перезагрузку и сообщает ее результат
+func (r *Reloader) Reload(source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log.Printf("Reloading configuration (%s)...", source)
+	if err := r.reload(); err != nil {
+		log.Printf("Failed to reload configuration: %v", err)
+		r.observer.ObserveConfigReload(false)
+		return err
+	}
+
+	log.Println("Configuration reloaded successfully")
+	r.observer.ObserveConfigReload(true)
+	return nil
+}
+
+// Run обрабатывает SIGHUP и отслеживает изменения файла до отмены контекста
+func (r *Reloader) Run(ctx context.Context) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	// Без отслеживания файла канал остается nil и никогда не срабатывает
+	var watchTick <-chan time.Time
+	if r.watchPath != "" && r.watchInterval > 0 {
+		log.Printf("Watching configuration file %s every %v", r.watchPath, r.watchInterval)
+		ticker := time.NewTicker(r.watchInterval)
+		defer ticker.Stop()
+		watchTick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			r.Reload(SourceSignal)
+		case <-watchTick:
+			if r.fileChanged() {
+				r.Reload(SourceFile)
+			}
+		}
+	}
+}
+
+// fileChanged сообщает, изменилось ли содержимое файла с прошлой проверки.
+// Неудачная перезагрузка не повторяется, пока файл не изменится снова.
+func (r *Reloader) fileChanged() bool {
+	hash, err := fileHash(r.watchPath)
+	if err != nil {
+		// Файл может временно отсутствовать во время замены
+		log.Printf("Failed to read configuration file: %v", err)
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if bytes.Equal(hash, r.fileHash) {
+		return false
+	}
+	r.fileHash = hash
+	return true
+}
+
+// Handler обрабатывает POST /-/reload
+func (r *Reloader) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost && req.Method != http.MethodPut {
+			w.Header().Set("Allow", "POST, PUT")
+			http.Error(w, "Only POST or PUT requests allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.Reload(SourceHTTP); err != nil {
+			http.Error(w, fmt.Sprintf("failed to reload configuration: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+}
+
+func fileHash(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
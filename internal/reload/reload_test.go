@@ -0,0 +1,148 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// testObserver запоминает результаты перезагрузок
+type testObserver struct {
+	mu      sync.Mutex
+	results []bool
+}
+
+func (o *testObserver) ObserveConfigReload(success bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.results = append(o.results, success)
+}
+
+func (o *testObserver) count() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.results)
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloader_Reload(t *testing.T) {
+	observer := &testObserver{}
+	reloadErr := errors.New("invalid configuration")
+	calls := 0
+	reloader := New(func() error {
+		calls++
+		if calls == 2 {
+			return reloadErr
+		}
+		return nil
+	}, WithObserver(observer))
+
+	if err := reloader.Reload(SourceHTTP); err != nil {
+		t.Errorf("Reload() error = %v, want nil", err)
+	}
+	if err := reloader.Reload(SourceHTTP); !errors.Is(err, reloadErr) {
+		t.Errorf("Reload() error = %v, want %v", err, reloadErr)
+	}
+
+	if want := []bool{true, false}; len(observer.results) != 2 || observer.results[0] != want[0] || observer.results[1] != want[1] {
+		t.Errorf("observed results = %v, want %v", observer.results, want)
+	}
+}
+
+func TestReloader_Handler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		reloadErr  error
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "post", method: http.MethodPost, wantStatus: http.StatusOK, wantCalls: 1},
+		{name: "put", method: http.MethodPut, wantStatus: http.StatusOK, wantCalls: 1},
+		{name: "get not allowed", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed, wantCalls: 0},
+		{name: "reload failure", method: http.MethodPost, reloadErr: errors.New("boom"), wantStatus: http.StatusInternalServerError, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			reloader := New(func() error {
+				calls++
+				return tt.reloadErr
+			})
+
+			recorder := httptest.NewRecorder()
+			reloader.Handler().ServeHTTP(recorder, httptest.NewRequest(tt.method, "/-/reload", nil))
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("reload calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestReloader_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("scraper:\n  interval: 10s\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	var calls atomic.Int32
+	observer := &testObserver{}
+	reloader := New(func() error {
+		calls.Add(1)
+		return nil
+	}, WithObserver(observer), WithFileWatch(path, 10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		reloader.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Неизмененный файл не вызывает перезагрузку
+	time.Sleep(50 * time.Millisecond)
+	if got := calls.Load(); got != 0 {
+		t.Fatalf("reload calls without changes = %d, want 0", got)
+	}
+
+	if err := os.WriteFile(path, []byte("scraper:\n  interval: 1m\n"), 0o600); err != nil {
+		t.Fatalf("Failed to update config file: %v", err)
+	}
+	waitFor(t, func() bool { return calls.Load() == 1 })
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Failed to send SIGHUP: %v", err)
+	}
+	waitFor(t, func() bool { return calls.Load() == 2 })
+
+	if got := observer.count(); got != 2 {
+		t.Errorf("observed reloads = %d, want 2", got)
+	}
+}
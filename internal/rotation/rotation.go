@@ -0,0 +1,178 @@
+package rotation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// gitlabDefaultLifetimeDays - срок действия нового токена, если expires_in_days не задан
+const gitlabDefaultLifetimeDays = 7
+
+// Rule - правило ротации токенов проектов и групп
+type Rule struct {
+	// Name - имя правила, значение метки rule
+	Name string `yaml:"name" json:"name"`
+	// OwnerKinds - типы владельцев: project и/или group (пусто - оба)
+	OwnerKinds []string `yaml:"owner_kinds" json:"owner_kinds"`
+	// OwnerIDs - ID проектов и групп (пусто - все отслеживаемые)
+	OwnerIDs []int `yaml:"owner_ids" json:"owner_ids"`
+	// Pattern - регулярное выражение для имени токена (пусто - любое имя)
+	Pattern string `yaml:"pattern" json:"pattern"`
+	// DaysBeforeExpiry - токен ротируется, когда до истечения остается не больше стольких дней
+	DaysBeforeExpiry int `yaml:"days_before_expiry" json:"days_before_expiry"`
+	// ExpiresInDays - срок действия нового токена (0 - срок по умолчанию GitLab, одна неделя)
+	ExpiresInDays int `yaml:"expires_in_days" json:"expires_in_days"`
+	// Sink - имя приемника, которому передается новый секрет
+	Sink string `yaml:"sink" json:"sink"`
+
+	pattern *regexp.Regexp
+	sink    sink
+}
+
+// Config - правила ротации и приемники новых секретов
+type Config struct {
+	Rules []Rule       `yaml:"rules" json:"rules"`
+	Sinks []SinkConfig `yaml:"sinks" json:"sinks"`
+}
+
+// LoadFile читает правила ротации из YAML-файла (JSON также поддерживается как подмножество YAML)
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rotation file: %w", err)
+	}
+
+	// Неизвестные поля считаются ошибкой: опечатка в условии отбора расширила бы правило на все токены
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse rotation file: %w", err)
+	}
+	if err := config.Compile(); err != nil {
+		return nil, fmt.Errorf("invalid rotation file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Compile проверяет правила и приемники, подготавливает шаблоны и регулярные выражения.
+// Возвращает все найденные ошибки, а не только первую.
+func (c *Config) Compile() error {
+	var errs []error
+
+	sinks := make(map[string]sink)
+	for i, config := range c.Sinks {
+		switch {
+		case config.Name == "":
+			errs = append(errs, fmt.Errorf("sink %d: name is required", i+1))
+			continue
+		case sinks[config.Name] != nil:
+			errs = append(errs, fmt.Errorf("sink %q: duplicate name", config.Name))
+			continue
+		}
+
+		sink, err := newSink(config)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sink %q: %w", config.Name, err))
+			continue
+		}
+		sinks[config.Name] = sink
+	}
+
+	names := make(map[string]bool)
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Name == "" {
+			errs = append(errs, fmt.Errorf("rule %d: name is required", i+1))
+		} else if names[rule.Name] {
+			errs = append(errs, fmt.Errorf("rule %q: duplicate name", rule.Name))
+		}
+		names[rule.Name] = true
+
+		if err := rule.compile(sinks); err != nil {
+			errs = append(errs, fmt.Errorf("rule %q: %w", rule.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Rule) compile(sinks map[string]sink) error {
+	for _, kind := range r.OwnerKinds {
+		if kind != metrics.KindProject && kind != metrics.KindGroup {
+			return fmt.Errorf("owner kind %q is not supported, only project and group tokens can be rotated", kind)
+		}
+	}
+
+	if r.DaysBeforeExpiry <= 0 {
+		return fmt.Errorf("days_before_expiry must be positive")
+	}
+	if r.ExpiresInDays < 0 {
+		return fmt.Errorf("expires_in_days must not be negative")
+	}
+	// Иначе новый токен сразу снова подпадал бы под правило и ротировался на каждом проходе
+	if lifetime := r.lifetimeDays(); lifetime <= r.DaysBeforeExpiry {
+		return fmt.Errorf("new token lifetime of %d days must be greater than days_before_expiry (%d)", lifetime, r.DaysBeforeExpiry)
+	}
+
+	if r.Pattern != "" {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		r.pattern = pattern
+	}
+
+	if r.Sink == "" {
+		return fmt.Errorf("sink is required")
+	}
+	r.sink = sinks[r.Sink]
+	if r.sink == nil {
+		return fmt.Errorf("unknown sink %q", r.Sink)
+	}
+	return nil
+}
+
+// lifetimeDays возвращает срок действия нового токена в днях
+func (r *Rule) lifetimeDays() int {
+	if r.ExpiresInDays > 0 {
+		return r.ExpiresInDays
+	}
+	return gitlabDefaultLifetimeDays
+}
+
+// expiresAt возвращает дату истечения нового токена (nil - срок по умолчанию GitLab)
+func (r *Rule) expiresAt(now time.Time) *time.Time {
+	if r.ExpiresInDays == 0 {
+		return nil
+	}
+	expiresAt := now.AddDate(0, 0, r.ExpiresInDays)
+	return &expiresAt
+}
+
+// matches сообщает, подпадает ли токен под правило к моменту now
+func (r *Rule) matches(token metrics.Token, now time.Time) bool {
+	if token.ExpiresAt == nil || !token.ExpiresAt.After(now) {
+		return false
+	}
+	if token.ExpiresAt.Sub(now) > time.Duration(r.DaysBeforeExpiry)*24*time.Hour {
+		return false
+	}
+	if len(r.OwnerKinds) > 0 && !slices.Contains(r.OwnerKinds, token.Labels.OwnerKind) {
+		return false
+	}
+	if len(r.OwnerIDs) > 0 && !slices.Contains(r.OwnerIDs, token.Labels.OwnerID) {
+		return false
+	}
+	return r.pattern == nil || r.pattern.MatchString(token.Labels.TokenName)
+}
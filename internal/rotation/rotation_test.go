@@ -0,0 +1,240 @@
+package rotation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+func testToken(kind string, ownerID, id int, name string, expiresAt *time.Time) metrics.Token {
+	return metrics.Token{
+		Labels:    metrics.TokenLabels{OwnerKind: kind, OwnerID: ownerID, OwnerName: "owner", TokenID: id, TokenName: name},
+		ExpiresAt: expiresAt,
+	}
+}
+
+func TestRule_Matches(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	in3Days := now.AddDate(0, 0, 3)
+	in30Days := now.AddDate(0, 0, 30)
+	expired := now.Add(-time.Hour)
+
+	tests := []struct {
+		name  string
+		rule  Rule
+		token metrics.Token
+		want  bool
+	}{
+		{name: "expiring", rule: Rule{DaysBeforeExpiry: 7}, token: testToken(metrics.KindProject, 1, 1, "ci", &in3Days), want: true},
+		{name: "not yet", rule: Rule{DaysBeforeExpiry: 7}, token: testToken(metrics.KindProject, 1, 1, "ci", &in30Days)},
+		// Истекший токен GitLab ротировать не позволяет
+		{name: "expired", rule: Rule{DaysBeforeExpiry: 7}, token: testToken(metrics.KindProject, 1, 1, "ci", &expired)},
+		{name: "never expires", rule: Rule{DaysBeforeExpiry: 7}, token: testToken(metrics.KindProject, 1, 1, "ci", nil)},
+		{name: "other owner kind", rule: Rule{DaysBeforeExpiry: 7, OwnerKinds: []string{metrics.KindGroup}}, token: testToken(metrics.KindProject, 1, 1, "ci", &in3Days)},
+		{name: "owner id", rule: Rule{DaysBeforeExpiry: 7, OwnerIDs: []int{1, 2}}, token: testToken(metrics.KindProject, 2, 1, "ci", &in3Days), want: true},
+		{name: "other owner id", rule: Rule{DaysBeforeExpiry: 7, OwnerIDs: []int{1}}, token: testToken(metrics.KindProject, 3, 1, "ci", &in3Days)},
+		{name: "pattern", rule: Rule{DaysBeforeExpiry: 7, Pattern: "^ci-"}, token: testToken(metrics.KindGroup, 1, 1, "ci-deploy", &in3Days), want: true},
+		{name: "pattern mismatch", rule: Rule{DaysBeforeExpiry: 7, Pattern: "^ci-"}, token: testToken(metrics.KindGroup, 1, 1, "manual", &in3Days)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			rule.Name, rule.ExpiresInDays, rule.Sink = "r", 30, "file"
+			if err := rule.compile(map[string]sink{"file": &fileSink{}}); err != nil {
+				t.Fatalf("compile() error = %v", err)
+			}
+			if got := rule.matches(tt.token, now); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Compile(t *testing.T) {
+	fileSink := SinkConfig{Name: "file", Type: SinkFile, Path: "/tmp/{{.TokenName}}"}
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			config: Config{
+				Rules: []Rule{
+					{Name: "ci", OwnerKinds: []string{metrics.KindProject}, DaysBeforeExpiry: 7, ExpiresInDays: 90, Sink: "file"},
+					// Срок по умолчанию GitLab (неделя) больше окна ротации
+					{Name: "short", DaysBeforeExpiry: 3, Sink: "hook"},
+				},
+				Sinks: []SinkConfig{
+					fileSink,
+					{Name: "hook", Type: SinkWebhook, URL: "http://localhost/hook"},
+					{Name: "k8s", Type: SinkKubernetesSecret, Path: "/tmp/secret.yaml", SecretName: "{{.TokenName}}"},
+				},
+			},
+		},
+		{
+			name: "user tokens",
+			config: Config{
+				Rules: []Rule{{Name: "r", OwnerKinds: []string{metrics.KindUser}, DaysBeforeExpiry: 7, ExpiresInDays: 30, Sink: "file"}},
+				Sinks: []SinkConfig{fileSink},
+			},
+			wantErr: []string{`owner kind "user" is not supported`},
+		},
+		{
+			// Иначе новый токен ротировался бы на каждом проходе
+			name: "lifetime within rotation window",
+			config: Config{
+				Rules: []Rule{
+					{Name: "default", DaysBeforeExpiry: 7, Sink: "file"},
+					{Name: "explicit", DaysBeforeExpiry: 30, ExpiresInDays: 30, Sink: "file"},
+				},
+				Sinks: []SinkConfig{fileSink},
+			},
+			wantErr: []string{
+				"new token lifetime of 7 days must be greater than days_before_expiry (7)",
+				"new token lifetime of 30 days must be greater than days_before_expiry (30)",
+			},
+		},
+		{
+			// Сообщаются все ошибки, а не только первая
+			name: "multiple errors",
+			config: Config{
+				Rules: []Rule{
+					{DaysBeforeExpiry: 1, Sink: "file"},
+					{Name: "no-days", Sink: "file"},
+					{Name: "pattern", DaysBeforeExpiry: 1, Pattern: "(", Sink: "file"},
+					{Name: "no-sink", DaysBeforeExpiry: 1},
+					{Name: "unknown-sink", DaysBeforeExpiry: 1, Sink: "missing"},
+				},
+				Sinks: []SinkConfig{
+					fileSink,
+					{Name: "file", Type: SinkFile, Path: "/tmp/other"},
+					{Type: SinkFile},
+					{Name: "no-path", Type: SinkFile},
+					{Name: "bad-template", Type: SinkKubernetesSecret, Path: "/tmp/{{.TokenName", SecretName: "s"},
+					{Name: "no-url", Type: SinkWebhook},
+					{Name: "email", Type: "email"},
+				},
+			},
+			wantErr: []string{
+				`sink "file": duplicate name`,
+				"sink 3: name is required",
+				`sink "no-path": path is required`,
+				`sink "bad-template": invalid path template`,
+				`sink "no-url": url is required`,
+				`unknown sink type "email"`,
+				"rule 1: name is required",
+				`rule "no-days": days_before_expiry must be positive`,
+				`rule "pattern": invalid pattern`,
+				`rule "no-sink": sink is required`,
+				`rule "unknown-sink": unknown sink "missing"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Compile()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("Compile() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Compile() error = nil, want error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Compile() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantRules int
+		wantErr   bool
+	}{
+		{
+			name: "yaml",
+			content: `rules:
+  - name: ci
+    owner_kinds: [project, group]
+    pattern: "^ci-"
+    days_before_expiry: 7
+    expires_in_days: 90
+    sink: hook
+sinks:
+  - name: hook
+    type: webhook
+    url: https://vault-sync.example.com/rotated
+    timeout: 5s
+    headers:
+      Authorization: "Bearer ${WEBHOOK_TOKEN}"
+`,
+			wantRules: 1,
+		},
+		{
+			name:      "json",
+			content:   `{"rules": [{"name": "ci", "days_before_expiry": 3, "sink": "file"}], "sinks": [{"name": "file", "type": "file", "path": "/tmp/token"}]}`,
+			wantRules: 1,
+		},
+		{
+			name:    "invalid syntax",
+			content: "rules: [",
+			wantErr: true,
+		},
+		{
+			name:    "invalid rule",
+			content: "rules:\n  - name: ci\n    days_before_expiry: 3\n",
+			wantErr: true,
+		},
+		{
+			// Опечатка в условии отбора не должна расширять правило на все токены
+			name: "unknown field",
+			content: `rules:
+  - name: ci
+    owner_id: [123]
+    days_before_expiry: 3
+    sink: file
+sinks:
+  - name: file
+    type: file
+    path: /tmp/token
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rotation.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write rotation file: %v", err)
+			}
+
+			config, err := LoadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(config.Rules) != tt.wantRules {
+				t.Errorf("got %d rules, want %d", len(config.Rules), tt.wantRules)
+			}
+		})
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadFile() for missing file error = nil, want error")
+	}
+}
@@ -0,0 +1,224 @@
+package rotation
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/gitlab"
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// Результаты ротации для метрик
+const (
+	ResultSuccess = "success"
+	// ResultFailure - GitLab не выполнил ротацию, старый токен действует
+	ResultFailure = "failure"
+	// ResultDeliveryFailure - токен ротирован, но приемник не получил новый секрет
+	ResultDeliveryFailure = "delivery_failure"
+	ResultDryRun          = "dry_run"
+	// ResultSkipped - ротация отложена до следующего прохода из-за лимита
+	ResultSkipped = "skipped"
+)
+
+// deliveryAttempts - количество попыток доставки секрета: после ротации он существует только в памяти
+const deliveryAttempts = 3
+
+// deliveryRetryWait - пауза между попытками доставки (переменная для тестов)
+var deliveryRetryWait = 2 * time.Second
+
+// Observer получает результаты ротации
+type Observer interface {
+	ObserveRotation(rule, ownerKind, result string)
+}
+
+// Option - функциональная опция для настройки Rotator
+type Option func(*Rotator)
+
+// WithDryRun включает пробный режим: подходящие токены только выводятся в лог
+func WithDryRun(enabled bool) Option {
+	return func(r *Rotator) {
+		r.dryRun = enabled
+	}
+}
+
+// WithMaxPerRun ограничивает количество ротаций за один проход
+func WithMaxPerRun(limit int) Option {
+	return func(r *Rotator) {
+		r.maxPerRun = limit
+	}
+}
+
+// WithRetryInterval задает паузу перед повтором неудачной или пробной ротации токена
+func WithRetryInterval(interval time.Duration) Option {
+	return func(r *Rotator) {
+		r.retryInterval = interval
+	}
+}
+
+// WithInstance задает имя инстанса GitLab, передаваемое приемникам
+func WithInstance(name string) Option {
+	return func(r *Rotator) {
+		r.instance = name
+	}
+}
+
+// Rotator ротирует истекающие токены проектов и групп по правилам Config
+type Rotator struct {
+	config        *Config
+	instance      string
+	dryRun        bool
+	maxPerRun     int
+	retryInterval time.Duration
+	now           func() time.Time
+
+	// attempts - время последней неудачной или пробной ротации по ID токена.
+	// Run вызывается только из цикла скрейпера, поэтому блокировка не нужна.
+	attempts map[int]time.Time
+}
+
+func NewRotator(config *Config, opts ...Option) *Rotator {
+	r := &Rotator{
+		config:        config,
+		maxPerRun:     1,
+		retryInterval: time.Hour,
+		now:           time.Now,
+		attempts:      make(map[int]time.Time),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// candidate - токен, подпадающий под правило ротации
+type candidate struct {
+	token metrics.Token
+	rule  *Rule
+}
+
+// Run ротирует токены, подпадающие под правила, начиная с ближайших к истечению.
+// Первое подходящее правило определяет срок действия нового токена и приемник секрета.
+func (r *Rotator) Run(ctx context.Context, client gitlab.GitLabClientInterface, tokens []metrics.Token, observer Observer) {
+	now := r.now()
+	candidates := r.candidates(tokens, now)
+	if len(candidates) == 0 {
+		return
+	}
+
+	for i, c := range candidates {
+		if ctx.Err() != nil {
+			return
+		}
+		if i >= r.maxPerRun {
+			log.Printf("Token rotation limit of %d per scrape reached, %d tokens are postponed to the next scrape", r.maxPerRun, len(candidates)-i)
+			for _, skipped := range candidates[i:] {
+				observer.ObserveRotation(skipped.rule.Name, skipped.token.Labels.OwnerKind, ResultSkipped)
+			}
+			return
+		}
+
+		labels := c.token.Labels
+		if r.dryRun {
+			log.Printf("Dry run: would rotate %s token %q (ID %d) of %q expiring at %s by rule %q", labels.OwnerKind, labels.TokenName, labels.TokenID, labels.OwnerName, c.token.ExpiresAt.Format(time.DateOnly), c.rule.Name)
+			r.attempts[labels.TokenID] = now
+			observer.ObserveRotation(c.rule.Name, labels.OwnerKind, ResultDryRun)
+			continue
+		}
+
+		observer.ObserveRotation(c.rule.Name, labels.OwnerKind, r.rotate(ctx, client, c, now))
+	}
+}
+
+// candidates возвращает токены, подпадающие под правила, отсортированные по времени истечения
+func (r *Rotator) candidates(tokens []metrics.Token, now time.Time) []candidate {
+	var candidates []candidate
+	for _, token := range tokens {
+		if token.Labels.OwnerKind != metrics.KindProject && token.Labels.OwnerKind != metrics.KindGroup {
+			continue
+		}
+		if attempt, ok := r.attempts[token.Labels.TokenID]; ok && now.Sub(attempt) < r.retryInterval {
+			continue
+		}
+		for i := range r.config.Rules {
+			if rule := &r.config.Rules[i]; rule.matches(token, now) {
+				candidates = append(candidates, candidate{token: token, rule: rule})
+				break
+			}
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return a.token.ExpiresAt.Compare(*b.token.ExpiresAt)
+	})
+	return candidates
+}
+
+// rotate ротирует один токен и доставляет новый секрет приемнику правила
+func (r *Rotator) rotate(ctx context.Context, client gitlab.GitLabClientInterface, c candidate, now time.Time) string {
+	labels := c.token.Labels
+	rotation := Rotation{
+		Rule:       c.rule.Name,
+		Instance:   r.instance,
+		OwnerKind:  labels.OwnerKind,
+		OwnerID:    labels.OwnerID,
+		OwnerName:  labels.OwnerName,
+		TokenName:  labels.TokenName,
+		OldTokenID: labels.TokenID,
+		TokenID:    labels.TokenID,
+		ExpiresAt:  c.token.ExpiresAt,
+	}
+
+	// Старый токен отзывается при ротации, поэтому непригодный приемник проверяется заранее
+	if err := c.rule.sink.check(rotation); err != nil {
+		log.Printf("Skipping rotation of %s token %q (ID %d) of %q by rule %q: sink %q cannot accept it: %v", labels.OwnerKind, labels.TokenName, labels.TokenID, labels.OwnerName, c.rule.Name, c.rule.Sink, err)
+		r.attempts[labels.TokenID] = now
+		return ResultFailure
+	}
+
+	expiresAt := c.rule.expiresAt(now)
+	var err error
+	switch labels.OwnerKind {
+	case metrics.KindProject:
+		token, rotateErr := client.RotateProjectAccessToken(ctx, labels.OwnerID, labels.TokenID, expiresAt)
+		if err = rotateErr; err == nil {
+			rotation.TokenID, rotation.Token, rotation.ExpiresAt = token.ID, token.Token, (*time.Time)(token.ExpiresAt)
+		}
+	case metrics.KindGroup:
+		token, rotateErr := client.RotateGroupAccessToken(ctx, labels.OwnerID, labels.TokenID, expiresAt)
+		if err = rotateErr; err == nil {
+			rotation.TokenID, rotation.Token, rotation.ExpiresAt = token.ID, token.Token, (*time.Time)(token.ExpiresAt)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to rotate %s token %q (ID %d) of %q by rule %q: %v", labels.OwnerKind, labels.TokenName, labels.TokenID, labels.OwnerName, c.rule.Name, err)
+		r.attempts[labels.TokenID] = now
+		return ResultFailure
+	}
+
+	// Старый токен уже отозван, поэтому доставка не прерывается вместе с проходом
+	if err := r.deliver(context.WithoutCancel(ctx), c.rule, rotation); err != nil {
+		log.Printf("Rotated %s token %q of %q (ID %d -> %d), but failed to deliver the new secret to sink %q: %v. The new token must be rotated again manually", labels.OwnerKind, labels.TokenName, labels.OwnerName, labels.TokenID, rotation.TokenID, c.rule.Sink, err)
+		return ResultDeliveryFailure
+	}
+
+	log.Printf("Rotated %s token %q of %q (ID %d -> %d) by rule %q, new secret delivered to sink %q", labels.OwnerKind, labels.TokenName, labels.OwnerName, labels.TokenID, rotation.TokenID, c.rule.Name, c.rule.Sink)
+	return ResultSuccess
+}
+
+// deliver передает секрет приемнику, повторяя попытку при ошибке
+func (r *Rotator) deliver(ctx context.Context, rule *Rule, rotation Rotation) error {
+	var err error
+	for attempt := 1; attempt <= deliveryAttempts; attempt++ {
+		if err = rule.sink.deliver(ctx, rotation); err == nil {
+			return nil
+		}
+		if attempt == deliveryAttempts {
+			break
+		}
+		log.Printf("Failed to deliver rotated token %d to sink %q (attempt %d of %d): %v", rotation.TokenID, rule.Sink, attempt, deliveryAttempts, err)
+		time.Sleep(deliveryRetryWait)
+	}
+	return err
+}
@@ -0,0 +1,228 @@
+package rotation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/gitlab"
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// fakeClient ротирует токены, остальные методы клиента в тестах не вызываются
+type fakeClient struct {
+	gitlab.GitLabClientInterface
+
+	err     error
+	rotated []int
+	expires []*time.Time
+}
+
+func (c *fakeClient) RotateProjectAccessToken(_ context.Context, _, tokenID int, expiresAt *time.Time) (*gitlabapi.ProjectAccessToken, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.rotated = append(c.rotated, tokenID)
+	c.expires = append(c.expires, expiresAt)
+	token := &gitlabapi.ProjectAccessToken{}
+	token.ID, token.Token = tokenID+100, "glpat-new"
+	return token, nil
+}
+
+func (c *fakeClient) RotateGroupAccessToken(_ context.Context, _, tokenID int, expiresAt *time.Time) (*gitlabapi.GroupAccessToken, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.rotated = append(c.rotated, tokenID)
+	c.expires = append(c.expires, expiresAt)
+	token := &gitlabapi.GroupAccessToken{}
+	token.ID, token.Token = tokenID+100, "glpat-new"
+	return token, nil
+}
+
+// recordingSink запоминает доставленные ротации
+type recordingSink struct {
+	mu        sync.Mutex
+	checkErr  error
+	err       error
+	delivered []Rotation
+}
+
+func (s *recordingSink) check(Rotation) error {
+	return s.checkErr
+}
+
+func (s *recordingSink) deliver(_ context.Context, rotation Rotation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.delivered = append(s.delivered, rotation)
+	return nil
+}
+
+// recordingObserver считает результаты ротации
+type recordingObserver map[string]int
+
+func (o recordingObserver) ObserveRotation(rule, ownerKind, result string) {
+	o[rule+"/"+ownerKind+"/"+result]++
+}
+
+func newTestRotator(t *testing.T, testSink *recordingSink, opts ...Option) *Rotator {
+	t.Helper()
+	config := &Config{Rules: []Rule{
+		{Name: "ci", Pattern: "^ci-", DaysBeforeExpiry: 7, ExpiresInDays: 30, Sink: "test"},
+		{Name: "groups", OwnerKinds: []string{metrics.KindGroup}, DaysBeforeExpiry: 3, Sink: "test"},
+	}}
+	// Тестовый приемник не описывается в Sinks, поэтому правила компилируются с ним напрямую
+	for i := range config.Rules {
+		if err := config.Rules[i].compile(map[string]sink{"test": testSink}); err != nil {
+			t.Fatalf("compile() error = %v", err)
+		}
+	}
+	return NewRotator(config, opts...)
+}
+
+func TestRotator_Run(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	in := func(days int) *time.Time {
+		expiresAt := now.AddDate(0, 0, days)
+		return &expiresAt
+	}
+	tokens := []metrics.Token{
+		testToken(metrics.KindProject, 1, 1, "ci-deploy", in(5)),
+		testToken(metrics.KindProject, 1, 2, "ci-later", in(30)),
+		testToken(metrics.KindProject, 1, 3, "manual", in(1)),
+		testToken(metrics.KindGroup, 5, 4, "group-bot", in(2)),
+		// Личные токены не ротируются, даже если подходят под правило
+		testToken(metrics.KindUser, 9, 5, "ci-user", in(1)),
+	}
+
+	sink := &recordingSink{}
+	rotator := newTestRotator(t, sink, WithMaxPerRun(10), WithInstance("gitlab-com"))
+	rotator.now = func() time.Time { return now }
+	client := &fakeClient{}
+	observer := recordingObserver{}
+
+	rotator.Run(context.Background(), client, tokens, observer)
+
+	// Сначала ротируются токены, ближайшие к истечению
+	if want := []int{4, 1}; !reflect.DeepEqual(client.rotated, want) {
+		t.Errorf("rotated tokens = %v, want %v", client.rotated, want)
+	}
+	// Срок нового токена задается правилом, без expires_in_days используется срок GitLab
+	if client.expires[0] != nil || client.expires[1] == nil || !client.expires[1].Equal(now.AddDate(0, 0, 30)) {
+		t.Errorf("unexpected new token expiry: %v", client.expires)
+	}
+	if len(sink.delivered) != 2 {
+		t.Fatalf("delivered %d secrets, want 2", len(sink.delivered))
+	}
+	if got := sink.delivered[1]; got.TokenID != 101 || got.OldTokenID != 1 || got.Token != "glpat-new" || got.Instance != "gitlab-com" || got.Rule != "ci" {
+		t.Errorf("unexpected rotation: %+v", got)
+	}
+	want := recordingObserver{"groups/group/success": 1, "ci/project/success": 1}
+	if !reflect.DeepEqual(observer, want) {
+		t.Errorf("observed %v, want %v", observer, want)
+	}
+}
+
+func TestRotator_Safeguards(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := now.AddDate(0, 0, 1)
+	tokens := []metrics.Token{
+		testToken(metrics.KindProject, 1, 1, "ci-a", &expiresAt),
+		testToken(metrics.KindProject, 1, 2, "ci-b", &expiresAt),
+		testToken(metrics.KindProject, 1, 3, "ci-c", &expiresAt),
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		sink := &recordingSink{}
+		rotator := newTestRotator(t, sink, WithDryRun(true), WithMaxPerRun(2))
+		rotator.now = func() time.Time { return now }
+		client := &fakeClient{}
+		observer := recordingObserver{}
+
+		rotator.Run(context.Background(), client, tokens, observer)
+		if len(client.rotated) != 0 || len(sink.delivered) != 0 {
+			t.Errorf("dry run rotated %v and delivered %d secrets", client.rotated, len(sink.delivered))
+		}
+		if want := (recordingObserver{"ci/project/dry_run": 2, "ci/project/skipped": 1}); !reflect.DeepEqual(observer, want) {
+			t.Errorf("observed %v, want %v", observer, want)
+		}
+
+		// Уже показанные токены не повторяются до истечения интервала повтора
+		observer = recordingObserver{}
+		rotator.Run(context.Background(), client, tokens, observer)
+		if want := (recordingObserver{"ci/project/dry_run": 1}); !reflect.DeepEqual(observer, want) {
+			t.Errorf("second run observed %v, want %v", observer, want)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		rotator := newTestRotator(t, &recordingSink{}, WithMaxPerRun(1))
+		rotator.now = func() time.Time { return now }
+		client := &fakeClient{}
+		observer := recordingObserver{}
+
+		rotator.Run(context.Background(), client, tokens, observer)
+		if len(client.rotated) != 1 {
+			t.Errorf("rotated %v, want one token", client.rotated)
+		}
+		if want := (recordingObserver{"ci/project/success": 1, "ci/project/skipped": 2}); !reflect.DeepEqual(observer, want) {
+			t.Errorf("observed %v, want %v", observer, want)
+		}
+	})
+
+	t.Run("failure is retried after interval", func(t *testing.T) {
+		rotator := newTestRotator(t, &recordingSink{}, WithMaxPerRun(1), WithRetryInterval(time.Hour))
+		current := now
+		rotator.now = func() time.Time { return current }
+		client := &fakeClient{err: errors.New("403 Forbidden")}
+		observer := recordingObserver{}
+
+		rotator.Run(context.Background(), client, tokens[:1], observer)
+		rotator.Run(context.Background(), client, tokens[:1], observer)
+		current = current.Add(time.Hour)
+		rotator.Run(context.Background(), client, tokens[:1], observer)
+
+		if want := (recordingObserver{"ci/project/failure": 2}); !reflect.DeepEqual(observer, want) {
+			t.Errorf("observed %v, want %v", observer, want)
+		}
+	})
+
+	t.Run("sink rejects token before rotation", func(t *testing.T) {
+		rotator := newTestRotator(t, &recordingSink{checkErr: errors.New("invalid secret name")}, WithMaxPerRun(1))
+		rotator.now = func() time.Time { return now }
+		client := &fakeClient{}
+		observer := recordingObserver{}
+
+		rotator.Run(context.Background(), client, tokens[:1], observer)
+		if len(client.rotated) != 0 {
+			t.Errorf("rotated %v, want no rotation", client.rotated)
+		}
+		if want := (recordingObserver{"ci/project/failure": 1}); !reflect.DeepEqual(observer, want) {
+			t.Errorf("observed %v, want %v", observer, want)
+		}
+	})
+
+	t.Run("delivery failure", func(t *testing.T) {
+		deliveryRetryWait = time.Millisecond
+		t.Cleanup(func() { deliveryRetryWait = 2 * time.Second })
+
+		rotator := newTestRotator(t, &recordingSink{err: errors.New("disk full")}, WithMaxPerRun(1))
+		rotator.now = func() time.Time { return now }
+		client := &fakeClient{}
+		observer := recordingObserver{}
+
+		rotator.Run(context.Background(), client, tokens[:1], observer)
+		if want := (recordingObserver{"ci/project/delivery_failure": 1}); !reflect.DeepEqual(observer, want) {
+			t.Errorf("observed %v, want %v", observer, want)
+		}
+	})
+}
@@ -0,0 +1,339 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Типы приемников нового секрета
+const (
+	// SinkFile - файл, содержащий только секрет
+	SinkFile = "file"
+	// SinkKubernetesSecret - манифест Kubernetes Secret, записанный в файл
+	SinkKubernetesSecret = "kubernetes_secret"
+	// SinkWebhook - POST-запрос с JSON-описанием ротации
+	SinkWebhook = "webhook"
+)
+
+// defaultWebhookTimeout - таймаут запроса к webhook, если timeout не задан
+const defaultWebhookTimeout = 10 * time.Second
+
+// dnsSubdomain - имя объекта Kubernetes (RFC 1123 subdomain), dnsLabel - имя пространства имен
+var (
+	dnsSubdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	dnsLabel     = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// unsafePathChars заменяются в значениях полей, подставляемых в шаблоны: имена токенов и
+// владельцев задают пользователи GitLab, и они не должны выводить путь за пределы каталога
+var unsafePathChars = strings.NewReplacer("/", "_", "\\", "_", "..", "__")
+
+// SinkConfig - приемник нового секрета после ротации. Path и SecretName - шаблоны
+// text/template с полями Rotation, кроме Token.
+type SinkConfig struct {
+	Name string `yaml:"name" json:"name"`
+	Type string `yaml:"type" json:"type"`
+	// Path - путь к файлу секрета или манифеста (file, kubernetes_secret)
+	Path string `yaml:"path" json:"path"`
+	// SecretName и Namespace - имя и пространство имен Secret (kubernetes_secret)
+	SecretName string `yaml:"secret_name" json:"secret_name"`
+	Namespace  string `yaml:"namespace" json:"namespace"`
+	// Key - ключ секрета в Secret (kubernetes_secret), по умолчанию token
+	Key string `yaml:"key" json:"key"`
+	// URL и Headers - адрес и заголовки запроса (webhook). В значениях заголовков
+	// подставляются переменные окружения: ${WEBHOOK_TOKEN}.
+	URL     string            `yaml:"url" json:"url"`
+	Headers map[string]string `yaml:"headers" json:"headers"`
+	// Timeout - таймаут запроса (webhook), по умолчанию 10s
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+}
+
+// Rotation - результат ротации одного токена, который получает приемник
+type Rotation struct {
+	Rule       string     `json:"rule"`
+	Instance   string     `json:"instance,omitempty"`
+	OwnerKind  string     `json:"owner_kind"`
+	OwnerID    int        `json:"owner_id"`
+	OwnerName  string     `json:"owner_name"`
+	TokenName  string     `json:"token_name"`
+	OldTokenID int        `json:"old_token_id"`
+	TokenID    int        `json:"token_id"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	// Token - новый секрет. Не выводится в лог и недоступен в шаблонах.
+	Token string `json:"token"`
+}
+
+// sink доставляет новый секрет
+type sink interface {
+	// check проверяет до ротации, что приемник сможет принять секрет токена.
+	// ID и срок действия нового токена еще неизвестны, вместо них используются значения старого.
+	check(rotation Rotation) error
+	deliver(ctx context.Context, rotation Rotation) error
+}
+
+func newSink(config SinkConfig) (sink, error) {
+	switch config.Type {
+	case SinkFile:
+		path, err := parsePath(config.Path)
+		if err != nil {
+			return nil, err
+		}
+		return &fileSink{path: path}, nil
+	case SinkKubernetesSecret:
+		path, err := parsePath(config.Path)
+		if err != nil {
+			return nil, err
+		}
+		secretName, err := parseTemplate("secret_name", config.SecretName)
+		if err != nil {
+			return nil, err
+		}
+		if config.Namespace != "" && (len(config.Namespace) > 63 || !dnsLabel.MatchString(config.Namespace)) {
+			return nil, fmt.Errorf("namespace %q is not a valid Kubernetes namespace name", config.Namespace)
+		}
+		key := config.Key
+		if key == "" {
+			key = "token"
+		}
+		return &kubernetesSecretSink{path: path, secretName: secretName, namespace: config.Namespace, key: key}, nil
+	case SinkWebhook:
+		if config.URL == "" {
+			return nil, fmt.Errorf("url is required")
+		}
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = defaultWebhookTimeout
+		}
+		return &webhookSink{url: config.URL, headers: config.Headers, client: &http.Client{Timeout: timeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", config.Type)
+	}
+}
+
+func parseTemplate(field, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, fmt.Errorf("%s is required", field)
+	}
+	tmpl, err := template.New(field).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", field, err)
+	}
+	return tmpl, nil
+}
+
+// render подставляет в шаблон поля ротации без секрета. В строковых полях разделители
+// путей и ".." заменяются на "_".
+func render(tmpl *template.Template, rotation Rotation) (string, error) {
+	rotation.Token = ""
+	for _, field := range []*string{&rotation.Rule, &rotation.Instance, &rotation.OwnerKind, &rotation.OwnerName, &rotation.TokenName} {
+		*field = unsafePathChars.Replace(*field)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, rotation); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+// pathTemplate - шаблон пути файла. dir - каталог из неизменной части шаблона до первой
+// подстановки, за пределы которого путь не может выйти.
+type pathTemplate struct {
+	tmpl *template.Template
+	dir  string
+}
+
+func parsePath(text string) (*pathTemplate, error) {
+	tmpl, err := parseTemplate("path", text)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, _, _ := strings.Cut(text, "{{")
+	return &pathTemplate{tmpl: tmpl, dir: filepath.Dir(prefix + "_")}, nil
+}
+
+// render возвращает путь файла ротации и проверяет, что он не выходит за пределы каталога шаблона
+func (p *pathTemplate) render(rotation Rotation) (string, error) {
+	path, err := render(p.tmpl, rotation)
+	if err != nil {
+		return "", err
+	}
+
+	path = filepath.Clean(path)
+	rel, err := filepath.Rel(p.dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside of directory %s", path, p.dir)
+	}
+	return path, nil
+}
+
+// fileSink записывает секрет в файл с правами 0600
+type fileSink struct {
+	path *pathTemplate
+}
+
+func (s *fileSink) check(rotation Rotation) error {
+	_, err := s.path.render(rotation)
+	return err
+}
+
+func (s *fileSink) deliver(_ context.Context, rotation Rotation) error {
+	path, err := s.path.render(rotation)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, []byte(rotation.Token+"\n"))
+}
+
+// kubernetesSecretSink записывает манифест Secret, который затем применяется, например, GitOps-агентом
+type kubernetesSecretSink struct {
+	path       *pathTemplate
+	secretName *template.Template
+	namespace  string
+	key        string
+}
+
+type secretManifest struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   secretMetadata    `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	StringData map[string]string `yaml:"stringData"`
+}
+
+type secretMetadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+func (s *kubernetesSecretSink) check(rotation Rotation) error {
+	if _, err := s.path.render(rotation); err != nil {
+		return err
+	}
+	_, err := s.renderName(rotation)
+	return err
+}
+
+// renderName возвращает имя Secret, которое примет Kubernetes
+func (s *kubernetesSecretSink) renderName(rotation Rotation) (string, error) {
+	name, err := render(s.secretName, rotation)
+	if err != nil {
+		return "", err
+	}
+	if len(name) > 253 || !dnsSubdomain.MatchString(name) {
+		return "", fmt.Errorf("secret name %q is not a valid Kubernetes object name", name)
+	}
+	return name, nil
+}
+
+func (s *kubernetesSecretSink) deliver(_ context.Context, rotation Rotation) error {
+	path, err := s.path.render(rotation)
+	if err != nil {
+		return err
+	}
+	name, err := s.renderName(rotation)
+	if err != nil {
+		return err
+	}
+
+	annotations := map[string]string{
+		"gitlab-token-exporter/token-id":     strconv.Itoa(rotation.TokenID),
+		"gitlab-token-exporter/rotated-from": strconv.Itoa(rotation.OldTokenID),
+		"gitlab-token-exporter/rule":         rotation.Rule,
+	}
+	if rotation.ExpiresAt != nil {
+		annotations["gitlab-token-exporter/expires-at"] = rotation.ExpiresAt.Format(time.DateOnly)
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err = encoder.Encode(secretManifest{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   secretMetadata{Name: name, Namespace: s.namespace, Annotations: annotations},
+		Type:       "Opaque",
+		StringData: map[string]string{s.key: rotation.Token},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode secret manifest: %w", err)
+	}
+	return writeFile(path, buf.Bytes())
+}
+
+// webhookSink отправляет ротацию вместе с секретом POST-запросом в формате JSON
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (s *webhookSink) check(Rotation) error {
+	return nil
+}
+
+func (s *webhookSink) deliver(ctx context.Context, rotation Rotation) error {
+	body, err := json.Marshal(rotation)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// writeFile атомарно заменяет файл: читатель видит либо старый, либо новый секрет целиком
+func writeFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	file, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func testRotation() Rotation {
+	expiresAt := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	return Rotation{
+		Rule:       "ci",
+		OwnerKind:  "project",
+		OwnerID:    1,
+		OwnerName:  "backend",
+		TokenName:  "deploy",
+		OldTokenID: 7,
+		TokenID:    8,
+		ExpiresAt:  &expiresAt,
+		Token:      "glpat-secret",
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := newSink(SinkConfig{Type: SinkFile, Path: filepath.Join(dir, "{{.OwnerKind}}-{{.OwnerID}}", "{{.TokenName}}")})
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+
+	if err := sink.deliver(context.Background(), testRotation()); err != nil {
+		t.Fatalf("deliver() error = %v", err)
+	}
+
+	path := filepath.Join(dir, "project-1", "deploy")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read secret: %v", err)
+	}
+	if string(data) != "glpat-secret\n" {
+		t.Errorf("secret = %q, want %q", data, "glpat-secret\n")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat secret: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("file mode = %o, want 600", mode)
+	}
+
+	// Секрет недоступен в шаблонах, чтобы не попасть в пути файлов
+	leaking, err := newSink(SinkConfig{Type: SinkFile, Path: filepath.Join(dir, "{{.Token}}")})
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+	if err := leaking.deliver(context.Background(), testRotation()); err == nil {
+		t.Error("expected an error for the path without file name")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "glpat-secret")); err == nil {
+		t.Error("secret leaked into the file path")
+	}
+}
+
+func TestFileSink_PathTraversal(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "secrets")
+
+	tests := []struct {
+		name      string
+		path      string
+		tokenName string
+		wantPath  string
+		wantErr   bool
+	}{
+		{
+			name:      "разделители в имени токена заменяются",
+			path:      filepath.Join(dir, "{{.TokenName}}"),
+			tokenName: "../../../etc/cron.d/x",
+			wantPath:  filepath.Join(dir, "_________etc_cron.d_x"),
+		},
+		{
+			name:      "имя токена из точек",
+			path:      filepath.Join(dir, "{{.TokenName}}"),
+			tokenName: "..",
+			wantPath:  filepath.Join(dir, "__"),
+		},
+		{
+			name:      "обратная косая черта",
+			path:      filepath.Join(dir, "{{.OwnerName}}-{{.TokenName}}"),
+			tokenName: `..\x`,
+			wantPath:  filepath.Join(dir, "backend-___x"),
+		},
+		{
+			name:      "путь из шаблона выходит из каталога",
+			path:      filepath.Join(dir, "{{.OwnerKind}}") + "/../../escape",
+			tokenName: "deploy",
+			wantErr:   true,
+		},
+		{
+			name:      "путь совпадает с каталогом",
+			path:      dir + "/{{.Instance}}",
+			tokenName: "deploy",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, err := newSink(SinkConfig{Type: SinkFile, Path: tt.path})
+			if err != nil {
+				t.Fatalf("newSink() error = %v", err)
+			}
+			rotation := testRotation()
+			rotation.TokenName = tt.tokenName
+
+			err = sink.check(rotation)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			err = sink.deliver(context.Background(), rotation)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("deliver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if _, err := os.Stat(tt.wantPath); err != nil {
+				t.Errorf("secret was not written to %s: %v", tt.wantPath, err)
+			}
+		})
+	}
+
+	// Вне каталога приемника ничего не создано
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", root, err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "secrets" {
+		t.Errorf("unexpected entries outside of the sink directory: %v", entries)
+	}
+}
+
+func TestKubernetesSecretSink(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := newSink(SinkConfig{
+		Type:       SinkKubernetesSecret,
+		Path:       filepath.Join(dir, "{{.TokenName}}.yaml"),
+		SecretName: "gitlab-{{.TokenName}}",
+		Namespace:  "ci",
+	})
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+
+	if err := sink.deliver(context.Background(), testRotation()); err != nil {
+		t.Fatalf("deliver() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "deploy.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	var manifest secretManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+	if manifest.Kind != "Secret" || manifest.Metadata.Name != "gitlab-deploy" || manifest.Metadata.Namespace != "ci" {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+	if got := manifest.StringData["token"]; got != "glpat-secret" {
+		t.Errorf("stringData.token = %q, want %q", got, "glpat-secret")
+	}
+	if got := manifest.Metadata.Annotations["gitlab-token-exporter/expires-at"]; got != "2025-06-01" {
+		t.Errorf("expires-at annotation = %q, want %q", got, "2025-06-01")
+	}
+}
+
+func TestKubernetesSecretSink_SecretName(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretName string
+		tokenName  string
+		wantErr    bool
+	}{
+		{name: "допустимое имя", secretName: "gitlab-{{.TokenName}}", tokenName: "ci-deploy.v2"},
+		{name: "заглавные буквы", secretName: "gitlab-{{.TokenName}}", tokenName: "Deploy", wantErr: true},
+		{name: "пробел", secretName: "gitlab-{{.TokenName}}", tokenName: "ci deploy", wantErr: true},
+		{name: "разделитель пути", secretName: "gitlab-{{.TokenName}}", tokenName: "ci/deploy", wantErr: true},
+		{name: "дефис в конце", secretName: "{{.TokenName}}", tokenName: "deploy-", wantErr: true},
+		{name: "слишком длинное имя", secretName: "{{.TokenName}}", tokenName: strings.Repeat("a", 254), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			sink, err := newSink(SinkConfig{
+				Type:       SinkKubernetesSecret,
+				Path:       filepath.Join(dir, "secret.yaml"),
+				SecretName: tt.secretName,
+			})
+			if err != nil {
+				t.Fatalf("newSink() error = %v", err)
+			}
+			rotation := testRotation()
+			rotation.TokenName = tt.tokenName
+
+			if err := sink.check(rotation); (err != nil) != tt.wantErr {
+				t.Errorf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := sink.deliver(context.Background(), rotation); (err != nil) != tt.wantErr {
+				t.Errorf("deliver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "secret.yaml")); tt.wantErr && err == nil {
+				t.Error("manifest with invalid secret name was written")
+			}
+		})
+	}
+
+	// Пространство имен проверяется при загрузке правил
+	_, err := newSink(SinkConfig{Type: SinkKubernetesSecret, Path: "secret.yaml", SecretName: "gitlab", Namespace: "CI"})
+	if err == nil {
+		t.Error("expected an error for the invalid namespace")
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	t.Setenv("TEST_WEBHOOK_TOKEN", "hook-secret")
+
+	var received Rotation
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer hook-secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer hook-secret")
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		if strings.HasSuffix(r.URL.Path, "/fail") {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	sink, err := newSink(SinkConfig{
+		Type:    SinkWebhook,
+		URL:     server.URL + "/ok",
+		Headers: map[string]string{"Authorization": "Bearer ${TEST_WEBHOOK_TOKEN}"},
+	})
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+	if err := sink.deliver(context.Background(), testRotation()); err != nil {
+		t.Fatalf("deliver() error = %v", err)
+	}
+	if received.Token != "glpat-secret" || received.TokenID != 8 || received.OldTokenID != 7 {
+		t.Errorf("unexpected payload: %+v", received)
+	}
+
+	failing, err := newSink(SinkConfig{Type: SinkWebhook, URL: server.URL + "/fail", Headers: map[string]string{"Authorization": "Bearer ${TEST_WEBHOOK_TOKEN}"}})
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+	if err := failing.deliver(context.Background(), testRotation()); err == nil {
+		t.Error("expected an error for a non-2xx response")
+	}
+}
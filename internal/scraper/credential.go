@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/gitlab"
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// readScopes - scopes, любого из которых достаточно для чтения токенов и ресурсов целей
+var readScopes = []string{"api", "read_api"}
+
+// rotateScopes - scopes, необходимые для ротации токенов
+var rotateScopes = []string{"api"}
+
+// VerifyCredential проверяет токен клиента при запуске и перезагрузке: GitLab должен принять
+// токен, а у токена должен быть scope api или read_api, а при включенной ротации (rotate) - api.
+// Если GitLab не поддерживает /personal_access_tokens/self, проверка пропускается, а ротация
+// запрещается: без ID токена экспортера он мог бы ротировать сам себя.
+func VerifyCredential(ctx context.Context, client gitlab.GitLabClientInterface, rotate bool) error {
+	token, err := client.GetSelfAccessToken(ctx)
+	switch {
+	case errors.Is(err, gitlabapi.ErrNotFound) && rotate:
+		return fmt.Errorf("token rotation requires GitLab to identify the exporter token via /personal_access_tokens/self (GitLab 15.5+): %w", err)
+	case errors.Is(err, gitlabapi.ErrNotFound):
+		log.Println("GitLab does not support /personal_access_tokens/self, skipping exporter token check")
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if !hasAnyScope(token.Scopes, readScopes) {
+		return fmt.Errorf("exporter token %q has scopes %v, but one of %v is required to read tokens of the configured targets", token.Name, token.Scopes, readScopes)
+	}
+	if rotate && !hasAnyScope(token.Scopes, rotateScopes) {
+		return fmt.Errorf("exporter token %q has scopes %v, but one of %v is required to rotate tokens", token.Name, token.Scopes, rotateScopes)
+	}
+
+	log.Printf("Exporter token %q (ID %d), scopes %v, expires: %s", token.Name, token.ID, token.Scopes, formatExpiry(token.ExpiresAt, time.Now()))
+	return nil
+}
+
+func hasAnyScope(scopes, required []string) bool {
+	return slices.ContainsFunc(scopes, func(scope string) bool { return slices.Contains(required, scope) })
+}
+
+// checkCredential проверяет токен экспортера в начале прохода. При сетевой ошибке остаются
+// сведения прошлой проверки, а при отказе GitLab токен отмечается недействительным.
+// Вызывается только из цикла Start.
+func (s *TokenScraper) checkCredential(ctx context.Context) *metrics.Credential {
+	if s.selfUnsupported {
+		return nil
+	}
+
+	token, err := s.gitlabClient.GetSelfAccessToken(ctx)
+	switch {
+	case err == nil:
+		s.credential = newCredential(token)
+	case errors.Is(err, gitlabapi.ErrNotFound):
+		log.Println("GitLab does not support /personal_access_tokens/self, exporter token metrics are disabled")
+		s.selfUnsupported = true
+		return nil
+	case errors.Is(err, gitlab.ErrUnauthorized):
+		log.Printf("GitLab rejected the exporter token: %v", err)
+		s.metrics.IncrementScrapeErrors()
+
+		// Снапшот предыдущего прохода ссылается на старое значение, поэтому создаем новое
+		var credential metrics.Credential
+		if s.credential != nil {
+			credential = *s.credential
+		}
+		credential.Valid = false
+		s.credential = &credential
+	default:
+		log.Printf("Failed to check exporter token: %v", err)
+		s.metrics.IncrementScrapeErrors()
+	}
+
+	return s.credential
+}
+
+// newCredential формирует сведения о токене экспортера для метрик
+func newCredential(token *gitlabapi.PersonalAccessToken) *metrics.Credential {
+	credential := &metrics.Credential{
+		Valid:      true,
+		TokenID:    token.ID,
+		TokenName:  token.Name,
+		Scopes:     token.Scopes,
+		LastUsedAt: token.LastUsedAt,
+	}
+	if token.ExpiresAt != nil {
+		expires := time.Time(*token.ExpiresAt)
+		credential.ExpiresAt = &expires
+	}
+	return credential
+}
@@ -0,0 +1,122 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/gitlab"
+)
+
+func TestVerifyCredential(t *testing.T) {
+	tests := []struct {
+		name      string
+		selfToken *gitlabapi.PersonalAccessToken
+		selfErr   error
+		rotate    bool
+		wantErr   bool
+	}{
+		{
+			name:      "read_api scope",
+			selfToken: &gitlabapi.PersonalAccessToken{ID: 1, Name: "exporter", Scopes: []string{"read_api"}},
+		},
+		{
+			name:      "api scope",
+			selfToken: &gitlabapi.PersonalAccessToken{ID: 1, Name: "exporter", Scopes: []string{"read_user", "api"}},
+		},
+		{
+			name:      "missing scope",
+			selfToken: &gitlabapi.PersonalAccessToken{ID: 1, Name: "exporter", Scopes: []string{"read_user", "read_registry"}},
+			wantErr:   true,
+		},
+		{
+			name:      "rotation with api scope",
+			selfToken: &gitlabapi.PersonalAccessToken{ID: 1, Name: "exporter", Scopes: []string{"api"}},
+			rotate:    true,
+		},
+		{
+			// Для ротации токенов недостаточно прав на чтение
+			name:      "rotation with read_api scope",
+			selfToken: &gitlabapi.PersonalAccessToken{ID: 1, Name: "exporter", Scopes: []string{"read_api"}},
+			rotate:    true,
+			wantErr:   true,
+		},
+		{
+			name:    "rejected token",
+			selfErr: fmt.Errorf("failed to get exporter token: %w", gitlab.ErrUnauthorized),
+			wantErr: true,
+		},
+		{
+			// Старые версии GitLab не поддерживают проверку, запуск не блокируется
+			name:    "not supported",
+			selfErr: gitlabapi.ErrNotFound,
+		},
+		{
+			// Без ID токена экспортера ротация могла бы отозвать его самого
+			name:    "rotation not supported",
+			selfErr: gitlabapi.ErrNotFound,
+			rotate:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockGitLabClient{selfToken: tt.selfToken, selfErr: tt.selfErr}
+			if err := VerifyCredential(context.Background(), client, tt.rotate); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyCredential() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenScraper_Credential(t *testing.T) {
+	expiresAt := time.Now().Add(30 * 24 * time.Hour).Truncate(24 * time.Hour)
+	client := &mockGitLabClient{
+		selfToken: &gitlabapi.PersonalAccessToken{
+			ID: 9, Name: "exporter", Scopes: []string{"read_api"}, ExpiresAt: isoTime(expiresAt),
+		},
+	}
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, nil, nil)
+
+	scraper.scrape(context.Background())
+	if got, _ := gaugeValue(t, registry, "gitlab_token_exporter_credential_valid", map[string]string{}); got != 1 {
+		t.Errorf("gitlab_token_exporter_credential_valid = %v, want 1", got)
+	}
+	labels := map[string]string{"token_id": "9", "token_name": "exporter"}
+	if got, _ := gaugeValue(t, registry, "gitlab_token_exporter_credential_expiry_timestamp_seconds", labels); got != float64(expiresAt.Unix()) {
+		t.Errorf("gitlab_token_exporter_credential_expiry_timestamp_seconds = %v, want %v", got, expiresAt.Unix())
+	}
+
+	// Сетевая ошибка не меняет результат прошлой проверки
+	client.selfErr = errors.New("connection refused")
+	scraper.scrape(context.Background())
+	if got, _ := gaugeValue(t, registry, "gitlab_token_exporter_credential_valid", map[string]string{}); got != 1 {
+		t.Errorf("gitlab_token_exporter_credential_valid after network error = %v, want 1", got)
+	}
+
+	// Отозванный токен отмечается недействительным, сведения о нем сохраняются
+	client.selfErr = fmt.Errorf("failed to get exporter token: %w", gitlab.ErrUnauthorized)
+	scraper.scrape(context.Background())
+	if got, _ := gaugeValue(t, registry, "gitlab_token_exporter_credential_valid", map[string]string{}); got != 0 {
+		t.Errorf("gitlab_token_exporter_credential_valid after rejection = %v, want 0", got)
+	}
+	if _, ok := gaugeValue(t, registry, "gitlab_token_exporter_credential_info", labels); !ok {
+		t.Error("expected credential info to be kept after rejection")
+	}
+	if got := counterValue(t, registry, "gitlab_token_scrape_errors_total"); got != 2 {
+		t.Errorf("gitlab_token_scrape_errors_total = %v, want 2", got)
+	}
+
+	// Без поддержки в GitLab метрики токена экспортера не экспортируются и ошибки не учитываются
+	unsupported := NewTokenScraper(&mockGitLabClient{selfErr: gitlabapi.ErrNotFound}, handler, nil, nil)
+	unsupported.scrape(context.Background())
+	if _, ok := gaugeValue(t, registry, "gitlab_token_exporter_credential_valid", map[string]string{}); ok {
+		t.Error("unexpected credential metrics when GitLab does not support the check")
+	}
+}
@@ -0,0 +1,96 @@
+package scraper
+
+import (
+	"context"
+	"log"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// WithDeployTokens включает сбор deploy-токенов проектов и групп
+func WithDeployTokens(enabled bool) Option {
+	return func(s *TokenScraper) {
+		s.deployTokens = enabled
+	}
+}
+
+// scrapeDeployTokens собирает deploy-токены всех отслеживаемых проектов и групп
+func (s *TokenScraper) scrapeDeployTokens(ctx context.Context, projectIDs, groupIDs []int, now time.Time) []metrics.DeployToken {
+	projectResults := make([][]metrics.DeployToken, len(projectIDs))
+	forEach(ctx, s.concurrency, len(projectIDs), func(i int) {
+		projectResults[i] = s.scrapeOwnerDeployTokens(ctx, metrics.KindProject, projectIDs[i], now)
+	})
+
+	groupResults := make([][]metrics.DeployToken, len(groupIDs))
+	forEach(ctx, s.concurrency, len(groupIDs), func(i int) {
+		groupResults[i] = s.scrapeOwnerDeployTokens(ctx, metrics.KindGroup, groupIDs[i], now)
+	})
+
+	var result []metrics.DeployToken
+	for _, tokens := range append(projectResults, groupResults...) {
+		result = append(result, tokens...)
+	}
+	return result
+}
+
+// scrapeOwnerDeployTokens собирает deploy-токены одного проекта или группы
+func (s *TokenScraper) scrapeOwnerDeployTokens(ctx context.Context, kind string, ownerID int, now time.Time) []metrics.DeployToken {
+	listTokens, getName := s.gitlabClient.GetProjectDeployTokens, s.gitlabClient.GetProjectName
+	if kind == metrics.KindGroup {
+		listTokens, getName = s.gitlabClient.GetGroupDeployTokens, s.gitlabClient.GetGroupName
+	}
+
+	tokens, err := listTokens(ctx, ownerID)
+	if err != nil {
+		log.Printf("Failed to get deploy tokens for %s %d: %v", kind, ownerID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	ownerName, err := getName(ctx, ownerID)
+	if err != nil {
+		log.Printf("Failed to get %s name for %s %d: %v", kind, kind, ownerID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	result := make([]metrics.DeployToken, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, newDeployToken(kind, ownerID, ownerName, token))
+		log.Printf("Deploy token: %s %d, Token: %s, Revoked: %t, Expires: %s", kind, ownerID, token.Name, token.Revoked, formatDeployExpiry(token.ExpiresAt, now))
+	}
+
+	return result
+}
+
+func newDeployToken(kind string, ownerID int, ownerName string, token *gitlabapi.DeployToken) metrics.DeployToken {
+	return metrics.DeployToken{
+		Labels: metrics.TokenLabels{
+			OwnerKind: kind,
+			OwnerID:   ownerID,
+			OwnerName: ownerName,
+			TokenID:   token.ID,
+			TokenName: token.Name,
+			Scopes:    token.Scopes,
+		},
+		Username:  token.Username,
+		ExpiresAt: token.ExpiresAt,
+		Revoked:   token.Revoked,
+	}
+}
+
+// formatDeployExpiry форматирует дату истечения deploy-токена для логов
+func formatDeployExpiry(expiresAt *time.Time, now time.Time) string {
+	if expiresAt == nil {
+		return "never"
+	}
+
+	iso := gitlabapi.ISOTime(*expiresAt)
+	return formatExpiry(&iso, now)
+}
@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestTokenScraper_DeployTokens(t *testing.T) {
+	expiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	client := &mockGitLabClient{
+		deployTokens: map[string][]*gitlabapi.DeployToken{
+			"project/1": {{ID: 10, Name: "registry", Username: "gitlab+deploy-token-10", ExpiresAt: &expiresAt, Scopes: []string{"read_registry"}}},
+			"group/5":   {{ID: 11, Name: "revoked", Username: "deployer", Revoked: true}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		want    bool
+	}{
+		{name: "enabled", enabled: true, want: true},
+		{name: "disabled", enabled: false, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, registry := newTestHandler(t)
+			scraper := NewTokenScraper(client, handler, []int{1}, []int{5}, WithDeployTokens(tt.enabled))
+			scraper.scrape(context.Background())
+
+			got, ok := gaugeValue(t, registry, "gitlab_deploy_token_expiry_timestamp_seconds", map[string]string{
+				"owner_kind": "project",
+				"owner_name": "Project1",
+				"token_name": "registry",
+				"username":   "gitlab+deploy-token-10",
+				"scopes":     "read_registry",
+			})
+			if ok != tt.want {
+				t.Fatalf("deploy token exported = %v, want %v", ok, tt.want)
+			}
+			if !tt.want {
+				return
+			}
+			if got != float64(expiresAt.Unix()) {
+				t.Errorf("expiry timestamp = %v, want %v", got, expiresAt.Unix())
+			}
+
+			if got, _ := gaugeValue(t, registry, "gitlab_deploy_token_revoked", map[string]string{"owner_name": "Group5", "token_name": "revoked"}); got != 1 {
+				t.Errorf("gitlab_deploy_token_revoked = %v, want 1", got)
+			}
+			if got, _ := gaugeValue(t, registry, "gitlab_deploy_tokens_total", map[string]string{"owner_kind": "group"}); got != 1 {
+				t.Errorf("gitlab_deploy_tokens_total{owner_kind=group} = %v, want 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// DiscoveryConfig - настройки автоматического обнаружения проектов и подгрупп
+type DiscoveryConfig struct {
+	// IncludeSubgroups - обходить подгруппы на любом уровне вложенности
+	IncludeSubgroups bool
+	// SkipArchived - не мониторить архивные проекты
+	SkipArchived bool
+	// Interval - период обновления списка обнаруженных целей
+	Interval time.Duration
+}
+
+// WithDiscovery включает обнаружение проектов и подгрупп в группах из groupIDs
+func WithDiscovery(config DiscoveryConfig) Option {
+	return func(s *TokenScraper) {
+		s.discovery = &config
+	}
+}
+
+// WithAdminMode включает режим администратора: обнаруживаются все проекты и группы инстанса,
+// а список GITLAB_PROJECT_IDS становится необязательным. Требует WithDiscovery.
+func WithAdminMode() Option {
+	return func(s *TokenScraper) {
+		s.adminMode = true
+	}
+}
+
+// groupTargets - проекты и подгруппы, обнаруженные в одной группе
+type groupTargets struct {
+	projectIDs []int
+	groupIDs   []int
+}
+
+// discoveredTargets хранит результаты последнего обнаружения по каждой группе
+type discoveredTargets struct {
+	mu     sync.RWMutex
+	groups map[int]groupTargets
+	// instance - все проекты и группы инстанса в режиме администратора
+	instance groupTargets
+}
+
+// discover обновляет список проектов и подгрупп. При ошибке для группы
+// сохраняется результат предыдущего обнаружения, чтобы цели не пропадали из мониторинга.
+func (s *TokenScraper) discover(ctx context.Context) {
+	start := time.Now()
+	log.Println("Starting target discovery...")
+
+	if s.adminMode {
+		s.discoverInstance(ctx)
+	}
+
+	for _, groupID := range s.groupIDs {
+		targets, err := s.discoverGroup(ctx, groupID)
+		if err != nil {
+			log.Printf("Failed to discover targets in group %d: %v", groupID, err)
+			s.metrics.IncrementScrapeErrors()
+			continue
+		}
+
+		s.discovered.mu.Lock()
+		s.discovered.groups[groupID] = targets
+		s.discovered.mu.Unlock()
+	}
+
+	projectIDs, groupIDs := s.targets()
+	s.metrics.SetDiscoveredTargets(len(projectIDs), len(groupIDs))
+	log.Printf("Target discovery completed in %v, monitoring %d projects and %d groups", time.Since(start), len(projectIDs), len(groupIDs))
+}
+
+// discoverInstance получает все проекты и группы инстанса
+func (s *TokenScraper) discoverInstance(ctx context.Context) {
+	var targets groupTargets
+
+	projects, err := s.gitlabClient.GetAllProjects(ctx, s.discovery.SkipArchived)
+	if err != nil {
+		log.Printf("Failed to discover instance projects: %v", err)
+		s.metrics.IncrementScrapeErrors()
+		return
+	}
+	for _, project := range projects {
+		targets.projectIDs = append(targets.projectIDs, project.ID)
+	}
+
+	groups, err := s.gitlabClient.GetAllGroups(ctx)
+	if err != nil {
+		log.Printf("Failed to discover instance groups: %v", err)
+		s.metrics.IncrementScrapeErrors()
+		return
+	}
+	for _, group := range groups {
+		targets.groupIDs = append(targets.groupIDs, group.ID)
+	}
+
+	s.discovered.mu.Lock()
+	s.discovered.instance = targets
+	s.discovered.mu.Unlock()
+}
+
+func (s *TokenScraper) discoverGroup(ctx context.Context, groupID int) (groupTargets, error) {
+	var targets groupTargets
+
+	projects, err := s.gitlabClient.GetGroupProjects(ctx, groupID, s.discovery.IncludeSubgroups, s.discovery.SkipArchived)
+	if err != nil {
+		return targets, err
+	}
+	for _, project := range projects {
+		targets.projectIDs = append(targets.projectIDs, project.ID)
+	}
+
+	if !s.discovery.IncludeSubgroups {
+		return targets, nil
+	}
+
+	groups, err := s.gitlabClient.GetDescendantGroups(ctx, groupID)
+	if err != nil {
+		return targets, err
+	}
+	for _, group := range groups {
+		targets.groupIDs = append(targets.groupIDs, group.ID)
+	}
+
+	return targets, nil
+}
+
+// targets возвращает настроенные и обнаруженные проекты и группы без повторов
+func (s *TokenScraper) targets() (projectIDs, groupIDs []int) {
+	if s.discovery == nil {
+		return newIDSet(s.projectIDs).ids, newIDSet(s.groupIDs).ids
+	}
+
+	s.discovered.mu.RLock()
+	defer s.discovered.mu.RUnlock()
+
+	projects := newIDSet(s.projectIDs)
+	groups := newIDSet(s.groupIDs)
+	for _, groupID := range s.groupIDs {
+		discovered := s.discovered.groups[groupID]
+		projects.add(discovered.projectIDs...)
+		groups.add(discovered.groupIDs...)
+	}
+	projects.add(s.discovered.instance.projectIDs...)
+	groups.add(s.discovered.instance.groupIDs...)
+
+	return projects.ids, groups.ids
+}
+
+// idSet - список ID без повторов с сохранением порядка добавления
+type idSet struct {
+	ids  []int
+	seen map[int]bool
+}
+
+func newIDSet(ids []int) *idSet {
+	set := &idSet{seen: make(map[int]bool)}
+	set.add(ids...)
+	return set
+}
+
+func (s *idSet) add(ids ...int) {
+	for _, id := range ids {
+		if !s.seen[id] {
+			s.seen[id] = true
+			s.ids = append(s.ids, id)
+		}
+	}
+}
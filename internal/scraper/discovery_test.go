@@ -0,0 +1,207 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+)
+
+func discoveryProject(id, namespaceID int, archived bool) *gitlabapi.Project {
+	return &gitlabapi.Project{ID: id, Archived: archived, Namespace: &gitlabapi.ProjectNamespace{ID: namespaceID}}
+}
+
+// newDiscoveryClient возвращает группу 10 с подгруппой 11, прямым, вложенным и архивным проектами
+func newDiscoveryClient() *mockGitLabClient {
+	return &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{
+			1: {projectToken(100, "static", nil)},
+			2: {projectToken(200, "direct", nil)},
+			3: {projectToken(300, "nested", nil)},
+			4: {projectToken(400, "archived", nil)},
+		},
+		groupTokens: map[int][]*gitlabapi.GroupAccessToken{
+			11: {groupToken(500, "subgroup", nil)},
+		},
+		groupProjects: map[int][]*gitlabapi.Project{
+			10: {discoveryProject(2, 10, false), discoveryProject(3, 11, false), discoveryProject(4, 10, true)},
+		},
+		subgroups: map[int][]*gitlabapi.Group{
+			10: {{ID: 11}},
+		},
+	}
+}
+
+func TestTokenScraper_Discover(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       DiscoveryConfig
+		wantProjects []int
+		wantGroups   []int
+	}{
+		{
+			name:         "subgroups without archived",
+			config:       DiscoveryConfig{IncludeSubgroups: true, SkipArchived: true},
+			wantProjects: []int{1, 2, 3},
+			wantGroups:   []int{10, 11},
+		},
+		{
+			name:         "subgroups with archived",
+			config:       DiscoveryConfig{IncludeSubgroups: true},
+			wantProjects: []int{1, 2, 3, 4},
+			wantGroups:   []int{10, 11},
+		},
+		{
+			name:         "direct projects only",
+			config:       DiscoveryConfig{SkipArchived: true},
+			wantProjects: []int{1, 2},
+			wantGroups:   []int{10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := newTestHandler(t)
+			scraper := NewTokenScraper(newDiscoveryClient(), handler, []int{1, 2}, []int{10}, WithDiscovery(tt.config))
+
+			scraper.discover(context.Background())
+
+			projectIDs, groupIDs := scraper.targets()
+			if !reflect.DeepEqual(projectIDs, tt.wantProjects) {
+				t.Errorf("projects = %v, want %v", projectIDs, tt.wantProjects)
+			}
+			if !reflect.DeepEqual(groupIDs, tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", groupIDs, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestTokenScraper_DiscoveredTokensScraped(t *testing.T) {
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(newDiscoveryClient(), handler, nil, []int{10},
+		WithDiscovery(DiscoveryConfig{IncludeSubgroups: true, SkipArchived: true}))
+
+	scraper.discover(context.Background())
+	scraper.scrape(context.Background())
+
+	if got, _ := gaugeValue(t, registry, "gitlab_tokens_total", map[string]string{}); got != 2 {
+		t.Errorf("gitlab_tokens_total = %v, want 2", got)
+	}
+	if _, ok := gaugeValue(t, registry, "gitlab_group_token_never_expires", map[string]string{"token_id": "500"}); !ok {
+		t.Error("token of discovered subgroup not exported")
+	}
+	if got, _ := gaugeValue(t, registry, "gitlab_discovered_targets", map[string]string{"kind": "project"}); got != 2 {
+		t.Errorf("gitlab_discovered_targets{kind=project} = %v, want 2", got)
+	}
+}
+
+func TestTokenScraper_DiscoveryKeepsTargetsOnError(t *testing.T) {
+	client := newDiscoveryClient()
+	handler, _ := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, nil, []int{10},
+		WithDiscovery(DiscoveryConfig{IncludeSubgroups: true, SkipArchived: true, Interval: time.Minute}))
+
+	scraper.discover(context.Background())
+	before, _ := scraper.targets()
+
+	// Временная ошибка API не должна убирать цели из мониторинга
+	client.failDiscovery = true
+	scraper.discover(context.Background())
+
+	after, _ := scraper.targets()
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("targets after failed discovery = %v, want %v", after, before)
+	}
+}
+
+func TestTokenScraper_AdminMode(t *testing.T) {
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{
+			1: {projectToken(100, "project-bot", nil)},
+			2: {projectToken(200, "archived-bot", nil)},
+		},
+		groupTokens: map[int][]*gitlabapi.GroupAccessToken{
+			5: {groupToken(500, "group-bot", nil)},
+		},
+		// Список личных токенов администратора включает токены ботов проектов и групп,
+		// в том числе бота архивного проекта, который не обходится
+		userTokens: []*gitlabapi.PersonalAccessToken{
+			{ID: 100, Name: "project-bot", UserID: 1001},
+			{ID: 200, Name: "archived-bot", UserID: 1002},
+			{ID: 500, Name: "group-bot", UserID: 1005},
+			{ID: 900, Name: "personal", UserID: 42},
+		},
+		botUserIDs:  map[int]bool{1001: true, 1002: true, 1005: true},
+		allProjects: []*gitlabapi.Project{discoveryProject(1, 5, false), discoveryProject(2, 5, true)},
+		allGroups:   []*gitlabapi.Group{{ID: 5}},
+	}
+
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, nil, nil,
+		WithDiscovery(DiscoveryConfig{SkipArchived: true}), WithAdminMode())
+
+	scraper.discover(context.Background())
+	scraper.scrape(context.Background())
+
+	projectIDs, groupIDs := scraper.targets()
+	if !reflect.DeepEqual(projectIDs, []int{1}) || !reflect.DeepEqual(groupIDs, []int{5}) {
+		t.Errorf("targets = %v, %v, want [1], [5]", projectIDs, groupIDs)
+	}
+
+	totals := map[string]float64{
+		"gitlab_tokens_total":       1,
+		"gitlab_group_tokens_total": 1,
+		"gitlab_user_tokens_total":  1,
+	}
+	for name, want := range totals {
+		if got, _ := gaugeValue(t, registry, name, map[string]string{}); got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	for _, tokenID := range []string{"100", "200"} {
+		if _, ok := gaugeValue(t, registry, "gitlab_user_token_never_expires", map[string]string{"token_id": tokenID}); ok {
+			t.Errorf("project bot token %s must not be exported as a user token", tokenID)
+		}
+	}
+
+	for _, kind := range []string{"project", "group", "user"} {
+		total, _ := gaugeValue(t, registry, "gitlab_scrape_targets", map[string]string{"kind": kind})
+		processed, _ := gaugeValue(t, registry, "gitlab_scrape_targets_processed", map[string]string{"kind": kind})
+		if total != 1 || processed != total {
+			t.Errorf("progress for %s = %v/%v, want 1/1", kind, processed, total)
+		}
+	}
+}
+
+func TestTokenScraper_AdminModeBotUsers(t *testing.T) {
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {projectToken(100, "project-bot", nil)}},
+		userTokens: []*gitlabapi.PersonalAccessToken{
+			{ID: 100, Name: "project-bot", UserID: 1001},
+			{ID: 900, Name: "personal", UserID: 42},
+		},
+		botUserIDs:  map[int]bool{1001: true},
+		allProjects: []*gitlabapi.Project{discoveryProject(1, 5, false)},
+	}
+
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, nil, nil, WithDiscovery(DiscoveryConfig{}), WithAdminMode())
+	scraper.discover(context.Background())
+	scraper.scrape(context.Background())
+
+	// Токены проекта не получены, а список ботов берется из предыдущего прохода
+	client.projectTokens = nil
+	client.botUsersErr = errors.New("503 Service Unavailable")
+	scraper.scrape(context.Background())
+
+	if _, ok := gaugeValue(t, registry, "gitlab_user_token_never_expires", map[string]string{"token_id": "100"}); ok {
+		t.Error("project bot token must not be exported as a user token")
+	}
+	if got, _ := gaugeValue(t, registry, "gitlab_user_tokens_total", map[string]string{}); got != 1 {
+		t.Errorf("gitlab_user_tokens_total = %v, want 1", got)
+	}
+}
@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"sort"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// inventoryKey - владелец токенов и значение scope или уровня доступа
+type inventoryKey struct {
+	ownerKind string
+	ownerID   int
+	value     string
+}
+
+// countInventory подсчитывает токены каждого владельца по scopes и уровням доступа.
+// Токены без уровня доступа (личные токены пользователей) в подсчет по уровням не входят.
+func countInventory(tokens []metrics.Token) (byScope, byAccessLevel []metrics.InventoryCount) {
+	scopes := make(map[inventoryKey]*metrics.InventoryCount)
+	levels := make(map[inventoryKey]*metrics.InventoryCount)
+
+	add := func(counts map[inventoryKey]*metrics.InventoryCount, labels metrics.TokenLabels, value string) {
+		key := inventoryKey{ownerKind: labels.OwnerKind, ownerID: labels.OwnerID, value: value}
+		count, ok := counts[key]
+		if !ok {
+			count = &metrics.InventoryCount{OwnerKind: labels.OwnerKind, OwnerID: labels.OwnerID, OwnerName: labels.OwnerName, Value: value}
+			counts[key] = count
+		}
+		count.Count++
+	}
+
+	for _, token := range tokens {
+		for _, scope := range token.Labels.Scopes {
+			add(scopes, token.Labels, scope)
+		}
+		if token.Labels.AccessLevel != "" {
+			add(levels, token.Labels, token.Labels.AccessLevel)
+		}
+	}
+
+	return sortedCounts(scopes), sortedCounts(levels)
+}
+
+// sortedCounts возвращает счетчики в стабильном порядке
+func sortedCounts(counts map[inventoryKey]*metrics.InventoryCount) []metrics.InventoryCount {
+	result := make([]metrics.InventoryCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.OwnerKind != b.OwnerKind {
+			return a.OwnerKind < b.OwnerKind
+		}
+		if a.OwnerID != b.OwnerID {
+			return a.OwnerID < b.OwnerID
+		}
+		return a.Value < b.Value
+	})
+	return result
+}
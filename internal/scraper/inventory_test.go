@@ -0,0 +1,69 @@
+package scraper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+func TestCountInventory(t *testing.T) {
+	project := func(id int, scopes []string, level string) metrics.Token {
+		return metrics.Token{Labels: metrics.TokenLabels{OwnerKind: metrics.KindProject, OwnerID: 1, OwnerName: "api", TokenID: id, Scopes: scopes, AccessLevel: level}}
+	}
+	user := metrics.Token{Labels: metrics.TokenLabels{OwnerKind: metrics.KindUser, OwnerID: 7, OwnerName: "Alice", TokenID: 10, Scopes: []string{"api", "sudo"}}}
+
+	byScope, byAccessLevel := countInventory([]metrics.Token{
+		project(1, []string{"api", "write_repository"}, "maintainer"),
+		project(2, []string{"api"}, "owner"),
+		project(3, []string{"read_api"}, "maintainer"),
+		user,
+	})
+
+	wantScopes := []metrics.InventoryCount{
+		{OwnerKind: "project", OwnerID: 1, OwnerName: "api", Value: "api", Count: 2},
+		{OwnerKind: "project", OwnerID: 1, OwnerName: "api", Value: "read_api", Count: 1},
+		{OwnerKind: "project", OwnerID: 1, OwnerName: "api", Value: "write_repository", Count: 1},
+		{OwnerKind: "user", OwnerID: 7, OwnerName: "Alice", Value: "api", Count: 1},
+		{OwnerKind: "user", OwnerID: 7, OwnerName: "Alice", Value: "sudo", Count: 1},
+	}
+	if !reflect.DeepEqual(byScope, wantScopes) {
+		t.Errorf("byScope = %+v, want %+v", byScope, wantScopes)
+	}
+
+	// Личные токены не имеют уровня доступа и не учитываются
+	wantLevels := []metrics.InventoryCount{
+		{OwnerKind: "project", OwnerID: 1, OwnerName: "api", Value: "maintainer", Count: 2},
+		{OwnerKind: "project", OwnerID: 1, OwnerName: "api", Value: "owner", Count: 1},
+	}
+	if !reflect.DeepEqual(byAccessLevel, wantLevels) {
+		t.Errorf("byAccessLevel = %+v, want %+v", byAccessLevel, wantLevels)
+	}
+}
+
+func TestTokenScraper_Inventory(t *testing.T) {
+	handler, registry := newTestHandler(t)
+
+	bot := projectToken(1, "deploy-bot", nil)
+	bot.Scopes = []string{"api", "write_repository"}
+	bot.AccessLevel = gitlabapi.OwnerPermissions
+
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {bot}},
+	}
+	scraper := NewTokenScraper(client, handler, []int{1}, nil)
+	scraper.scrape(context.Background())
+
+	if _, ok := gaugeValue(t, registry, "gitlab_access_token_info", map[string]string{"token_name": "deploy-bot", "scopes": "api,write_repository", "access_level": "owner"}); !ok {
+		t.Error("gitlab_access_token_info for deploy-bot not found")
+	}
+	if got, _ := gaugeValue(t, registry, "gitlab_access_tokens_by_scope", map[string]string{"owner_name": "Project1", "scope": "write_repository"}); got != 1 {
+		t.Errorf("gitlab_access_tokens_by_scope{scope=write_repository} = %v, want 1", got)
+	}
+	if got, _ := gaugeValue(t, registry, "gitlab_access_tokens_by_access_level", map[string]string{"access_level": "owner"}); got != 1 {
+		t.Errorf("gitlab_access_tokens_by_access_level{access_level=owner} = %v, want 1", got)
+	}
+}
@@ -0,0 +1,218 @@
+package scraper
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+	"golang.org/x/crypto/ssh"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// WithKeys включает сбор deploy-ключей, а в режиме администратора - SSH и GPG-ключей пользователей
+func WithKeys(enabled bool) Option {
+	return func(s *TokenScraper) {
+		s.keys = enabled
+	}
+}
+
+// scrapeKeys собирает deploy-ключи отслеживаемых проектов. В режиме администратора
+// deploy-ключи запрашиваются одним списком по инстансу, а также собираются ключи пользователей.
+func (s *TokenScraper) scrapeKeys(ctx context.Context, projectIDs []int, now time.Time) []metrics.Key {
+	var result []metrics.Key
+	if s.adminMode {
+		result = append(result, s.scrapeInstanceDeployKeys(ctx)...)
+		result = append(result, s.scrapeUserKeys(ctx)...)
+	} else {
+		projectResults := make([][]metrics.Key, len(projectIDs))
+		forEach(ctx, s.concurrency, len(projectIDs), func(i int) {
+			projectResults[i] = s.scrapeProjectDeployKeys(ctx, projectIDs[i])
+		})
+		for _, keys := range projectResults {
+			result = append(result, keys...)
+		}
+	}
+
+	for _, key := range result {
+		log.Printf("Key: %s, %s %d, Title: %s, Expires: %s", key.Type, key.OwnerKind, key.OwnerID, key.Title, formatDeployExpiry(key.ExpiresAt, now))
+	}
+	return result
+}
+
+// scrapeProjectDeployKeys собирает deploy-ключи одного проекта
+func (s *TokenScraper) scrapeProjectDeployKeys(ctx context.Context, projectID int) []metrics.Key {
+	keys, err := s.gitlabClient.GetProjectDeployKeys(ctx, projectID)
+	if err != nil {
+		log.Printf("Failed to get deploy keys for project %d: %v", projectID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	projectName, err := s.gitlabClient.GetProjectName(ctx, projectID)
+	if err != nil {
+		log.Printf("Failed to get project name for project %d: %v", projectID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	result := make([]metrics.Key, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, metrics.Key{
+			Type:        metrics.KeyTypeDeploy,
+			OwnerKind:   metrics.KindProject,
+			OwnerID:     projectID,
+			OwnerName:   projectName,
+			ID:          key.ID,
+			Title:       key.Title,
+			Fingerprint: key.FingerprintSHA256,
+			ExpiresAt:   key.ExpiresAt,
+		})
+	}
+	return result
+}
+
+// scrapeInstanceDeployKeys собирает все deploy-ключи инстанса. Ключ, подключенный
+// к нескольким проектам, экспортируется для каждого из них, как и при обходе по проектам.
+func (s *TokenScraper) scrapeInstanceDeployKeys(ctx context.Context) []metrics.Key {
+	keys, err := s.gitlabClient.GetAllDeployKeys(ctx)
+	if err != nil {
+		log.Printf("Failed to get instance deploy keys: %v", err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	var result []metrics.Key
+	for _, key := range keys {
+		deployKey := metrics.Key{
+			Type:        metrics.KeyTypeDeploy,
+			OwnerKind:   metrics.KindInstance,
+			ID:          key.ID,
+			Title:       key.Title,
+			Fingerprint: key.FingerprintSHA256,
+			ExpiresAt:   key.ExpiresAt,
+		}
+
+		projects := slices.Concat(key.ProjectsWithWriteAccess, key.ProjectsWithReadonlyAccess)
+		if len(projects) == 0 {
+			result = append(result, deployKey)
+			continue
+		}
+		for _, project := range projects {
+			projectKey := deployKey
+			projectKey.OwnerKind = metrics.KindProject
+			projectKey.OwnerID = project.ID
+			projectKey.OwnerName = project.Name
+			result = append(result, projectKey)
+		}
+	}
+	return result
+}
+
+// scrapeUserKeys собирает SSH и GPG-ключи всех активных пользователей инстанса
+func (s *TokenScraper) scrapeUserKeys(ctx context.Context) []metrics.Key {
+	users, err := s.gitlabClient.GetAllUsers(ctx)
+	if err != nil {
+		log.Printf("Failed to get users: %v", err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	results := make([][]metrics.Key, len(users))
+	forEach(ctx, s.concurrency, len(users), func(i int) {
+		results[i] = s.scrapeOneUserKeys(ctx, users[i])
+	})
+
+	var result []metrics.Key
+	for _, keys := range results {
+		result = append(result, keys...)
+	}
+	return result
+}
+
+func (s *TokenScraper) scrapeOneUserKeys(ctx context.Context, user *gitlabapi.User) []metrics.Key {
+	sshKeys, err := s.gitlabClient.GetUserSSHKeys(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to get SSH keys for user %d: %v", user.ID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	gpgKeys, err := s.gitlabClient.GetUserGPGKeys(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to get GPG keys for user %d: %v", user.ID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	result := make([]metrics.Key, 0, len(sshKeys)+len(gpgKeys))
+	for _, key := range sshKeys {
+		result = append(result, metrics.Key{
+			Type:        metrics.KeyTypeSSH,
+			OwnerKind:   metrics.KindUser,
+			OwnerID:     user.ID,
+			OwnerName:   user.Name,
+			ID:          key.ID,
+			Title:       key.Title,
+			Fingerprint: sshFingerprint(key.Key),
+			ExpiresAt:   key.ExpiresAt,
+		})
+	}
+	for _, key := range gpgKeys {
+		gpgKey := metrics.Key{
+			Type:      metrics.KeyTypeGPG,
+			OwnerKind: metrics.KindUser,
+			OwnerID:   user.ID,
+			OwnerName: user.Name,
+			ID:        key.ID,
+		}
+		// API не возвращает срок действия GPG-ключа, он хранится в самом ключе
+		if err := parseGPGKey(key.Key, &gpgKey); err != nil {
+			log.Printf("Failed to parse GPG key %d of user %d: %v", key.ID, user.ID, err)
+		}
+		result = append(result, gpgKey)
+	}
+	return result
+}
+
+// sshFingerprint возвращает SHA256-отпечаток открытого SSH-ключа в формате OpenSSH
+func sshFingerprint(authorizedKey string) string {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		return ""
+	}
+	return ssh.FingerprintSHA256(key)
+}
+
+// parseGPGKey заполняет отпечаток, основной идентификатор и срок действия из открытого GPG-ключа
+func parseGPGKey(armored string, key *metrics.Key) error {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if len(entities) == 0 {
+		return fmt.Errorf("no keys found")
+	}
+
+	entity := entities[0]
+	key.Fingerprint = strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint))
+
+	signature, identity := entity.PrimarySelfSignature()
+	if identity != nil {
+		key.Title = identity.Name
+	}
+	if signature != nil && signature.KeyLifetimeSecs != nil && *signature.KeyLifetimeSecs > 0 {
+		expiresAt := entity.PrimaryKey.CreationTime.Add(time.Duration(*signature.KeyLifetimeSecs) * time.Second)
+		key.ExpiresAt = &expiresAt
+	}
+	return nil
+}
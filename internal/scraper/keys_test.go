@@ -0,0 +1,187 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+	"golang.org/x/crypto/ssh"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// armoredGPGKey генерирует открытый GPG-ключ со сроком действия lifetime (0 - бессрочный)
+func armoredGPGKey(t *testing.T, created time.Time, lifetime time.Duration) string {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Alice", "", "alice@example.com", &packet.Config{
+		Algorithm:       packet.PubKeyAlgoEdDSA,
+		KeyLifetimeSecs: uint32(lifetime.Seconds()),
+		Time:            func() time.Time { return created },
+	})
+	if err != nil {
+		t.Fatalf("Failed to generate GPG key: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("Failed to encode GPG key: %v", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("Failed to serialize GPG key: %v", err)
+	}
+	w.Close()
+	return buf.String()
+}
+
+// authorizedSSHKey генерирует открытый SSH-ключ и возвращает его вместе с отпечатком
+func authorizedSSHKey(t *testing.T) (string, string) {
+	t.Helper()
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate SSH key: %v", err)
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatalf("Failed to convert SSH key: %v", err)
+	}
+	return string(ssh.MarshalAuthorizedKey(key)), ssh.FingerprintSHA256(key)
+}
+
+func TestParseGPGKey(t *testing.T) {
+	created := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := created.Add(365 * 24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		lifetime    time.Duration
+		wantExpires *time.Time
+	}{
+		{name: "never expires", lifetime: 0},
+		{name: "expires in a year", lifetime: 365 * 24 * time.Hour, wantExpires: &expiresAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var key metrics.Key
+			if err := parseGPGKey(armoredGPGKey(t, created, tt.lifetime), &key); err != nil {
+				t.Fatalf("parseGPGKey() error = %v", err)
+			}
+
+			if key.Title != "Alice <alice@example.com>" {
+				t.Errorf("Title = %q, want %q", key.Title, "Alice <alice@example.com>")
+			}
+			if len(key.Fingerprint) != 40 {
+				t.Errorf("Fingerprint = %q, want 40 hex characters", key.Fingerprint)
+			}
+			switch {
+			case tt.wantExpires == nil && key.ExpiresAt != nil:
+				t.Errorf("ExpiresAt = %v, want nil", key.ExpiresAt)
+			case tt.wantExpires != nil && (key.ExpiresAt == nil || !key.ExpiresAt.Equal(*tt.wantExpires)):
+				t.Errorf("ExpiresAt = %v, want %v", key.ExpiresAt, tt.wantExpires)
+			}
+		})
+	}
+
+	if err := parseGPGKey("not a key", &metrics.Key{}); err == nil {
+		t.Error("parseGPGKey() expected error for invalid key")
+	}
+}
+
+func TestSSHFingerprint(t *testing.T) {
+	authorized, fingerprint := authorizedSSHKey(t)
+
+	if got := sshFingerprint(authorized); got != fingerprint {
+		t.Errorf("sshFingerprint() = %q, want %q", got, fingerprint)
+	}
+	if got := sshFingerprint("not a key"); got != "" {
+		t.Errorf("sshFingerprint() = %q for invalid key, want empty", got)
+	}
+}
+
+func TestTokenScraper_ProjectDeployKeys(t *testing.T) {
+	expiresAt := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+
+	client := &mockGitLabClient{
+		deployKeys: map[int][]*gitlabapi.ProjectDeployKey{
+			1: {{ID: 7, Title: "ci-deploy", FingerprintSHA256: "SHA256:abc", ExpiresAt: &expiresAt}},
+		},
+	}
+
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, []int{1}, nil, WithKeys(true))
+	scraper.scrape(context.Background())
+
+	got, ok := gaugeValue(t, registry, "gitlab_key_expiry_timestamp_seconds", map[string]string{
+		"key_type":    "deploy_key",
+		"owner_kind":  "project",
+		"owner_name":  "Project1",
+		"title":       "ci-deploy",
+		"fingerprint": "SHA256:abc",
+	})
+	if !ok || got != float64(expiresAt.Unix()) {
+		t.Errorf("deploy key expiry = %v (found %v), want %v", got, ok, expiresAt.Unix())
+	}
+}
+
+func TestTokenScraper_AdminModeKeys(t *testing.T) {
+	authorized, fingerprint := authorizedSSHKey(t)
+	expiresAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	client := &mockGitLabClient{
+		instanceKeys: []*gitlabapi.InstanceDeployKey{
+			{
+				ID: 7, Title: "shared",
+				ProjectsWithWriteAccess:    []*gitlabapi.DeployKeyProject{{ID: 1, Name: "api"}},
+				ProjectsWithReadonlyAccess: []*gitlabapi.DeployKeyProject{{ID: 2, Name: "web"}},
+			},
+			{ID: 8, Title: "orphan"},
+		},
+		users: []*gitlabapi.User{{ID: 42, Name: "Alice"}},
+		sshKeys: map[int][]*gitlabapi.SSHKey{
+			42: {{ID: 9, Title: "laptop", Key: authorized, ExpiresAt: &expiresAt}},
+		},
+		gpgKeys: map[int][]*gitlabapi.GPGKey{
+			42: {{ID: 10, Key: armoredGPGKey(t, time.Now(), 0)}},
+		},
+	}
+
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, nil, nil,
+		WithDiscovery(DiscoveryConfig{}), WithAdminMode(), WithKeys(true))
+	scraper.scrape(context.Background())
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   float64
+	}{
+		{"write access project", map[string]string{"title": "shared", "owner_name": "api"}, 0},
+		{"readonly access project", map[string]string{"title": "shared", "owner_name": "web"}, 0},
+		{"key without projects", map[string]string{"title": "orphan", "owner_kind": "instance"}, 0},
+		{"expired ssh key", map[string]string{"key_type": "ssh_key", "owner_name": "Alice", "fingerprint": fingerprint}, 1},
+		{"gpg key", map[string]string{"key_type": "gpg_key", "title": "Alice <alice@example.com>"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := gaugeValue(t, registry, "gitlab_key_is_expired", tt.labels)
+			if !ok {
+				t.Fatalf("gitlab_key_is_expired%v not found", tt.labels)
+			}
+			if got != tt.want {
+				t.Errorf("gitlab_key_is_expired%v = %v, want %v", tt.labels, got, tt.want)
+			}
+		})
+	}
+
+	if got, _ := gaugeValue(t, registry, "gitlab_keys_total", map[string]string{"key_type": "deploy_key"}); got != 3 {
+		t.Errorf("gitlab_keys_total{key_type=deploy_key} = %v, want 3", got)
+	}
+}
@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"context"
+	"log"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// WithPipelines включает сбор триггеров и расписаний пайплайнов проектов
+func WithPipelines(enabled bool) Option {
+	return func(s *TokenScraper) {
+		s.pipelines = enabled
+	}
+}
+
+// scrapePipelines собирает триггеры и расписания пайплайнов всех отслеживаемых проектов
+func (s *TokenScraper) scrapePipelines(ctx context.Context, projectIDs []int) []metrics.PipelineResource {
+	results := make([][]metrics.PipelineResource, len(projectIDs))
+	forEach(ctx, s.concurrency, len(projectIDs), func(i int) {
+		results[i] = s.scrapeProjectPipelines(ctx, projectIDs[i])
+	})
+
+	var resources []metrics.PipelineResource
+	for _, result := range results {
+		resources = append(resources, result...)
+	}
+	return resources
+}
+
+func (s *TokenScraper) scrapeProjectPipelines(ctx context.Context, projectID int) []metrics.PipelineResource {
+	triggers, err := s.gitlabClient.GetPipelineTriggers(ctx, projectID)
+	if err != nil {
+		log.Printf("Failed to get pipeline triggers for project %d: %v", projectID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	schedules, err := s.gitlabClient.GetPipelineSchedules(ctx, projectID)
+	if err != nil {
+		log.Printf("Failed to get pipeline schedules for project %d: %v", projectID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	if len(triggers) == 0 && len(schedules) == 0 {
+		return nil
+	}
+
+	projectName, err := s.gitlabClient.GetProjectName(ctx, projectID)
+	if err != nil {
+		log.Printf("Failed to get project name for project %d: %v", projectID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	result := make([]metrics.PipelineResource, 0, len(triggers)+len(schedules))
+	for _, trigger := range triggers {
+		resource := metrics.PipelineResource{
+			Kind:        metrics.PipelineKindTrigger,
+			ProjectID:   projectID,
+			ProjectName: projectName,
+			ID:          trigger.ID,
+			Description: trigger.Description,
+			LastUsed:    trigger.LastUsed,
+		}
+		setPipelineOwner(&resource, trigger.Owner)
+		result = append(result, resource)
+	}
+	for _, schedule := range schedules {
+		resource := metrics.PipelineResource{
+			Kind:        metrics.PipelineKindSchedule,
+			ProjectID:   projectID,
+			ProjectName: projectName,
+			ID:          schedule.ID,
+			Description: schedule.Description,
+			NextRunAt:   schedule.NextRunAt,
+			Active:      schedule.Active,
+		}
+		setPipelineOwner(&resource, schedule.Owner)
+		result = append(result, resource)
+	}
+
+	for _, resource := range result {
+		if resource.OwnerInactive() {
+			log.Printf("Pipeline %s %d %q in project %d is owned by %s user %q", resource.Kind, resource.ID, resource.Description, projectID, resource.OwnerState, resource.OwnerUsername)
+		}
+	}
+
+	return result
+}
+
+// setPipelineOwner заполняет данные владельца триггера или расписания
+func setPipelineOwner(resource *metrics.PipelineResource, owner *gitlabapi.User) {
+	if owner == nil {
+		resource.OwnerState = metrics.OwnerStateMissing
+		return
+	}
+
+	resource.OwnerUsername = owner.Username
+	resource.OwnerState = owner.State
+}
@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestTokenScraper_Pipelines(t *testing.T) {
+	lastUsed := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	client := &mockGitLabClient{
+		triggers: map[int][]*gitlabapi.PipelineTrigger{
+			1: {
+				{ID: 1, Description: "deploy", LastUsed: &lastUsed, Owner: &gitlabapi.User{Username: "alice", State: "active"}},
+				{ID: 2, Description: "legacy"},
+			},
+		},
+		schedules: map[int][]*gitlabapi.PipelineSchedule{
+			1: {{ID: 3, Description: "nightly", Active: true, Owner: &gitlabapi.User{Username: "bob", State: "blocked"}}},
+		},
+	}
+
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, []int{1}, nil, WithPipelines(true))
+	scraper.scrape(context.Background())
+
+	got, ok := gaugeValue(t, registry, "gitlab_pipeline_trigger_last_used_timestamp_seconds", map[string]string{
+		"project_name":   "Project1",
+		"description":    "deploy",
+		"owner_username": "alice",
+		"owner_state":    "active",
+	})
+	if !ok || got != float64(lastUsed.Unix()) {
+		t.Errorf("trigger last used = %v (found %v), want %v", got, ok, lastUsed.Unix())
+	}
+
+	tests := []struct {
+		description string
+		ownerState  string
+		want        float64
+	}{
+		{"deploy", "active", 0},
+		{"legacy", "missing", 1},
+		{"nightly", "blocked", 1},
+	}
+	for _, tt := range tests {
+		got, ok := gaugeValue(t, registry, "gitlab_pipeline_owner_inactive", map[string]string{"description": tt.description, "owner_state": tt.ownerState})
+		if !ok {
+			t.Errorf("gitlab_pipeline_owner_inactive{description=%q} not found", tt.description)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("gitlab_pipeline_owner_inactive{description=%q} = %v, want %v", tt.description, got, tt.want)
+		}
+	}
+
+	if got, _ := gaugeValue(t, registry, "gitlab_pipeline_schedule_active", map[string]string{"description": "nightly"}); got != 1 {
+		t.Errorf("gitlab_pipeline_schedule_active = %v, want 1", got)
+	}
+}
+
+func TestTokenScraper_PipelinesDisabled(t *testing.T) {
+	client := &mockGitLabClient{
+		triggers: map[int][]*gitlabapi.PipelineTrigger{1: {{ID: 1, Description: "deploy"}}},
+	}
+
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, []int{1}, nil)
+	scraper.scrape(context.Background())
+
+	if _, ok := gaugeValue(t, registry, "gitlab_pipeline_owner_inactive", map[string]string{}); ok {
+		t.Error("pipeline metrics must not be exported when disabled")
+	}
+}
@@ -0,0 +1,42 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+)
+
+// forEach вызывает fn для каждого индекса из [0, n), используя не более concurrency горутин.
+// После отмены ctx новые вызовы не запускаются. Возвращает управление после завершения
+// всех начатых вызовов.
+func forEach(ctx context.Context, concurrency, n int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	workers := min(concurrency, n)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				fn(i)
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
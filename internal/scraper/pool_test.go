@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		n           int
+	}{
+		{name: "more jobs than workers", concurrency: 3, n: 10},
+		{name: "more workers than jobs", concurrency: 8, n: 2},
+		{name: "no jobs", concurrency: 4, n: 0},
+		{name: "invalid concurrency", concurrency: 0, n: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			seen := make(map[int]int)
+			var inFlight, maxInFlight int32
+
+			forEach(context.Background(), tt.concurrency, tt.n, func(i int) {
+				current := atomic.AddInt32(&inFlight, 1)
+				for {
+					prev := atomic.LoadInt32(&maxInFlight)
+					if current <= prev || atomic.CompareAndSwapInt32(&maxInFlight, prev, current) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&inFlight, -1)
+
+				mu.Lock()
+				seen[i]++
+				mu.Unlock()
+			})
+
+			if len(seen) != tt.n {
+				t.Errorf("processed %d indexes, want %d", len(seen), tt.n)
+			}
+			for i, count := range seen {
+				if count != 1 {
+					t.Errorf("index %d processed %d times", i, count)
+				}
+			}
+			if limit := int32(max(tt.concurrency, 1)); maxInFlight > limit {
+				t.Errorf("max in-flight = %d, want <= %d", maxInFlight, limit)
+			}
+		})
+	}
+}
+
+func TestForEach_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls int32
+	forEach(ctx, 1, 10, func(i int) {
+		atomic.AddInt32(&calls, 1)
+		// После первого вызова новые задачи раздаваться не должны
+		cancel()
+	})
+
+	if calls != 1 {
+		t.Errorf("fn called %d times after cancellation, want 1", calls)
+	}
+}
@@ -0,0 +1,197 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// probeTimeoutOffset - запас до таймаута scrape Prometheus, чтобы ответ успел дойти до него
+const probeTimeoutOffset = 500 * time.Millisecond
+
+// ProbeTarget - цель пробы: проект, группа или пользователь
+type ProbeTarget struct {
+	Kind string
+	ID   int
+}
+
+func (t ProbeTarget) String() string {
+	return fmt.Sprintf("%s:%d", t.Kind, t.ID)
+}
+
+// ParseProbeTarget разбирает цель вида "project:123", "group:45" или "user:67"
+func ParseProbeTarget(value string) (ProbeTarget, error) {
+	kind, idStr, ok := strings.Cut(value, ":")
+	if !ok {
+		return ProbeTarget{}, fmt.Errorf("invalid target %q, expected <kind>:<id>", value)
+	}
+
+	switch kind {
+	case metrics.KindProject, metrics.KindGroup, metrics.KindUser:
+	default:
+		return ProbeTarget{}, fmt.Errorf("invalid target kind %q, expected project, group or user", kind)
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return ProbeTarget{}, fmt.Errorf("invalid target ID %q", idStr)
+	}
+
+	return ProbeTarget{Kind: kind, ID: id}, nil
+}
+
+// Probe синхронно собирает токены и ресурсы одной цели и передает снапшот в metricsHandler.
+// Обнаружение и режим администратора не применяются: проба касается только указанной цели.
+// Возвращает ошибку, если хотя бы один запрос к GitLab не удался или истек таймаут.
+func (s *TokenScraper) Probe(ctx context.Context, target ProbeTarget, metricsHandler *metrics.Handler) error {
+	probe := s.probeScraper(metricsHandler)
+	now := time.Now()
+
+	var snapshot metrics.Snapshot
+	switch target.Kind {
+	case metrics.KindProject:
+		projectIDs := []int{target.ID}
+		snapshot.Tokens = probe.scrapeProject(ctx, target.ID, now)
+		if probe.deployTokens {
+			snapshot.DeployTokens = probe.scrapeDeployTokens(ctx, projectIDs, nil, now)
+		}
+		if probe.pipelines {
+			snapshot.Pipelines = probe.scrapePipelines(ctx, projectIDs)
+		}
+		if probe.keys {
+			snapshot.Keys = probe.scrapeKeys(ctx, projectIDs, now)
+		}
+		if probe.runners {
+			snapshot.Runners = probe.scrapeRunners(ctx, projectIDs, nil, now)
+		}
+	case metrics.KindGroup:
+		groupIDs := []int{target.ID}
+		snapshot.Tokens = probe.scrapeGroup(ctx, target.ID, now)
+		if probe.deployTokens {
+			snapshot.DeployTokens = probe.scrapeDeployTokens(ctx, nil, groupIDs, now)
+		}
+		if probe.runners {
+			snapshot.Runners = probe.scrapeRunners(ctx, nil, groupIDs, now)
+		}
+	case metrics.KindUser:
+		tokens, err := probe.gitlabClient.GetUserAccessTokensForUser(ctx, target.ID)
+		if err != nil {
+			log.Printf("Failed to get user access tokens for user %d: %v", target.ID, err)
+			metricsHandler.IncrementScrapeErrors()
+			break
+		}
+		snapshot.Tokens = probe.newUserTokens(ctx, tokens, now)
+	default:
+		return fmt.Errorf("unsupported target kind %q", target.Kind)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("probe of %s aborted: %w", target, err)
+	}
+
+	probe.completeSnapshot(&snapshot)
+	metricsHandler.Update(snapshot)
+	metricsHandler.SetLastScrapeTime(now)
+
+	if failures := metricsHandler.ScrapeErrors(); failures > 0 {
+		return fmt.Errorf("probe of %s failed with %d errors", target, failures)
+	}
+	return nil
+}
+
+// probeScraper возвращает скрейпер с текущими параметрами s, который пишет метрики в metricsHandler
+func (s *TokenScraper) probeScraper(metricsHandler *metrics.Handler) *TokenScraper {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &TokenScraper{
+		gitlabClient:       s.gitlabClient,
+		metrics:            metricsHandler,
+		neverExpiresPolicy: s.neverExpiresPolicy,
+		unusedThreshold:    s.unusedThreshold,
+		policy:             s.policy,
+		concurrency:        s.concurrency,
+		deployTokens:       s.deployTokens,
+		pipelines:          s.pipelines,
+		keys:               s.keys,
+		runners:            s.runners,
+		runnerTokenWarning: s.runnerTokenWarning,
+	}
+}
+
+// ProbeHandler возвращает HTTP-обработчик /probe?target=<kind>:<id> в стиле blackbox_exporter.
+// Каждая проба выполняется синхронно и отдает метрики цели из нового реестра вместе с
+// probe_success и probe_duration_seconds. Длительность пробы ограничена timeout и
+// таймаутом scrape из заголовка X-Prometheus-Scrape-Timeout-Seconds. Опции opts
+// применяются к Handler метрик пробы.
+func (s *TokenScraper) ProbeHandler(timeout time.Duration, opts ...metrics.Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target, err := ParseProbeTarget(r.URL.Query().Get("target"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		if timeout := probeTimeout(r, timeout); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		registry := prometheus.NewRegistry()
+		probeSuccess := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_success",
+			Help: "Whether the probe of the target succeeded (1 - yes, 0 - no)",
+		})
+		probeDuration := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_duration_seconds",
+			Help: "Duration of the probe of the target",
+		})
+		registry.MustRegister(probeSuccess, probeDuration)
+		// Копия opts, чтобы параллельные пробы не писали в общий массив
+		metricsHandler := metrics.NewHandler(append(slices.Clone(opts), metrics.WithRegistry(registry))...)
+
+		start := time.Now()
+		if err := s.Probe(ctx, target, metricsHandler); err != nil {
+			log.Printf("Probe failed: %v", err)
+		} else {
+			probeSuccess.Set(1)
+		}
+		probeDuration.Set(time.Since(start).Seconds())
+
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
+
+// probeTimeout возвращает таймаут пробы: не больше timeout и таймаута scrape Prometheus за вычетом запаса
+func probeTimeout(r *http.Request, timeout time.Duration) time.Duration {
+	value := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if value == "" {
+		return timeout
+	}
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return timeout
+	}
+
+	scrapeTimeout := time.Duration(seconds*float64(time.Second)) - probeTimeoutOffset
+	if scrapeTimeout <= 0 {
+		return timeout
+	}
+	if timeout <= 0 || scrapeTimeout < timeout {
+		return scrapeTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,191 @@
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+func TestParseProbeTarget(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ProbeTarget
+		wantErr bool
+	}{
+		{value: "project:123", want: ProbeTarget{Kind: metrics.KindProject, ID: 123}},
+		{value: "group:5", want: ProbeTarget{Kind: metrics.KindGroup, ID: 5}},
+		{value: "user:42", want: ProbeTarget{Kind: metrics.KindUser, ID: 42}},
+		{value: "", wantErr: true},
+		{value: "project", wantErr: true},
+		{value: "runner:1", wantErr: true},
+		{value: "project:abc", wantErr: true},
+		{value: "project:0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseProbeTarget(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProbeTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseProbeTarget() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// probe выполняет запрос к /probe и возвращает код ответа и текст метрик
+func probe(t *testing.T, handler http.Handler, target string, header http.Header) (int, string) {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodGet, "/probe?target="+target, nil)
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	body, err := io.ReadAll(recorder.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return recorder.Code, string(body)
+}
+
+func TestTokenScraper_ProbeHandler(t *testing.T) {
+	expiresAt := isoTime(time.Now().Add(24 * time.Hour))
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{
+			1: {projectToken(11, "deploy", expiresAt)},
+			2: {projectToken(12, "other", expiresAt)},
+		},
+		groupTokens: map[int][]*gitlabapi.GroupAccessToken{
+			5: {groupToken(21, "group-bot", expiresAt)},
+		},
+		userTokens: []*gitlabapi.PersonalAccessToken{
+			{ID: 31, Name: "mine", UserID: 42, ExpiresAt: expiresAt},
+			{ID: 32, Name: "foreign", UserID: 43, ExpiresAt: expiresAt},
+		},
+	}
+
+	// Проба не зависит от целей скрейпера и не изменяет его метрики
+	mainHandler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, mainHandler, []int{2}, nil, WithDiscovery(DiscoveryConfig{Interval: time.Hour}), WithAdminMode())
+	handler := scraper.ProbeHandler(time.Minute)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		want       []string
+		notWant    []string
+	}{
+		{
+			name:       "project",
+			target:     "project:1",
+			wantStatus: http.StatusOK,
+			want:       []string{"probe_success 1", "probe_duration_seconds", `token_id="11"`, "gitlab_tokens_total 1"},
+			notWant:    []string{`token_id="12"`, `token_id="31"`},
+		},
+		{
+			name:       "group",
+			target:     "group:5",
+			wantStatus: http.StatusOK,
+			want:       []string{"probe_success 1", `token_id="21"`, "gitlab_group_tokens_total 1"},
+		},
+		{
+			name:       "user",
+			target:     "user:42",
+			wantStatus: http.StatusOK,
+			want:       []string{"probe_success 1", `token_id="31"`, "gitlab_user_tokens_total 1"},
+			notWant:    []string{`token_id="32"`},
+		},
+		{
+			name:       "invalid target",
+			target:     "project:x",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := probe(t, handler, tt.target, nil)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", status, tt.wantStatus, body)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("expected %q in probe output:\n%s", want, body)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(body, notWant) {
+					t.Errorf("unexpected %q in probe output", notWant)
+				}
+			}
+		})
+	}
+
+	if got, _ := gaugeValue(t, registry, "gitlab_tokens_total", map[string]string{}); got != 0 {
+		t.Errorf("gitlab_tokens_total of the scraper = %v, want 0", got)
+	}
+}
+
+func TestTokenScraper_ProbeHandler_Timeout(t *testing.T) {
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {projectToken(1, "slow", nil)}},
+		delay:         time.Second,
+	}
+	handler, _ := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, nil, nil)
+
+	// Таймаут scrape Prometheus короче таймаута пробы, проба прерывается по нему
+	start := time.Now()
+	header := http.Header{"X-Prometheus-Scrape-Timeout-Seconds": {"0.6"}}
+	status, body := probe(t, scraper.ProbeHandler(time.Minute), "project:1", header)
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("probe took %v, want it to be cut by the scrape timeout", elapsed)
+	}
+	if status != http.StatusOK || !strings.Contains(body, "probe_success 0") {
+		t.Errorf("status = %d, want 200 with probe_success 0:\n%s", status, body)
+	}
+	if strings.Contains(body, `token_name="slow"`) {
+		t.Error("aborted probe must not export target metrics")
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{name: "no header", timeout: 30 * time.Second, want: 30 * time.Second},
+		{name: "shorter scrape timeout", header: "10", timeout: 30 * time.Second, want: 9500 * time.Millisecond},
+		{name: "longer scrape timeout", header: "60", timeout: 30 * time.Second, want: 30 * time.Second},
+		{name: "no probe timeout", header: "10", timeout: 0, want: 9500 * time.Millisecond},
+		{name: "invalid header", header: "soon", timeout: 30 * time.Second, want: 30 * time.Second},
+		{name: "scrape timeout below offset", header: "0.2", timeout: 30 * time.Second, want: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/probe", nil)
+			if tt.header != "" {
+				request.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", tt.header)
+			}
+			if got := probeTimeout(request, tt.timeout); got != tt.want {
+				t.Errorf("probeTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"log"
+	"time"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/gitlab"
+	"ru/mvideo/com/gitlab/token-exporter/internal/policy"
+	"ru/mvideo/com/gitlab/token-exporter/internal/rotation"
+)
+
+// ReloadConfig - параметры скрейпера, которые заменяются без перезапуска
+type ReloadConfig struct {
+	// Client - клиент GitLab с новыми адресом и учетными данными
+	Client     gitlab.GitLabClientInterface
+	ProjectIDs []int
+	GroupIDs   []int
+	// Interval - новый период скрейпинга (0 - не менять)
+	Interval time.Duration
+	// Policy - новая политика токенов (nil - политика выключена)
+	Policy *policy.Policy
+	// Rotator - новый ротатор токенов (nil - ротация выключена)
+	Rotator *rotation.Rotator
+}
+
+// Reload передает новые параметры запущенному скрейперу. Они применяются целиком
+// между проходами, после чего сразу выполняется новый проход: метрики удаленных
+// целей пропадают вместе со старым снапшотом. Если предыдущая перезагрузка
+// еще не применена, она заменяется новой.
+func (s *TokenScraper) Reload(config ReloadConfig) {
+	for {
+		select {
+		case s.reloads <- config:
+			return
+		default:
+		}
+
+		select {
+		case <-s.reloads:
+		default:
+		}
+	}
+}
+
+// applyReload заменяет параметры скрейпера. Вызывается только из цикла Start.
+func (s *TokenScraper) applyReload(config ReloadConfig) {
+	s.mu.Lock()
+	s.gitlabClient = config.Client
+	s.projectIDs = config.ProjectIDs
+	s.groupIDs = config.GroupIDs
+	s.policy = config.Policy
+	s.rotator = config.Rotator
+	s.mu.Unlock()
+
+	// Новый клиент может использовать другой токен и другой инстанс GitLab
+	s.credential = nil
+	s.selfUnsupported = false
+
+	// Результаты обнаружения в удаленных группах больше не нужны
+	groups := newIDSet(config.GroupIDs)
+	s.discovered.mu.Lock()
+	for groupID := range s.discovered.groups {
+		if !groups.seen[groupID] {
+			delete(s.discovered.groups, groupID)
+		}
+	}
+	s.discovered.mu.Unlock()
+
+	log.Printf("Token scraper configuration reloaded: %d projects, %d groups", len(config.ProjectIDs), len(config.GroupIDs))
+}
@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/policy"
+)
+
+func TestTokenScraper_Reload(t *testing.T) {
+	handler, registry := newTestHandler(t)
+
+	oldClient := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {projectToken(1, "old-token", nil)}},
+	}
+	newClient := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{2: {projectToken(2, "new-token", nil)}},
+	}
+	scraper := NewTokenScraper(oldClient, handler, []int{1}, nil, WithDiscovery(DiscoveryConfig{Interval: time.Hour}))
+	scraper.discovered.groups[5] = groupTargets{projectIDs: []int{1}}
+
+	tokenPolicy := &policy.Policy{Rules: []policy.Rule{{Name: "expiry", Type: policy.RuleRequireExpiry}}}
+	if err := tokenPolicy.Compile(); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scraper.Start(ctx, time.Hour)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitForMetric(t, func() bool {
+		_, ok := gaugeValue(t, registry, "gitlab_token_never_expires", map[string]string{"token_name": "old-token"})
+		return ok
+	})
+
+	// Повторные вызовы до применения заменяют друг друга, применяется последний
+	scraper.Reload(ReloadConfig{Client: oldClient, ProjectIDs: []int{1}})
+	scraper.Reload(ReloadConfig{Client: newClient, ProjectIDs: []int{2}, Policy: tokenPolicy})
+
+	waitForMetric(t, func() bool {
+		_, ok := gaugeValue(t, registry, "gitlab_token_never_expires", map[string]string{"token_name": "new-token"})
+		return ok
+	})
+
+	// Метрики удаленного проекта пропадают после первого прохода с новой конфигурацией
+	if _, ok := gaugeValue(t, registry, "gitlab_token_never_expires", map[string]string{"token_name": "old-token"}); ok {
+		t.Error("metrics of the removed project must be cleaned up after reload")
+	}
+	if _, ok := gaugeValue(t, registry, "gitlab_token_policy_violation", map[string]string{"rule": "expiry", "token_name": "new-token"}); !ok {
+		t.Error("reloaded policy must be evaluated")
+	}
+	// Группа 5 удалена из конфигурации вместе с результатами ее обнаружения
+	if projectIDs, _ := scraper.targets(); len(projectIDs) != 1 || projectIDs[0] != 2 {
+		t.Errorf("targets after reload = %v, want [2]", projectIDs)
+	}
+}
+
+// waitForMetric ждет выполнения условия, проверяемого по метрикам работающего скрейпера
+func waitForMetric(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
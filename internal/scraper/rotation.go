@@ -0,0 +1,41 @@
+package scraper
+
+import (
+	"context"
+	"log"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+	"ru/mvideo/com/gitlab/token-exporter/internal/rotation"
+)
+
+// WithRotator включает ротацию истекающих токенов проектов и групп после каждого прохода
+func WithRotator(r *rotation.Rotator) Option {
+	return func(s *TokenScraper) {
+		s.rotator = r
+	}
+}
+
+// rotate передает собранные токены ротатору. Токен экспортера не ротируется:
+// GitLab отзывает старый токен, и скрейпер потерял бы доступ до перезапуска.
+func (s *TokenScraper) rotate(ctx context.Context, tokens []metrics.Token) {
+	if s.rotator == nil {
+		return
+	}
+
+	// Без ID токена экспортера его нельзя исключить из ротации, поэтому ротация не выполняется
+	if s.credential == nil || s.credential.TokenID == 0 {
+		log.Println("Skipping token rotation: the exporter token is unknown")
+		return
+	}
+
+	candidates := make([]metrics.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if token.Labels.TokenID == s.credential.TokenID {
+			log.Printf("Skipping rotation check of %s token %q: it is the exporter token", token.Labels.OwnerKind, token.Labels.TokenName)
+			continue
+		}
+		candidates = append(candidates, token)
+	}
+
+	s.rotator.Run(ctx, s.gitlabClient, candidates, s.metrics)
+}
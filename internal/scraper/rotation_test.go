@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/rotation"
+)
+
+// newTestRotation возвращает правила ротации всех истекающих токенов в файлы каталога dir
+func newTestRotation(t *testing.T, dir string) *rotation.Config {
+	t.Helper()
+	config := &rotation.Config{
+		Rules: []rotation.Rule{{Name: "ci", DaysBeforeExpiry: 7, ExpiresInDays: 30, Sink: "file"}},
+		Sinks: []rotation.SinkConfig{{Name: "file", Type: rotation.SinkFile, Path: filepath.Join(dir, "{{.TokenID}}")}},
+	}
+	if err := config.Compile(); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	return config
+}
+
+func TestTokenScraper_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	config := newTestRotation(t, dir)
+
+	expiresAt := isoTime(time.Now().Add(48 * time.Hour))
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{
+			1: {projectToken(11, "deploy", expiresAt), projectToken(12, "exporter", expiresAt)},
+		},
+		// Токен экспортера подходит под правило, но не ротируется
+		selfToken: &gitlabapi.PersonalAccessToken{ID: 12, Name: "exporter", Scopes: []string{"api"}},
+	}
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, []int{1}, nil, WithRotator(rotation.NewRotator(config, rotation.WithMaxPerRun(5))))
+
+	scraper.scrape(context.Background())
+
+	if want := []int{11}; !reflect.DeepEqual(client.rotated, want) {
+		t.Errorf("rotated tokens = %v, want %v", client.rotated, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "111")); err != nil {
+		t.Errorf("expected the new secret to be delivered: %v", err)
+	}
+	if got := counterValue(t, registry, "gitlab_token_rotations_total"); got != 1 {
+		t.Errorf("gitlab_token_rotations_total = %v, want 1", got)
+	}
+}
+
+func TestTokenScraper_RotationUnknownCredential(t *testing.T) {
+	expiresAt := isoTime(time.Now().Add(48 * time.Hour))
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{
+			1: {projectToken(11, "deploy", expiresAt), projectToken(12, "exporter", expiresAt)},
+		},
+		// GitLab не сообщает, какой из токенов принадлежит экспортеру
+		selfErr: gitlabapi.ErrNotFound,
+	}
+	handler, registry := newTestHandler(t)
+	config := newTestRotation(t, t.TempDir())
+	scraper := NewTokenScraper(client, handler, []int{1}, nil, WithRotator(rotation.NewRotator(config, rotation.WithMaxPerRun(5))))
+
+	scraper.scrape(context.Background())
+
+	if len(client.rotated) != 0 {
+		t.Errorf("rotated tokens = %v, want none", client.rotated)
+	}
+	if got := counterValue(t, registry, "gitlab_token_rotations_total"); got != 0 {
+		t.Errorf("gitlab_token_rotations_total = %v, want 0", got)
+	}
+}
@@ -0,0 +1,117 @@
+package scraper
+
+import (
+	"context"
+	"log"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+)
+
+// WithRunners включает сбор раннеров. Токен раннера, истекающий раньше чем через
+// tokenWarning, отмечается метрикой gitlab_runner_token_expires_soon.
+func WithRunners(enabled bool, tokenWarning time.Duration) Option {
+	return func(s *TokenScraper) {
+		s.runners = enabled
+		s.runnerTokenWarning = tokenWarning
+	}
+}
+
+// scrapeRunners собирает раннеры отслеживаемых проектов и групп, а в режиме администратора - всего инстанса
+func (s *TokenScraper) scrapeRunners(ctx context.Context, projectIDs, groupIDs []int, now time.Time) []metrics.Runner {
+	var runners []*gitlabapi.Runner
+	if s.adminMode {
+		all, err := s.gitlabClient.GetAllRunners(ctx)
+		if err != nil {
+			log.Printf("Failed to get instance runners: %v", err)
+			s.metrics.IncrementScrapeErrors()
+			return nil
+		}
+		runners = all
+	} else {
+		runners = s.listOwnerRunners(ctx, projectIDs, groupIDs)
+	}
+
+	result := make([]metrics.Runner, len(runners))
+	forEach(ctx, s.concurrency, len(runners), func(i int) {
+		result[i] = s.scrapeRunner(ctx, runners[i], now)
+	})
+
+	for _, runner := range result {
+		log.Printf("Runner: %d %q, Type: %s, Status: %s, Token expires: %s", runner.ID, runner.Description, runner.Type, runner.Status, formatDeployExpiry(runner.TokenExpiresAt, now))
+		if runner.TokenExpiresSoon {
+			log.Printf("Warning: authentication token of runner %d %q expires within %v", runner.ID, runner.Description, s.runnerTokenWarning)
+		}
+	}
+	return result
+}
+
+// listOwnerRunners собирает раннеры проектов и групп без повторов: групповой раннер
+// виден во всех подгруппах, а раннер проекта может быть подключен к нескольким проектам
+func (s *TokenScraper) listOwnerRunners(ctx context.Context, projectIDs, groupIDs []int) []*gitlabapi.Runner {
+	projectResults := make([][]*gitlabapi.Runner, len(projectIDs))
+	forEach(ctx, s.concurrency, len(projectIDs), func(i int) {
+		runners, err := s.gitlabClient.GetProjectRunners(ctx, projectIDs[i])
+		if err != nil {
+			log.Printf("Failed to get runners for project %d: %v", projectIDs[i], err)
+			s.metrics.IncrementScrapeErrors()
+			return
+		}
+		projectResults[i] = runners
+	})
+
+	groupResults := make([][]*gitlabapi.Runner, len(groupIDs))
+	forEach(ctx, s.concurrency, len(groupIDs), func(i int) {
+		runners, err := s.gitlabClient.GetGroupRunners(ctx, groupIDs[i])
+		if err != nil {
+			log.Printf("Failed to get runners for group %d: %v", groupIDs[i], err)
+			s.metrics.IncrementScrapeErrors()
+			return
+		}
+		groupResults[i] = runners
+	})
+
+	seen := make(map[int]bool)
+	var result []*gitlabapi.Runner
+	for _, runners := range append(projectResults, groupResults...) {
+		for _, runner := range runners {
+			if !seen[runner.ID] {
+				seen[runner.ID] = true
+				result = append(result, runner)
+			}
+		}
+	}
+	return result
+}
+
+// scrapeRunner дополняет раннер из списка временем последнего контакта и сроком действия токена.
+// Если подробности получить не удалось, раннер экспортируется с данными из списка.
+func (s *TokenScraper) scrapeRunner(ctx context.Context, runner *gitlabapi.Runner, now time.Time) metrics.Runner {
+	result := metrics.Runner{
+		ID:             runner.ID,
+		Description:    runner.Description,
+		Type:           runner.RunnerType,
+		Status:         runner.Status,
+		Online:         runner.Online,
+		Paused:         runner.Paused,
+		TokenExpiresAt: runner.TokenExpiresAt,
+	}
+
+	details, err := s.gitlabClient.GetRunnerDetails(ctx, runner.ID)
+	if err != nil {
+		log.Printf("Failed to get details for runner %d: %v", runner.ID, err)
+		s.metrics.IncrementScrapeErrors()
+	} else {
+		result.ContactedAt = details.ContactedAt
+		if details.TokenExpiresAt != nil {
+			result.TokenExpiresAt = details.TokenExpiresAt
+		}
+	}
+
+	if result.TokenExpiresAt != nil {
+		result.TokenExpiresSoon = result.TokenExpiresAt.Sub(now) < s.runnerTokenWarning
+	}
+	return result
+}
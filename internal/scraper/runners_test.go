@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/gitlab"
+)
+
+// runnerDetails возвращает подробности раннера с временем контакта и сроком действия токена
+func runnerDetails(id int, contactedAt, tokenExpiresAt *time.Time) *gitlab.RunnerDetails {
+	details := &gitlab.RunnerDetails{TokenExpiresAt: tokenExpiresAt}
+	details.ID = id
+	details.ContactedAt = contactedAt
+	return details
+}
+
+func TestTokenScraper_Runners(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	contacted := now.Add(-time.Minute)
+	soon := now.Add(3 * 24 * time.Hour)
+	later := now.Add(60 * 24 * time.Hour)
+
+	client := &mockGitLabClient{
+		runners: map[string][]*gitlabapi.Runner{
+			"project/1": {{ID: 10, Description: "shared-docker", RunnerType: "project_type", Online: true, Status: "online"}},
+			"project/2": {{ID: 10, Description: "shared-docker", RunnerType: "project_type", Online: true, Status: "online"}},
+			"group/5": {
+				{ID: 20, Description: "group-k8s", RunnerType: "group_type", Status: "stale"},
+				{ID: 30, Description: "no-details", RunnerType: "group_type", Status: "offline"},
+			},
+		},
+		runnerInfo: map[int]*gitlab.RunnerDetails{
+			10: runnerDetails(10, &contacted, &soon),
+			20: runnerDetails(20, nil, &later),
+		},
+	}
+
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, []int{1, 2}, []int{5}, WithRunners(true, 14*24*time.Hour))
+	scraper.scrape(context.Background())
+
+	// Раннер 10 подключен к двум проектам, но экспортируется один раз
+	if got, _ := gaugeValue(t, registry, "gitlab_runners_total", map[string]string{"runner_type": "project_type"}); got != 1 {
+		t.Errorf("gitlab_runners_total{runner_type=project_type} = %v, want 1", got)
+	}
+
+	tests := []struct {
+		metric string
+		runner string
+		want   float64
+	}{
+		{"gitlab_runner_token_expires_soon", "shared-docker", 1},
+		{"gitlab_runner_token_expires_soon", "group-k8s", 0},
+		{"gitlab_runner_token_expires_soon", "no-details", 0},
+		{"gitlab_runner_online", "shared-docker", 1},
+		{"gitlab_runner_stale", "group-k8s", 1},
+		{"gitlab_runner_last_contact_timestamp_seconds", "shared-docker", float64(contacted.Unix())},
+		{"gitlab_runner_token_expiry_timestamp_seconds", "group-k8s", float64(later.Unix())},
+	}
+	for _, tt := range tests {
+		got, ok := gaugeValue(t, registry, tt.metric, map[string]string{"description": tt.runner})
+		if !ok {
+			t.Errorf("%s{description=%q} not found", tt.metric, tt.runner)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s{description=%q} = %v, want %v", tt.metric, tt.runner, got, tt.want)
+		}
+	}
+
+	// Ошибка получения подробностей учитывается, но раннер не пропадает из метрик
+	if got := counterValue(t, registry, "gitlab_token_scrape_errors_total"); got != 1 {
+		t.Errorf("gitlab_token_scrape_errors_total = %v, want 1", got)
+	}
+}
+
+func TestTokenScraper_AdminModeRunners(t *testing.T) {
+	client := &mockGitLabClient{
+		runners: map[string][]*gitlabapi.Runner{
+			"project/1": {{ID: 10, Description: "project-runner", RunnerType: "project_type"}},
+		},
+		allRunners: []*gitlabapi.Runner{
+			{ID: 10, Description: "project-runner", RunnerType: "project_type"},
+			{ID: 40, Description: "instance-runner", RunnerType: "instance_type"},
+		},
+		runnerInfo: map[int]*gitlab.RunnerDetails{
+			10: runnerDetails(10, nil, nil),
+			40: runnerDetails(40, nil, nil),
+		},
+	}
+
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, []int{1}, nil,
+		WithDiscovery(DiscoveryConfig{}), WithAdminMode(), WithRunners(true, time.Hour))
+	scraper.scrape(context.Background())
+
+	if _, ok := gaugeValue(t, registry, "gitlab_runner_online", map[string]string{"description": "instance-runner"}); !ok {
+		t.Error("instance runner must be exported in admin mode")
+	}
+}
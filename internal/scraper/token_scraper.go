@@ -2,40 +2,115 @@ package scraper
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strconv"
+	"sync"
 	"time"
 
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
 	"ru/mvideo/com/gitlab/token-exporter/internal/gitlab"
 	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+	"ru/mvideo/com/gitlab/token-exporter/internal/policy"
+	"ru/mvideo/com/gitlab/token-exporter/internal/rotation"
+)
+
+// NeverExpiresPolicy определяет, как обрабатываются токены без даты истечения
+type NeverExpiresPolicy string
+
+const (
+	// NeverExpiresAllow - бессрочные токены только экспортируются
+	NeverExpiresAllow NeverExpiresPolicy = "allow"
+	// NeverExpiresViolation - бессрочные токены дополнительно отмечаются как нарушение
+	NeverExpiresViolation NeverExpiresPolicy = "violation"
 )
 
+// Option - функциональная опция для настройки TokenScraper
+type Option func(*TokenScraper)
+
+// WithNeverExpiresPolicy задает политику для токенов без даты истечения
+func WithNeverExpiresPolicy(policy NeverExpiresPolicy) Option {
+	return func(s *TokenScraper) {
+		s.neverExpiresPolicy = policy
+	}
+}
+
+// WithConcurrency задает количество параллельных запросов к GitLab во время скрейпинга
+func WithConcurrency(concurrency int) Option {
+	return func(s *TokenScraper) {
+		s.concurrency = concurrency
+	}
+}
+
+// WithUnusedThreshold включает обнаружение токенов, не использовавшихся дольше threshold (0 - выключено)
+func WithUnusedThreshold(threshold time.Duration) Option {
+	return func(s *TokenScraper) {
+		s.unusedThreshold = threshold
+	}
+}
+
+// WithPolicy включает проверку токенов правилами политики после каждого прохода
+func WithPolicy(p *policy.Policy) Option {
+	return func(s *TokenScraper) {
+		s.policy = p
+	}
+}
+
+// WithScrapeTimeout ограничивает время одного прохода скрейпера (0 - без ограничений)
+func WithScrapeTimeout(timeout time.Duration) Option {
+	return func(s *TokenScraper) {
+		s.scrapeTimeout = timeout
+	}
+}
+
 type TokenScraper struct {
-	gitlabClient         gitlab.GitLabClientInterface
-	metrics              *metrics.Handler
-	projectIDs           []int
-	groupIDs             []int
-	knownTokens          map[string]bool // для отслеживания токенов между скрейпингами
-	knownUserTokens      map[string]bool // для отслеживания пользовательских токенов между скрейпингами
-	knownGroupTokens     map[string]bool // для отслеживания групповых токенов между скрейпингами
-	currentProjectTokens map[string]bool
-	currentUserTokens    map[string]bool
-	currentGroupTokens   map[string]bool
-}
-
-func NewTokenScraper(gitlabClient gitlab.GitLabClientInterface, metrics *metrics.Handler, projectIDs []int, groupIDs []int) *TokenScraper {
-	return &TokenScraper{
-		gitlabClient:         gitlabClient,
-		metrics:              metrics,
-		projectIDs:           projectIDs,
-		groupIDs:             groupIDs,
-		knownTokens:          make(map[string]bool),
-		knownUserTokens:      make(map[string]bool),
-		knownGroupTokens:     make(map[string]bool),
-		currentProjectTokens: make(map[string]bool),
-		currentUserTokens:    make(map[string]bool),
-		currentGroupTokens:   make(map[string]bool),
+	gitlabClient       gitlab.GitLabClientInterface
+	metrics            *metrics.Handler
+	projectIDs         []int
+	groupIDs           []int
+	neverExpiresPolicy NeverExpiresPolicy
+	unusedThreshold    time.Duration
+	policy             *policy.Policy
+	rotator            *rotation.Rotator
+	concurrency        int
+	scrapeTimeout      time.Duration
+	discovery          *DiscoveryConfig
+	discovered         discoveredTargets
+	adminMode          bool
+	deployTokens       bool
+	pipelines          bool
+	keys               bool
+	runners            bool
+	runnerTokenWarning time.Duration
+	reloads            chan ReloadConfig
+	// credential - последние сведения о токене экспортера, selfUnsupported - GitLab
+	// не поддерживает их получение
+	credential      *metrics.Credential
+	selfUnsupported bool
+	// botUserIDs - ID ботов проектов и групп из последнего успешного запроса (режим администратора)
+	botUserIDs map[int]bool
+	// mu защищает параметры, заменяемые перезагрузкой, от чтения пробами из других горутин.
+	// Цикл Start сам применяет перезагрузку, поэтому читает их без блокировки.
+	mu sync.RWMutex
+}
+
+func NewTokenScraper(gitlabClient gitlab.GitLabClientInterface, metricsHandler *metrics.Handler, projectIDs []int, groupIDs []int, opts ...Option) *TokenScraper {
+	s := &TokenScraper{
+		gitlabClient:       gitlabClient,
+		metrics:            metricsHandler,
+		projectIDs:         projectIDs,
+		groupIDs:           groupIDs,
+		neverExpiresPolicy: NeverExpiresAllow,
+		concurrency:        1,
+		discovered:         discoveredTargets{groups: make(map[int]groupTargets)},
+		reloads:            make(chan ReloadConfig, 1),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func (s *TokenScraper) Start(ctx context.Context, interval time.Duration) {
@@ -44,118 +119,205 @@ func (s *TokenScraper) Start(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	s.scrape()
+	// Без обнаружения канал остается nil и никогда не срабатывает
+	var discoveryTick <-chan time.Time
+	if s.discovery != nil {
+		log.Printf("Target discovery enabled with interval: %v", s.discovery.Interval)
+		discoveryTicker := time.NewTicker(s.discovery.Interval)
+		defer discoveryTicker.Stop()
+		discoveryTick = discoveryTicker.C
+
+		s.discover(ctx)
+	}
+
+	s.scrape(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("Token scraper stopped")
 			return
+		case <-discoveryTick:
+			s.discover(ctx)
 		case <-ticker.C:
-			s.scrape()
+			s.scrape(ctx)
+		case config := <-s.reloads:
+			if config.Interval > 0 {
+				ticker.Reset(config.Interval)
+			}
+			s.applyReload(config)
+			if s.discovery != nil {
+				s.discover(ctx)
+			}
+			s.scrape(ctx)
 		}
 	}
 }
 
-func (s *TokenScraper) scrape() {
+func (s *TokenScraper) scrape(ctx context.Context) {
 	start := time.Now()
 	log.Println("Starting token scrape...")
 
+	if s.scrapeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.scrapeTimeout)
+		defer cancel()
+	}
+
 	now := time.Now()
+	projectIDs, groupIDs := s.targets()
 
-	totalProjectTokens := s.scrapeProjectTokens(now)
+	credential := s.checkCredential(ctx)
 
-	totalUserTokens := s.scrapeUserTokens(now)
+	projectTokens := s.scrapeProjectTokens(ctx, projectIDs, now)
 
-	totalGroupTokens := s.scrapeGroupTokens(now)
+	groupTokens := s.scrapeGroupTokens(ctx, groupIDs, now)
 
-	for knownToken := range s.knownTokens {
-		if !s.currentProjectTokens[knownToken] {
-			s.metrics.DeleteTokenMetrics(knownToken)
-			log.Printf("Removed metrics for deleted project token: %s", knownToken)
-		}
+	// В режиме администратора список личных токенов включает токены ботов проектов и групп,
+	// которые учитываются как токены проектов и групп, даже если их проект не удалось обойти
+	var botUserIDs, botTokenIDs map[int]bool
+	if s.adminMode {
+		botUserIDs = s.projectBotUserIDs(ctx)
+		botTokenIDs = tokenIDs(projectTokens, groupTokens)
 	}
+	userTokens := s.scrapeUserTokens(ctx, botUserIDs, botTokenIDs, now)
 
-	for knownUserToken := range s.knownUserTokens {
-		if !s.currentUserTokens[knownUserToken] {
-			s.metrics.DeleteUserTokenMetrics(knownUserToken)
-			log.Printf("Removed metrics for deleted user token: %s", knownUserToken)
-		}
+	var deployTokens []metrics.DeployToken
+	if s.deployTokens {
+		deployTokens = s.scrapeDeployTokens(ctx, projectIDs, groupIDs, now)
 	}
 
-	for knownGroupToken := range s.knownGroupTokens {
-		if !s.currentGroupTokens[knownGroupToken] {
-			s.metrics.DeleteGroupTokenMetrics(knownGroupToken)
-			log.Printf("Removed metrics for deleted group token: %s", knownGroupToken)
-		}
+	var pipelines []metrics.PipelineResource
+	if s.pipelines {
+		pipelines = s.scrapePipelines(ctx, projectIDs)
+	}
+
+	var keys []metrics.Key
+	if s.keys {
+		keys = s.scrapeKeys(ctx, projectIDs, now)
+	}
+
+	var runners []metrics.Runner
+	if s.runners {
+		runners = s.scrapeRunners(ctx, projectIDs, groupIDs, now)
+	}
+
+	// Прерванный проход дает неполный снапшот, поэтому метрики остаются от предыдущего
+	if err := ctx.Err(); err != nil {
+		log.Printf("Token scrape aborted after %v: %v", time.Since(start), err)
+		s.metrics.IncrementScrapeErrors()
+		return
 	}
 
-	s.knownTokens = s.currentProjectTokens
-	s.knownUserTokens = s.currentUserTokens
-	s.knownGroupTokens = s.currentGroupTokens
+	// Снапшот заменяется целиком, поэтому удаленные токены пропадают из метрик автоматически
+	snapshot := metrics.Snapshot{}
+	snapshot.Tokens = append(snapshot.Tokens, projectTokens...)
+	snapshot.Tokens = append(snapshot.Tokens, userTokens...)
+	snapshot.Tokens = append(snapshot.Tokens, groupTokens...)
+	snapshot.DeployTokens = deployTokens
+	snapshot.Pipelines = pipelines
+	snapshot.Keys = keys
+	snapshot.Runners = runners
+	snapshot.Credential = credential
+	s.completeSnapshot(&snapshot)
+	s.metrics.Update(snapshot)
 
-	s.metrics.SetTotalTokens(totalProjectTokens)
-	s.metrics.SetTotalUserTokens(totalUserTokens)
-	s.metrics.SetTotalGroupTokens(totalGroupTokens)
 	s.metrics.SetLastScrapeTime(now)
 	duration := time.Since(start)
 	s.metrics.RecordScrapeDuration(duration)
-	log.Printf("Token scrape completed in %v, found %d project tokens, %d user tokens, %d group tokens", duration, totalProjectTokens, totalUserTokens, totalGroupTokens)
+	log.Printf("Token scrape completed in %v, found %d project tokens, %d user tokens, %d group tokens, %d deploy tokens, %d pipeline triggers and schedules, %d keys, %d runners", duration, len(projectTokens), len(userTokens), len(groupTokens), len(deployTokens), len(pipelines), len(keys), len(runners))
+
+	// Ротация не входит в длительность прохода, ротированные токены появятся в метриках со следующим
+	s.rotate(ctx, snapshot.Tokens)
 }
 
-func (s *TokenScraper) scrapeProjectTokens(now time.Time) int {
-	totalTokens := 0
-	s.currentProjectTokens = make(map[string]bool)
+func (s *TokenScraper) scrapeProjectTokens(ctx context.Context, projectIDs []int, now time.Time) []metrics.Token {
+	s.metrics.SetScrapeTargets(metrics.KindProject, len(projectIDs))
+	results := make([][]metrics.Token, len(projectIDs))
+	forEach(ctx, s.concurrency, len(projectIDs), func(i int) {
+		results[i] = s.scrapeProject(ctx, projectIDs[i], now)
+		s.metrics.IncrementScrapeProgress(metrics.KindProject)
+	})
 
-	for _, projectID := range s.projectIDs {
-		tokens, err := s.gitlabClient.GetProjectAccessTokens(projectID)
+	return flatten(results)
+}
 
-		if err != nil {
-			log.Printf("Failed to get project access tokens for project %d: %v", projectID, err)
-			s.metrics.IncrementScrapeErrors()
-			continue
-		}
+func (s *TokenScraper) scrapeProject(ctx context.Context, projectID int, now time.Time) []metrics.Token {
+	tokens, err := s.gitlabClient.GetProjectAccessTokens(ctx, projectID)
 
-		projectName, err := s.gitlabClient.GetProjectName(projectID)
-		if err != nil {
-			log.Printf("Failed to get project name for project %d: %v", projectID, err)
-			s.metrics.IncrementScrapeErrors()
-			continue
-		}
+	if err != nil {
+		log.Printf("Failed to get project access tokens for project %d: %v", projectID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
 
-		for _, token := range tokens {
-			metrics_name := projectName + " " + strconv.Itoa(projectID) + " " + token.Name
+	projectName, err := s.gitlabClient.GetProjectName(ctx, projectID)
+	if err != nil {
+		log.Printf("Failed to get project name for project %d: %v", projectID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
 
-			s.currentProjectTokens[metrics_name] = true
+	result := make([]metrics.Token, 0, len(tokens))
+	for _, token := range tokens {
+		labels := metrics.TokenLabels{
+			OwnerKind:   metrics.KindProject,
+			OwnerID:     projectID,
+			OwnerName:   projectName,
+			TokenID:     token.ID,
+			TokenName:   token.Name,
+			Scopes:      token.Scopes,
+			AccessLevel: accessLevelName(token.AccessLevel),
+		}
 
-			expiresAt := time.Time(*token.ExpiresAt)
-			isExpired := expiresAt.Before(now)
+		result = append(result, s.newToken(labels, &token.PersonalAccessToken, now))
+		log.Printf("Project: %d, Token: %s, Expires: %s", projectID, token.Name, formatExpiry(token.ExpiresAt, now))
+	}
 
-			s.metrics.SetTokenExpiresAt(metrics_name, expiresAt)
-			s.metrics.SetTokenIsExpired(metrics_name, isExpired)
+	return result
+}
 
-			log.Printf("Project: %d, Token: %s, Expires: %s, IsExpired: %t", projectID, token.Name, expiresAt.Format(time.RFC3339), isExpired)
-		}
-		totalTokens += len(tokens)
+// projectBotUserIDs возвращает ID ботов проектов и групп. Если запрос не удался, используется
+// результат предыдущего прохода. Вызывается только из цикла Start.
+func (s *TokenScraper) projectBotUserIDs(ctx context.Context) map[int]bool {
+	ids, err := s.gitlabClient.GetProjectBotUserIDs(ctx)
+	if err != nil {
+		log.Printf("Failed to get project and group bot users, using the previous list: %v", err)
+		s.metrics.IncrementScrapeErrors()
+		return s.botUserIDs
 	}
 
-	return totalTokens
+	s.botUserIDs = ids
+	return ids
 }
 
-func (s *TokenScraper) scrapeUserTokens(now time.Time) int {
-	totalUserTokens := 0
-	s.currentUserTokens = make(map[string]bool)
-
-	userTokens, err := s.gitlabClient.GetUserAccessTokens()
+// scrapeUserTokens собирает личные токены, кроме токенов пользователей excludeUsers и токенов excludeTokens
+func (s *TokenScraper) scrapeUserTokens(ctx context.Context, excludeUsers, excludeTokens map[int]bool, now time.Time) []metrics.Token {
+	allTokens, err := s.gitlabClient.GetUserAccessTokens(ctx)
 
 	if err != nil {
 		log.Printf("Failed to get user access tokens: %v", err)
 		s.metrics.IncrementScrapeErrors()
-		return 0
+		return nil
+	}
+
+	userTokens := make([]*gitlabapi.PersonalAccessToken, 0, len(allTokens))
+	for _, token := range allTokens {
+		if !excludeUsers[token.UserID] && !excludeTokens[token.ID] {
+			userTokens = append(userTokens, token)
+		}
 	}
 
-	for _, token := range userTokens {
-		userName, err := s.gitlabClient.GetUserName(token.UserID)
+	return s.newUserTokens(ctx, userTokens, now)
+}
+
+// newUserTokens дополняет личные токены именами их владельцев
+func (s *TokenScraper) newUserTokens(ctx context.Context, userTokens []*gitlabapi.PersonalAccessToken, now time.Time) []metrics.Token {
+	s.metrics.SetScrapeTargets(metrics.KindUser, len(userTokens))
+	result := make([]metrics.Token, len(userTokens))
+	forEach(ctx, s.concurrency, len(userTokens), func(i int) {
+		token := userTokens[i]
+		userName, err := s.gitlabClient.GetUserName(ctx, token.UserID)
 
 		if err != nil {
 			log.Printf("Failed to get user name for token %d: %v", token.UserID, err)
@@ -163,58 +325,215 @@ func (s *TokenScraper) scrapeUserTokens(now time.Time) int {
 			userName = "Unknown user"
 		}
 
-		metrics_name := userName + " " + strconv.Itoa(token.UserID) + " " + token.Name
+		labels := metrics.TokenLabels{
+			OwnerKind: metrics.KindUser,
+			OwnerID:   token.UserID,
+			OwnerName: userName,
+			TokenID:   token.ID,
+			TokenName: token.Name,
+			Scopes:    token.Scopes,
+		}
+
+		result[i] = s.newToken(labels, token, now)
+		log.Printf("User: %s, Token: %s, Expires: %s", userName, token.Name, formatExpiry(token.ExpiresAt, now))
+		s.metrics.IncrementScrapeProgress(metrics.KindUser)
+	})
 
-		s.currentUserTokens[metrics_name] = true
+	return result
+}
+
+func (s *TokenScraper) scrapeGroupTokens(ctx context.Context, groupIDs []int, now time.Time) []metrics.Token {
+	s.metrics.SetScrapeTargets(metrics.KindGroup, len(groupIDs))
+	results := make([][]metrics.Token, len(groupIDs))
+	forEach(ctx, s.concurrency, len(groupIDs), func(i int) {
+		results[i] = s.scrapeGroup(ctx, groupIDs[i], now)
+		s.metrics.IncrementScrapeProgress(metrics.KindGroup)
+	})
 
-		expiresAt := time.Time(*token.ExpiresAt)
-		isExpired := expiresAt.Before(now)
+	return flatten(results)
+}
 
-		s.metrics.SetUserTokenExpiresAt(metrics_name, expiresAt)
-		s.metrics.SetUserTokenIsExpired(metrics_name, isExpired)
+func (s *TokenScraper) scrapeGroup(ctx context.Context, groupID int, now time.Time) []metrics.Token {
+	tokens, err := s.gitlabClient.GetGroupAccessTokens(ctx, groupID)
 
-		log.Printf("User: %s, Token: %s, Expires: %s, IsExpired: %t", userName, token.Name, expiresAt.Format(time.RFC3339), isExpired)
+	if err != nil {
+		log.Printf("Failed to get group access tokens for group %d: %v", groupID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
 	}
-	totalUserTokens = len(userTokens)
 
-	return totalUserTokens
+	groupName, err := s.gitlabClient.GetGroupName(ctx, groupID)
+	if err != nil {
+		log.Printf("Failed to get group name for group %d: %v", groupID, err)
+		s.metrics.IncrementScrapeErrors()
+		return nil
+	}
+
+	result := make([]metrics.Token, 0, len(tokens))
+	for _, token := range tokens {
+		labels := metrics.TokenLabels{
+			OwnerKind:   metrics.KindGroup,
+			OwnerID:     groupID,
+			OwnerName:   groupName,
+			TokenID:     token.ID,
+			TokenName:   token.Name,
+			Scopes:      token.Scopes,
+			AccessLevel: accessLevelName(token.AccessLevel),
+		}
+
+		result = append(result, s.newToken(labels, &token.PersonalAccessToken, now))
+		log.Printf("Group: %d, Token: %s, Expires: %s", groupID, token.Name, formatExpiry(token.ExpiresAt, now))
+	}
+
+	return result
 }
 
-func (s *TokenScraper) scrapeGroupTokens(now time.Time) int {
-	totalGroupTokens := 0
-	s.currentGroupTokens = make(map[string]bool)
+// newToken формирует запись снапшота и применяет политики для бессрочных и неиспользуемых токенов.
+// Токены проектов и групп передаются через встроенный PersonalAccessToken.
+func (s *TokenScraper) newToken(labels metrics.TokenLabels, source *gitlabapi.PersonalAccessToken, now time.Time) metrics.Token {
+	token := metrics.Token{
+		Labels:     labels,
+		CreatedAt:  source.CreatedAt,
+		LastUsedAt: source.LastUsedAt,
+	}
+	token.Unused = s.checkUnused(token, now)
 
-	for _, groupID := range s.groupIDs {
-		tokens, err := s.gitlabClient.GetGroupAccessTokens(groupID)
+	if source.ExpiresAt == nil {
+		token.NeverExpiresViolation = s.checkNeverExpires(labels)
+		return token
+	}
 
-		if err != nil {
-			log.Printf("Failed to get group access tokens for group %d: %v", groupID, err)
-			s.metrics.IncrementScrapeErrors()
-			continue
-		}
+	expires := time.Time(*source.ExpiresAt)
+	token.ExpiresAt = &expires
+	return token
+}
 
-		groupName, err := s.gitlabClient.GetGroupName(groupID)
-		if err != nil {
-			log.Printf("Failed to get group name for group %d: %v", groupID, err)
-			s.metrics.IncrementScrapeErrors()
+// checkUnused сообщает, что токен не использовался дольше unusedThreshold.
+// Ни разу не использованный токен считается неиспользуемым, если он создан раньше этого срока.
+func (s *TokenScraper) checkUnused(token metrics.Token, now time.Time) bool {
+	if s.unusedThreshold <= 0 {
+		return false
+	}
+
+	lastActivity := token.LastUsedAt
+	if lastActivity == nil {
+		lastActivity = token.CreatedAt
+	}
+	if lastActivity == nil || now.Sub(*lastActivity) < s.unusedThreshold {
+		return false
+	}
+
+	log.Printf("Unused token: %s token %q was last used %s", token.Labels.OwnerKind, token.Labels.LegacyName(), formatLastUsed(token.LastUsedAt))
+	return true
+}
+
+// formatLastUsed форматирует время последнего использования токена для логов
+func formatLastUsed(lastUsedAt *time.Time) string {
+	if lastUsedAt == nil {
+		return "never"
+	}
+	return lastUsedAt.Format(time.RFC3339)
+}
+
+// completeSnapshot убирает повторы токенов и дополняет снапшот сводкой по scopes и уровням
+// доступа и нарушениями политики
+func (s *TokenScraper) completeSnapshot(snapshot *metrics.Snapshot) {
+	snapshot.Tokens = uniqueTokens(snapshot.Tokens)
+	snapshot.ScopeCounts, snapshot.AccessLevelCounts = countInventory(snapshot.Tokens)
+	snapshot.PolicyViolations = s.evaluatePolicy(snapshot.Tokens)
+}
+
+// evaluatePolicy проверяет токены правилами политики и логирует нарушения
+func (s *TokenScraper) evaluatePolicy(tokens []metrics.Token) []metrics.PolicyViolation {
+	if s.policy == nil {
+		return nil
+	}
+
+	violations := s.policy.Evaluate(tokens)
+	for _, violation := range violations {
+		log.Printf("Policy violation: rule %q, %s token %q: %s", violation.Rule, violation.Labels.OwnerKind, violation.Labels.LegacyName(), violation.Reason)
+	}
+	return violations
+}
+
+// checkNeverExpires применяет политику для токена без даты истечения и сообщает о нарушении
+func (s *TokenScraper) checkNeverExpires(token metrics.TokenLabels) bool {
+	if s.neverExpiresPolicy != NeverExpiresViolation {
+		return false
+	}
+
+	log.Printf("Policy violation: %s token %q has no expiration date", token.OwnerKind, token.LegacyName())
+	return true
+}
+
+// uniqueTokens убирает повторно собранные токены: коллектор отдает каждый токен отдельной серией,
+// и повтор сделал бы недоступным весь /metrics
+func uniqueTokens(tokens []metrics.Token) []metrics.Token {
+	seen := make(map[string]bool, len(tokens))
+	unique := tokens[:0]
+	for _, token := range tokens {
+		key := token.Labels.Key()
+		if seen[key] {
 			continue
 		}
+		seen[key] = true
+		unique = append(unique, token)
+	}
+	return unique
+}
 
+// tokenIDs возвращает множество ID токенов из нескольких списков
+func tokenIDs(lists ...[]metrics.Token) map[int]bool {
+	ids := make(map[int]bool)
+	for _, tokens := range lists {
 		for _, token := range tokens {
-			metrics_name := groupName + " " + strconv.Itoa(groupID) + " " + token.Name
+			ids[token.Labels.TokenID] = true
+		}
+	}
+	return ids
+}
 
-			s.currentGroupTokens[metrics_name] = true
+// flatten объединяет результаты отдельных целей, сохраняя их порядок
+func flatten(results [][]metrics.Token) []metrics.Token {
+	var tokens []metrics.Token
+	for _, result := range results {
+		tokens = append(tokens, result...)
+	}
+	return tokens
+}
 
-			expiresAt := time.Time(*token.ExpiresAt)
-			isExpired := expiresAt.Before(now)
+// formatExpiry форматирует дату истечения токена для логов
+func formatExpiry(expiresAt *gitlabapi.ISOTime, now time.Time) string {
+	if expiresAt == nil {
+		return "never"
+	}
 
-			s.metrics.SetGroupTokenExpiresAt(metrics_name, expiresAt)
-			s.metrics.SetGroupTokenIsExpired(metrics_name, isExpired)
+	expires := time.Time(*expiresAt)
+	return fmt.Sprintf("%s, IsExpired: %t", expires.Format(time.RFC3339), expires.Before(now))
+}
 
-			log.Printf("Group: %d, Token: %s, Expires: %s, IsExpired: %t", groupID, token.Name, expiresAt.Format(time.RFC3339), isExpired)
-		}
-		totalGroupTokens += len(tokens)
+// accessLevelName возвращает название уровня доступа для метки access_level
+func accessLevelName(level gitlabapi.AccessLevelValue) string {
+	switch level {
+	case gitlabapi.NoPermissions:
+		return ""
+	case gitlabapi.MinimalAccessPermissions:
+		return "minimal_access"
+	case gitlabapi.GuestPermissions:
+		return "guest"
+	case gitlabapi.PlannerPermissions:
+		return "planner"
+	case gitlabapi.ReporterPermissions:
+		return "reporter"
+	case gitlabapi.DeveloperPermissions:
+		return "developer"
+	case gitlabapi.MaintainerPermissions:
+		return "maintainer"
+	case gitlabapi.OwnerPermissions:
+		return "owner"
+	case gitlabapi.AdminPermissions:
+		return "admin"
+	default:
+		return strconv.Itoa(int(level))
 	}
-
-	return totalGroupTokens
 }
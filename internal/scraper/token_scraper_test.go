@@ -1,56 +1,705 @@
 package scraper
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlabapi "gitlab.com/gitlab-org/api/client-go"
+
+	"ru/mvideo/com/gitlab/token-exporter/internal/gitlab"
+	"ru/mvideo/com/gitlab/token-exporter/internal/metrics"
+	"ru/mvideo/com/gitlab/token-exporter/internal/policy"
 )
 
-func TestTokenScraper_KnownTokensTracking(t *testing.T) {
-	// Простой тест для проверки логики отслеживания известных токенов
-	scraper := &TokenScraper{
-		knownTokens:     make(map[string]bool),
-		knownUserTokens: make(map[string]bool),
+// mockGitLabClient - мок GitLabClientInterface для тестов скрейпера
+type mockGitLabClient struct {
+	projectTokens map[int][]*gitlabapi.ProjectAccessToken
+	groupTokens   map[int][]*gitlabapi.GroupAccessToken
+	userTokens    []*gitlabapi.PersonalAccessToken
+	// groupProjects и subgroups возвращаются при обнаружении целей
+	groupProjects map[int][]*gitlabapi.Project
+	subgroups     map[int][]*gitlabapi.Group
+	// allProjects и allGroups возвращаются в режиме администратора
+	allProjects []*gitlabapi.Project
+	allGroups   []*gitlabapi.Group
+	// deployTokens - deploy-токены по ключу "<тип владельца>/<ID>"
+	deployTokens map[string][]*gitlabapi.DeployToken
+	triggers     map[int][]*gitlabapi.PipelineTrigger
+	schedules    map[int][]*gitlabapi.PipelineSchedule
+	deployKeys   map[int][]*gitlabapi.ProjectDeployKey
+	instanceKeys []*gitlabapi.InstanceDeployKey
+	users        []*gitlabapi.User
+	// botUserIDs и botUsersErr возвращаются при запросе ботов проектов и групп
+	botUserIDs  map[int]bool
+	botUsersErr error
+	sshKeys     map[int][]*gitlabapi.SSHKey
+	gpgKeys     map[int][]*gitlabapi.GPGKey
+	runners     map[string][]*gitlabapi.Runner
+	allRunners  []*gitlabapi.Runner
+	runnerInfo  map[int]*gitlab.RunnerDetails
+	// selfToken и selfErr возвращаются при запросе токена экспортера
+	selfToken *gitlabapi.PersonalAccessToken
+	selfErr   error
+	// rotated - ID токенов, ротированных через API
+	rotated []int
+	// failDiscovery имитирует ошибку API при обнаружении целей
+	failDiscovery bool
+	// delay имитирует задержку ответа API при получении токенов проекта
+	delay       time.Duration
+	inFlight    int32
+	maxInFlight int32
+}
+
+var _ gitlab.GitLabClientInterface = (*mockGitLabClient)(nil)
+
+func (m *mockGitLabClient) GetProjectAccessTokens(ctx context.Context, projectID int) ([]*gitlabapi.ProjectAccessToken, error) {
+	current := atomic.AddInt32(&m.inFlight, 1)
+	defer atomic.AddInt32(&m.inFlight, -1)
+	for {
+		prev := atomic.LoadInt32(&m.maxInFlight)
+		if current <= prev || atomic.CompareAndSwapInt32(&m.maxInFlight, prev, current) {
+			break
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(m.delay):
+	}
+	return m.projectTokens[projectID], nil
+}
+
+func (m *mockGitLabClient) GetProjectName(_ context.Context, projectID int) (string, error) {
+	return fmt.Sprintf("Project%d", projectID), nil
+}
+
+func (m *mockGitLabClient) GetUserAccessTokens(_ context.Context) ([]*gitlabapi.PersonalAccessToken, error) {
+	return m.userTokens, nil
+}
+
+func (m *mockGitLabClient) GetUserAccessTokensForUser(_ context.Context, userID int) ([]*gitlabapi.PersonalAccessToken, error) {
+	var tokens []*gitlabapi.PersonalAccessToken
+	for _, token := range m.userTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *mockGitLabClient) GetUserName(_ context.Context, userID int) (string, error) {
+	return fmt.Sprintf("User%d", userID), nil
+}
+
+func (m *mockGitLabClient) GetGroupAccessTokens(_ context.Context, groupID int) ([]*gitlabapi.GroupAccessToken, error) {
+	return m.groupTokens[groupID], nil
+}
+
+func (m *mockGitLabClient) GetGroupName(_ context.Context, groupID int) (string, error) {
+	return fmt.Sprintf("Group%d", groupID), nil
+}
+
+func (m *mockGitLabClient) GetGroupProjects(_ context.Context, groupID int, includeSubgroups, skipArchived bool) ([]*gitlabapi.Project, error) {
+	if m.failDiscovery {
+		return nil, errors.New("discovery failed")
+	}
+
+	var projects []*gitlabapi.Project
+	for _, project := range m.groupProjects[groupID] {
+		if skipArchived && project.Archived {
+			continue
+		}
+		// Проекты подгрупп помечены пространством имен, отличным от группы
+		if !includeSubgroups && project.Namespace != nil && project.Namespace.ID != groupID {
+			continue
+		}
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+func (m *mockGitLabClient) GetDescendantGroups(_ context.Context, groupID int) ([]*gitlabapi.Group, error) {
+	if m.failDiscovery {
+		return nil, errors.New("discovery failed")
+	}
+	return m.subgroups[groupID], nil
+}
+
+func (m *mockGitLabClient) GetAllProjects(_ context.Context, skipArchived bool) ([]*gitlabapi.Project, error) {
+	if m.failDiscovery {
+		return nil, errors.New("discovery failed")
+	}
+
+	var projects []*gitlabapi.Project
+	for _, project := range m.allProjects {
+		if !skipArchived || !project.Archived {
+			projects = append(projects, project)
+		}
+	}
+	return projects, nil
+}
+
+func (m *mockGitLabClient) GetAllGroups(_ context.Context) ([]*gitlabapi.Group, error) {
+	if m.failDiscovery {
+		return nil, errors.New("discovery failed")
+	}
+	return m.allGroups, nil
+}
+
+func (m *mockGitLabClient) GetProjectDeployTokens(_ context.Context, projectID int) ([]*gitlabapi.DeployToken, error) {
+	return m.deployTokens[fmt.Sprintf("project/%d", projectID)], nil
+}
+
+func (m *mockGitLabClient) GetGroupDeployTokens(_ context.Context, groupID int) ([]*gitlabapi.DeployToken, error) {
+	return m.deployTokens[fmt.Sprintf("group/%d", groupID)], nil
+}
+
+func (m *mockGitLabClient) GetPipelineTriggers(_ context.Context, projectID int) ([]*gitlabapi.PipelineTrigger, error) {
+	return m.triggers[projectID], nil
+}
+
+func (m *mockGitLabClient) GetPipelineSchedules(_ context.Context, projectID int) ([]*gitlabapi.PipelineSchedule, error) {
+	return m.schedules[projectID], nil
+}
+
+func (m *mockGitLabClient) GetProjectDeployKeys(_ context.Context, projectID int) ([]*gitlabapi.ProjectDeployKey, error) {
+	return m.deployKeys[projectID], nil
+}
+
+func (m *mockGitLabClient) GetAllDeployKeys(_ context.Context) ([]*gitlabapi.InstanceDeployKey, error) {
+	return m.instanceKeys, nil
+}
+
+func (m *mockGitLabClient) GetAllUsers(_ context.Context) ([]*gitlabapi.User, error) {
+	return m.users, nil
+}
+
+func (m *mockGitLabClient) GetProjectBotUserIDs(_ context.Context) (map[int]bool, error) {
+	if m.botUsersErr != nil {
+		return nil, m.botUsersErr
+	}
+	return m.botUserIDs, nil
+}
+
+func (m *mockGitLabClient) GetUserSSHKeys(_ context.Context, userID int) ([]*gitlabapi.SSHKey, error) {
+	return m.sshKeys[userID], nil
+}
+
+func (m *mockGitLabClient) GetUserGPGKeys(_ context.Context, userID int) ([]*gitlabapi.GPGKey, error) {
+	return m.gpgKeys[userID], nil
+}
+
+func (m *mockGitLabClient) GetProjectRunners(_ context.Context, projectID int) ([]*gitlabapi.Runner, error) {
+	return m.runners[fmt.Sprintf("project/%d", projectID)], nil
+}
+
+func (m *mockGitLabClient) GetGroupRunners(_ context.Context, groupID int) ([]*gitlabapi.Runner, error) {
+	return m.runners[fmt.Sprintf("group/%d", groupID)], nil
+}
+
+func (m *mockGitLabClient) GetAllRunners(_ context.Context) ([]*gitlabapi.Runner, error) {
+	return m.allRunners, nil
+}
+
+func (m *mockGitLabClient) GetRunnerDetails(_ context.Context, runnerID int) (*gitlab.RunnerDetails, error) {
+	details, ok := m.runnerInfo[runnerID]
+	if !ok {
+		return nil, gitlabapi.ErrNotFound
+	}
+	return details, nil
+}
+
+func (m *mockGitLabClient) GetSelfAccessToken(_ context.Context) (*gitlabapi.PersonalAccessToken, error) {
+	if m.selfErr != nil {
+		return nil, m.selfErr
+	}
+	if m.selfToken == nil {
+		return &gitlabapi.PersonalAccessToken{ID: 1, Name: "exporter", Scopes: []string{"read_api"}}, nil
+	}
+	return m.selfToken, nil
+}
+
+func (m *mockGitLabClient) RotateProjectAccessToken(_ context.Context, _, tokenID int, _ *time.Time) (*gitlabapi.ProjectAccessToken, error) {
+	m.rotated = append(m.rotated, tokenID)
+	return projectToken(tokenID+100, "rotated", nil), nil
+}
+
+func (m *mockGitLabClient) RotateGroupAccessToken(_ context.Context, _, tokenID int, _ *time.Time) (*gitlabapi.GroupAccessToken, error) {
+	m.rotated = append(m.rotated, tokenID)
+	return groupToken(tokenID+100, "rotated", nil), nil
+}
+
+func (m *mockGitLabClient) GetClient() *gitlabapi.Client {
+	return nil
+}
+
+// newTestHandler создает metrics.Handler и возвращает его реестр
+func newTestHandler(t *testing.T) (*metrics.Handler, *prometheus.Registry) {
+	t.Helper()
+	handler := metrics.NewHandler()
+	return handler, handler.Registry()
+}
+
+// gaugeValue ищет значение метрики с заданными метками в реестре
+func gaugeValue(t *testing.T, registry *prometheus.Registry, name string, labels map[string]string) (float64, bool) {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matched := 0
+			for _, label := range metric.GetLabel() {
+				if value, ok := labels[label.GetName()]; ok && value == label.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(labels) {
+				return metric.GetGauge().GetValue(), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// counterValue возвращает значение счетчика без меток
+func counterValue(t *testing.T, registry *prometheus.Registry, name string) float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() == name && len(family.GetMetric()) > 0 {
+			return family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func isoTime(t time.Time) *gitlabapi.ISOTime {
+	value := gitlabapi.ISOTime(t)
+	return &value
+}
+
+func projectToken(id int, name string, expiresAt *gitlabapi.ISOTime) *gitlabapi.ProjectAccessToken {
+	return &gitlabapi.ProjectAccessToken{
+		PersonalAccessToken: gitlabapi.PersonalAccessToken{ID: id, Name: name, ExpiresAt: expiresAt},
+	}
+}
+
+func groupToken(id int, name string, expiresAt *gitlabapi.ISOTime) *gitlabapi.GroupAccessToken {
+	return &gitlabapi.GroupAccessToken{
+		PersonalAccessToken: gitlabapi.PersonalAccessToken{ID: id, Name: name, ExpiresAt: expiresAt},
+	}
+}
+
+func TestTokenScraper_DeletedTokens(t *testing.T) {
+	handler, registry := newTestHandler(t)
+	expiresAt := isoTime(time.Now().Add(24 * time.Hour))
+
+	// Имитируем первый скрейпинг с двумя токенами проекта и двумя пользовательскими токенами
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{
+			1: {projectToken(1, "token1", expiresAt), projectToken(2, "token2", expiresAt)},
+		},
+		userTokens: []*gitlabapi.PersonalAccessToken{
+			{ID: 3, Name: "token1", UserID: 123, ExpiresAt: expiresAt},
+			{ID: 4, Name: "token2", UserID: 456, ExpiresAt: expiresAt},
+		},
+	}
+	scraper := NewTokenScraper(client, handler, []int{1}, nil)
+	scraper.scrape(context.Background())
+
+	if got, _ := gaugeValue(t, registry, "gitlab_tokens_total", map[string]string{}); got != 2 {
+		t.Errorf("Expected 2 project tokens, got %v", got)
+	}
+	if got, _ := gaugeValue(t, registry, "gitlab_user_tokens_total", map[string]string{}); got != 2 {
+		t.Errorf("Expected 2 user tokens, got %v", got)
+	}
+
+	// Имитируем второй скрейпинг, в котором по одному токену удалено
+	client.projectTokens[1] = client.projectTokens[1][:1]
+	client.userTokens = client.userTokens[:1]
+	scraper.scrape(context.Background())
+
+	if got, _ := gaugeValue(t, registry, "gitlab_tokens_total", map[string]string{}); got != 1 {
+		t.Errorf("Expected 1 project token, got %v", got)
 	}
 
-	// Имитируем первый скрейпинг с двумя токенами проектов
-	currentTokens := map[string]bool{
-		"Project1 1 token1": true,
-		"Project1 1 token2": true,
+	// Проверяем, что метрики удаленных токенов больше не экспортируются
+	if _, ok := gaugeValue(t, registry, "gitlab_token_is_expired", map[string]string{"token_id": "2"}); ok {
+		t.Error("Expected metrics for project token2 to be removed")
+	}
+	if _, ok := gaugeValue(t, registry, "gitlab_user_token_is_expired", map[string]string{"token_id": "4"}); ok {
+		t.Error("Expected metrics for user token2 to be removed")
+	}
+	if _, ok := gaugeValue(t, registry, "gitlab_token_is_expired", map[string]string{"token_id": "1"}); !ok {
+		t.Error("Expected metrics for project token1 to be kept")
 	}
+}
+
+func TestTokenScraper_StructuredLabels(t *testing.T) {
+	handler, registry := newTestHandler(t)
+
+	// Два токена с одинаковым именем в одном проекте не должны конфликтовать
+	first := projectToken(1, "deploy", isoTime(time.Now().Add(time.Hour)))
+	first.Scopes = []string{"read_api", "api"}
+	first.AccessLevel = gitlabapi.MaintainerPermissions
+	second := projectToken(2, "deploy", isoTime(time.Now().Add(-time.Hour)))
 
-	// Проверяем, что известные токены обновляются
-	scraper.knownTokens = currentTokens
-	if len(scraper.knownTokens) != 2 {
-		t.Errorf("Expected 2 known project tokens, got %d", len(scraper.knownTokens))
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {first, second}},
 	}
+	scraper := NewTokenScraper(client, handler, []int{1}, nil)
+	scraper.scrape(context.Background())
 
-	// Имитируем второй скрейпинг с одним токеном (один удален)
-	currentTokens = map[string]bool{
-		"Project1 1 token1": true,
+	got, ok := gaugeValue(t, registry, "gitlab_token_is_expired", map[string]string{
+		"owner_kind":   metrics.KindProject,
+		"owner_id":     "1",
+		"owner_name":   "Project1",
+		"token_id":     "1",
+		"token_name":   "deploy",
+		"scopes":       "api,read_api",
+		"access_level": "maintainer",
+	})
+	if !ok || got != 0 {
+		t.Errorf("first token: got %v (found %v), want 0", got, ok)
 	}
 
-	// Проверяем, что удаленный токен больше не в списке
-	if currentTokens["Project1 1 token2"] {
-		t.Error("Expected token2 to be removed from current project tokens")
+	got, ok = gaugeValue(t, registry, "gitlab_token_is_expired", map[string]string{"token_id": "2", "token_name": "deploy"})
+	if !ok || got != 1 {
+		t.Errorf("second token: got %v (found %v), want 1", got, ok)
 	}
+}
+
+func TestTokenScraper_DuplicateTargets(t *testing.T) {
+	handler, registry := newTestHandler(t)
+	expiresAt := isoTime(time.Now().Add(24 * time.Hour))
+	token := projectToken(1, "deploy", expiresAt)
 
-	// Имитируем скрейпинг пользовательских токенов
-	currentUserTokens := map[string]bool{
-		"User 123 token1": true,
-		"User 456 token2": true,
+	// Повторы ID в настройках и повтор токена в ответе GitLab (например, на стыке страниц)
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {token, token}},
+		groupTokens:   map[int][]*gitlabapi.GroupAccessToken{5: {groupToken(2, "group", expiresAt)}},
 	}
+	scraper := NewTokenScraper(client, handler, []int{1, 1}, []int{5, 5})
+	scraper.scrape(context.Background())
 
-	scraper.knownUserTokens = currentUserTokens
-	if len(scraper.knownUserTokens) != 2 {
-		t.Errorf("Expected 2 known user tokens, got %d", len(scraper.knownUserTokens))
+	if _, err := registry.Gather(); err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+	if got, _ := gaugeValue(t, registry, "gitlab_tokens_total", map[string]string{}); got != 1 {
+		t.Errorf("Expected 1 project token, got %v", got)
 	}
+	if got, _ := gaugeValue(t, registry, "gitlab_group_tokens_total", map[string]string{}); got != 1 {
+		t.Errorf("Expected 1 group token, got %v", got)
+	}
+}
 
-	// Имитируем второй скрейпинг с одним пользовательским токеном (один удален)
-	currentUserTokens = map[string]bool{
-		"User 123 token1": true,
+func TestTokenScraper_NeverExpiringTokens(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        NeverExpiresPolicy
+		wantViolation bool
+	}{
+		{name: "allow policy", policy: NeverExpiresAllow, wantViolation: false},
+		{name: "violation policy", policy: NeverExpiresViolation, wantViolation: true},
 	}
 
-	// Проверяем, что удаленный пользовательский токен больше не в списке
-	if currentUserTokens["User 456 token2"] {
-		t.Error("Expected user token2 to be removed from current user tokens")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, registry := newTestHandler(t)
+
+			client := &mockGitLabClient{
+				projectTokens: map[int][]*gitlabapi.ProjectAccessToken{
+					1: {
+						projectToken(1, "forever", nil),
+						projectToken(2, "dated", isoTime(time.Now().Add(48*time.Hour))),
+					},
+				},
+				groupTokens: map[int][]*gitlabapi.GroupAccessToken{
+					2: {groupToken(3, "group-forever", nil)},
+				},
+				userTokens: []*gitlabapi.PersonalAccessToken{
+					{ID: 4, Name: "user-forever", UserID: 10},
+				},
+			}
+
+			scraper := NewTokenScraper(client, handler, []int{1}, []int{2}, WithNeverExpiresPolicy(tt.policy))
+			scraper.scrape(context.Background())
+
+			checks := []struct {
+				metric string
+				name   string
+				want   float64
+			}{
+				{"gitlab_token_never_expires", "forever", 1},
+				{"gitlab_token_never_expires", "dated", 0},
+				{"gitlab_group_token_never_expires", "group-forever", 1},
+				{"gitlab_user_token_never_expires", "user-forever", 1},
+				{"gitlab_token_is_expired", "forever", 0},
+			}
+			for _, check := range checks {
+				got, ok := gaugeValue(t, registry, check.metric, map[string]string{"token_name": check.name})
+				if !ok {
+					t.Errorf("%s{name=%q} not found", check.metric, check.name)
+					continue
+				}
+				if got != check.want {
+					t.Errorf("%s{name=%q} = %v, want %v", check.metric, check.name, got, check.want)
+				}
+			}
+
+			if _, ok := gaugeValue(t, registry, "gitlab_access_token_expiry_timestamp_seconds", map[string]string{"token_name": "forever"}); ok {
+				t.Error("gitlab_access_token_expiry_timestamp_seconds should not be exported for a token without expiration date")
+			}
+
+			_, violation := gaugeValue(t, registry, "gitlab_token_never_expires_violation", map[string]string{"owner_kind": metrics.KindProject, "token_name": "forever"})
+			if violation != tt.wantViolation {
+				t.Errorf("violation exported = %v, want %v", violation, tt.wantViolation)
+			}
+			if _, ok := gaugeValue(t, registry, "gitlab_token_never_expires_violation", map[string]string{"token_name": "dated"}); ok {
+				t.Error("token with expiration date must not be reported as violation")
+			}
+		})
+	}
+}
+
+func TestTokenScraper_ExpiryTimestamp(t *testing.T) {
+	handler, registry := newTestHandler(t)
+
+	expiresAt := time.Date(2030, time.January, 2, 0, 0, 0, 0, time.UTC)
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {projectToken(1, "project", isoTime(expiresAt))}},
+		groupTokens:   map[int][]*gitlabapi.GroupAccessToken{2: {groupToken(2, "group", isoTime(expiresAt))}},
+		userTokens:    []*gitlabapi.PersonalAccessToken{{ID: 3, Name: "user", UserID: 10, ExpiresAt: isoTime(expiresAt)}},
+	}
+	scraper := NewTokenScraper(client, handler, []int{1}, []int{2})
+	scraper.scrape(context.Background())
+
+	for _, kind := range []string{metrics.KindProject, metrics.KindGroup, metrics.KindUser} {
+		got, ok := gaugeValue(t, registry, "gitlab_access_token_expiry_timestamp_seconds", map[string]string{"owner_kind": kind})
+		if !ok {
+			t.Errorf("expiry timestamp for %s token not found", kind)
+			continue
+		}
+		if got != float64(expiresAt.Unix()) {
+			t.Errorf("expiry timestamp for %s token = %v, want %v", kind, got, float64(expiresAt.Unix()))
+		}
+	}
+
+	// Устаревшие метрики в часах по умолчанию не экспортируются
+	if _, ok := gaugeValue(t, registry, "gitlab_token_expires_at", map[string]string{}); ok {
+		t.Error("gitlab_token_expires_at must not be exported without legacy mode")
+	}
+}
+
+func TestTokenScraper_CheckUnused(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		at := now.AddDate(0, 0, -days)
+		return &at
+	}
+
+	tests := []struct {
+		name       string
+		threshold  time.Duration
+		createdAt  *time.Time
+		lastUsedAt *time.Time
+		want       bool
+	}{
+		{name: "detector disabled", threshold: 0, createdAt: daysAgo(400), want: false},
+		{name: "recently used", threshold: 90 * 24 * time.Hour, createdAt: daysAgo(400), lastUsedAt: daysAgo(1), want: false},
+		{name: "dormant", threshold: 90 * 24 * time.Hour, createdAt: daysAgo(400), lastUsedAt: daysAgo(120), want: true},
+		{name: "never used and old", threshold: 90 * 24 * time.Hour, createdAt: daysAgo(100), want: true},
+		{name: "never used but new", threshold: 90 * 24 * time.Hour, createdAt: daysAgo(10), want: false},
+		{name: "no dates", threshold: 90 * 24 * time.Hour, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scraper := NewTokenScraper(&mockGitLabClient{}, nil, nil, nil, WithUnusedThreshold(tt.threshold))
+			token := metrics.Token{Labels: metrics.TokenLabels{TokenName: "token"}, CreatedAt: tt.createdAt, LastUsedAt: tt.lastUsedAt}
+			if got := scraper.checkUnused(token, now); got != tt.want {
+				t.Errorf("checkUnused() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenScraper_TokenUsage(t *testing.T) {
+	handler, registry := newTestHandler(t)
+
+	created := time.Now().AddDate(-1, 0, 0).Truncate(time.Second)
+	lastUsed := time.Now().AddDate(0, -6, 0).Truncate(time.Second)
+	dormant := projectToken(1, "dormant", nil)
+	dormant.CreatedAt = &created
+	dormant.LastUsedAt = &lastUsed
+
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {dormant}},
+		userTokens:    []*gitlabapi.PersonalAccessToken{{ID: 2, Name: "user-dormant", UserID: 10, CreatedAt: &created, LastUsedAt: &lastUsed}},
+	}
+	scraper := NewTokenScraper(client, handler, []int{1}, nil, WithUnusedThreshold(30*24*time.Hour))
+	scraper.scrape(context.Background())
+
+	if got, _ := gaugeValue(t, registry, "gitlab_access_token_last_used_timestamp_seconds", map[string]string{"token_name": "dormant"}); got != float64(lastUsed.Unix()) {
+		t.Errorf("last used timestamp = %v, want %v", got, lastUsed.Unix())
+	}
+	if got, _ := gaugeValue(t, registry, "gitlab_access_token_created_timestamp_seconds", map[string]string{"owner_kind": metrics.KindUser}); got != float64(created.Unix()) {
+		t.Errorf("created timestamp = %v, want %v", got, created.Unix())
+	}
+	for _, name := range []string{"dormant", "user-dormant"} {
+		if _, ok := gaugeValue(t, registry, "gitlab_access_token_unused", map[string]string{"token_name": name}); !ok {
+			t.Errorf("token %q must be reported as unused", name)
+		}
+	}
+}
+
+func TestTokenScraper_Policy(t *testing.T) {
+	handler, registry := newTestHandler(t)
+
+	expiresAt := gitlabapi.ISOTime(time.Now().AddDate(0, 1, 0))
+	bot := projectToken(1, "my-token", &expiresAt)
+	bot.AccessLevel = gitlabapi.OwnerPermissions
+
+	tokenPolicy := &policy.Policy{Rules: []policy.Rule{
+		{Name: "no-owner", Type: policy.RuleForbiddenAccessLevels, AccessLevels: []string{"owner"}},
+		{Name: "naming", Type: policy.RuleNamePattern, Pattern: "^ci-"},
+		{Name: "expiry", Type: policy.RuleRequireExpiry},
+	}}
+	if err := tokenPolicy.Compile(); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {bot}},
+	}
+	scraper := NewTokenScraper(client, handler, []int{1}, nil, WithPolicy(tokenPolicy))
+	scraper.scrape(context.Background())
+
+	for _, rule := range []string{"no-owner", "naming"} {
+		if got, ok := gaugeValue(t, registry, "gitlab_token_policy_violation", map[string]string{"rule": rule, "token_name": "my-token"}); !ok || got != 1 {
+			t.Errorf("gitlab_token_policy_violation{rule=%q} = %v (found %v), want 1", rule, got, ok)
+		}
+	}
+	// Токен с датой истечения не нарушает правило expiry
+	if _, ok := gaugeValue(t, registry, "gitlab_token_policy_violation", map[string]string{"rule": "expiry"}); ok {
+		t.Error("token with expiry date must not violate the expiry rule")
+	}
+}
+
+func TestTokenScraper_ConcurrentScrape(t *testing.T) {
+	const projects = 20
+	const concurrency = 4
+
+	client := &mockGitLabClient{
+		projectTokens: make(map[int][]*gitlabapi.ProjectAccessToken),
+		delay:         20 * time.Millisecond,
+	}
+	projectIDs := make([]int, 0, projects)
+	for id := 1; id <= projects; id++ {
+		projectIDs = append(projectIDs, id)
+		client.projectTokens[id] = []*gitlabapi.ProjectAccessToken{
+			projectToken(id*10, "a", nil),
+			projectToken(id*10+1, "b", nil),
+		}
+	}
+
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, projectIDs, nil, WithConcurrency(concurrency))
+
+	start := time.Now()
+	scraper.scrape(context.Background())
+	elapsed := time.Since(start)
+
+	if client.maxInFlight < 2 {
+		t.Errorf("max in-flight requests = %d, expected parallel scraping", client.maxInFlight)
+	}
+	if client.maxInFlight > concurrency {
+		t.Errorf("max in-flight requests = %d, want <= %d", client.maxInFlight, concurrency)
+	}
+	if sequential := projects * client.delay; elapsed >= sequential {
+		t.Errorf("scrape took %v, expected less than sequential %v", elapsed, sequential)
+	}
+
+	if got, _ := gaugeValue(t, registry, "gitlab_tokens_total", map[string]string{}); got != 2*projects {
+		t.Errorf("gitlab_tokens_total = %v, want %d", got, 2*projects)
+	}
+	for id := 1; id <= projects; id++ {
+		if _, ok := gaugeValue(t, registry, "gitlab_token_never_expires", map[string]string{"token_id": fmt.Sprint(id*10 + 1)}); !ok {
+			t.Errorf("token %d of project %d not exported", id*10+1, id)
+		}
+	}
+}
+
+func TestTokenScraper_ScrapeTimeout(t *testing.T) {
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{
+			1: {projectToken(1, "token1", nil)},
+			2: {projectToken(2, "token2", nil)},
+		},
+	}
+	handler, registry := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, []int{1, 2}, nil, WithScrapeTimeout(50*time.Millisecond))
+
+	scraper.scrape(context.Background())
+	if got, _ := gaugeValue(t, registry, "gitlab_tokens_total", map[string]string{}); got != 2 {
+		t.Fatalf("gitlab_tokens_total = %v, want 2", got)
+	}
+
+	// Имитируем зависший API: проход должен прерваться по дедлайну
+	client.delay = time.Minute
+	start := time.Now()
+	scraper.scrape(context.Background())
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("scrape took %v, expected to be aborted by deadline", elapsed)
+	}
+
+	// Неполный снапшот не должен затирать метрики предыдущего прохода
+	if got, _ := gaugeValue(t, registry, "gitlab_tokens_total", map[string]string{}); got != 2 {
+		t.Errorf("gitlab_tokens_total = %v, want 2 from previous scrape", got)
+	}
+	if got := counterValue(t, registry, "gitlab_token_scrape_errors_total"); got < 1 {
+		t.Errorf("gitlab_token_scrape_errors_total = %v, want >= 1", got)
+	}
+}
+
+func TestTokenScraper_StartStopsOnCancel(t *testing.T) {
+	client := &mockGitLabClient{
+		projectTokens: map[int][]*gitlabapi.ProjectAccessToken{1: {projectToken(1, "token1", nil)}},
+		delay:         time.Minute,
+	}
+	handler, _ := newTestHandler(t)
+	scraper := NewTokenScraper(client, handler, []int{1}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scraper.Start(ctx, time.Hour)
+		close(done)
+	}()
+
+	// Отмена контекста должна прервать зависший запрос
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scraper did not stop after context cancellation")
 	}
 }
@@ -0,0 +1,79 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source возвращает актуальное значение секрета
+type Source interface {
+	Secret(ctx context.Context) (string, error)
+}
+
+// Static - секрет, заданный напрямую
+type Static string
+
+func (s Static) Secret(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// File читает секрет из файла и перечитывает его при изменении времени модификации или размера,
+// поэтому ротация Kubernetes-секрета подхватывается без перезапуска
+type File struct {
+	path string
+
+	mu      sync.Mutex
+	value   string
+	modTime time.Time
+	size    int64
+}
+
+// NewFile создает источник секрета из файла path
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Secret возвращает содержимое файла без пробельных символов по краям.
+// Если файл не удалось перечитать, возвращается последнее прочитанное значение.
+func (f *File) Secret(context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return f.fallback(fmt.Errorf("failed to stat secret file: %w", err))
+	}
+	if f.value != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.value, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return f.fallback(fmt.Errorf("failed to read secret file: %w", err))
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return f.fallback(fmt.Errorf("secret file %s is empty", f.path))
+	}
+
+	if f.value != "" && f.value != value {
+		log.Printf("Secret file %s changed, using the new value", f.path)
+	}
+	f.value = value
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return value, nil
+}
+
+func (f *File) fallback(err error) (string, error) {
+	if f.value == "" {
+		return "", err
+	}
+	log.Printf("Using previous secret value: %v", err)
+	return f.value, nil
+}
@@ -0,0 +1,60 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatic(t *testing.T) {
+	got, err := Static("token").Secret(context.Background())
+	if err != nil || got != "token" {
+		t.Errorf("Secret() = %q, %v, want %q", got, err, "token")
+	}
+}
+
+func TestFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "token")
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write secret file: %v", err)
+		}
+		// Явное время модификации, чтобы изменения в пределах одной секунды были заметны
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+
+	source := NewFile(path)
+	if _, err := source.Secret(ctx); err == nil {
+		t.Error("Secret() for missing file error = nil, want error")
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write("first-token\n", start)
+	if got, err := source.Secret(ctx); err != nil || got != "first-token" {
+		t.Errorf("Secret() = %q, %v, want %q", got, err, "first-token")
+	}
+
+	// Ротация секрета подхватывается без пересоздания источника
+	write("rotated-token", start.Add(time.Minute))
+	if got, err := source.Secret(ctx); err != nil || got != "rotated-token" {
+		t.Errorf("Secret() after rotation = %q, %v, want %q", got, err, "rotated-token")
+	}
+
+	// Пустой или удаленный файл не сбрасывает последнее значение
+	write("  \n", start.Add(2*time.Minute))
+	if got, err := source.Secret(ctx); err != nil || got != "rotated-token" {
+		t.Errorf("Secret() for empty file = %q, %v, want previous value", got, err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove secret file: %v", err)
+	}
+	if got, err := source.Secret(ctx); err != nil || got != "rotated-token" {
+		t.Errorf("Secret() for removed file = %q, %v, want previous value", got, err)
+	}
+}
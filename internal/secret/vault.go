@@ -0,0 +1,212 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// vaultRetryInterval - пауза перед повторным запросом к Vault после ошибки (не больше интервала обновления)
+const vaultRetryInterval = 30 * time.Second
+
+// vaultRequestTimeout - ограничение времени одного обновления секрета
+const vaultRequestTimeout = 30 * time.Second
+
+// VaultOption - функциональная опция для настройки Vault
+type VaultOption func(*Vault)
+
+// WithKVVersion задает версию движка KV (1 или 2, по умолчанию 2)
+func WithKVVersion(version int) VaultOption {
+	return func(v *Vault) {
+		v.kvVersion = version
+	}
+}
+
+// WithRefreshInterval задает, как часто секрет перечитывается из Vault (по умолчанию 5 минут)
+func WithRefreshInterval(interval time.Duration) VaultOption {
+	return func(v *Vault) {
+		v.refreshInterval = interval
+	}
+}
+
+// WithHTTPClient задает HTTP-клиент для запросов к Vault
+func WithHTTPClient(client *http.Client) VaultOption {
+	return func(v *Vault) {
+		v.httpClient = client
+	}
+}
+
+// Vault читает секрет из движка KV HashiCorp Vault
+type Vault struct {
+	address         string
+	token           Source
+	mount           string
+	path            string
+	key             string
+	kvVersion       int
+	refreshInterval time.Duration
+	httpClient      *http.Client
+	now             func() time.Time
+
+	mu    sync.Mutex
+	value string
+	err   error
+	// nextFetch - время, после которого значение снова запрашивается у Vault
+	nextFetch time.Time
+	// refreshing закрывается по завершении текущего запроса к Vault (nil - запроса нет)
+	refreshing chan struct{}
+}
+
+// NewVault создает источник, читающий поле key секрета path в движке KV, смонтированном в mount.
+// token - токен Vault; он запрашивается при каждом обращении к Vault, поэтому тоже может ротироваться.
+func NewVault(address string, token Source, mount, path, key string, opts ...VaultOption) *Vault {
+	v := &Vault{
+		address:         strings.TrimRight(address, "/"),
+		token:           token,
+		mount:           strings.Trim(mount, "/"),
+		path:            strings.Trim(path, "/"),
+		key:             key,
+		kvVersion:       2,
+		refreshInterval: 5 * time.Minute,
+		httpClient:      &http.Client{Timeout: vaultRequestTimeout},
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// Secret возвращает значение из кэша или запрашивает его у Vault после истечения refreshInterval.
+// Запрос выполняет только один вызов, остальные тем временем получают прежнее значение или,
+// если его еще нет, ждут результата. После ошибки запрос повторяется не раньше чем через
+// vaultRetryInterval: до этого возвращается прежнее значение, а без него - последняя ошибка.
+func (v *Vault) Secret(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	stale := !v.now().Before(v.nextFetch)
+	switch {
+	case v.value != "" && (v.refreshing != nil || !stale):
+		value := v.value
+		v.mu.Unlock()
+		return value, nil
+	case v.refreshing == nil && !stale:
+		err := v.err
+		v.mu.Unlock()
+		return "", err
+	}
+
+	done := v.refreshing
+	if done == nil {
+		done = make(chan struct{})
+		v.refreshing = done
+		go v.refresh(context.WithoutCancel(ctx), done)
+	}
+	v.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.value != "" {
+		return v.value, nil
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return "", v.err
+}
+
+// refresh запрашивает секрет у Vault и закрывает done. Отмена контекста вызова, начавшего
+// обновление, не прерывает его для остальных, поэтому ctx передается без отмены.
+func (v *Vault) refresh(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(ctx, vaultRequestTimeout)
+	defer cancel()
+	value, err := v.fetch(ctx)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.refreshing = nil
+	v.err = err
+
+	if err != nil {
+		retry := min(v.refreshInterval, vaultRetryInterval)
+		v.nextFetch = v.now().Add(retry)
+		if v.value != "" {
+			log.Printf("Using previous secret value, next attempt in %v: %v", retry, err)
+		}
+		return
+	}
+
+	v.value = value
+	v.nextFetch = v.now().Add(v.refreshInterval)
+}
+
+// vaultResponse - ответ Vault на чтение секрета. В KV v2 поля секрета вложены в data.data.
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+func (v *Vault) fetch(ctx context.Context) (string, error) {
+	token, err := v.token.Secret(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get vault token: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/%s", v.address, v.mount, v.path)
+	if v.kvVersion == 2 {
+		url = fmt.Sprintf("%s/v1/%s/data/%s", v.address, v.mount, v.path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode vault response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to read vault secret %s/%s: status %d: %s", v.mount, v.path, resp.StatusCode, strings.Join(body.Errors, "; "))
+	}
+
+	data := body.Data
+	if v.kvVersion == 2 {
+		var versioned struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &versioned); err != nil {
+			return "", fmt.Errorf("failed to decode vault secret: %w", err)
+		}
+		data = versioned.Data
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("failed to decode vault secret: %w", err)
+	}
+	value, ok := fields[v.key].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("vault secret %s/%s has no string field %q", v.mount, v.path, v.key)
+	}
+	return value, nil
+}
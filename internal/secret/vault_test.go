@@ -0,0 +1,343 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newVaultServer имитирует чтение секрета из Vault и считает запросы
+func newVaultServer(t *testing.T, path string, status int, body string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if got := r.Header.Get("X-Vault-Token"); got != "vault-token" {
+			t.Errorf("X-Vault-Token = %q, want %q", got, "vault-token")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestVault_Secret(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		status    int
+		body      string
+		kvVersion int
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "kv v2",
+			path:      "/v1/secret/data/exporter",
+			status:    http.StatusOK,
+			body:      `{"data": {"data": {"token": "glpat-v2"}, "metadata": {"version": 3}}}`,
+			kvVersion: 2,
+			want:      "glpat-v2",
+		},
+		{
+			name:      "kv v1",
+			path:      "/v1/secret/exporter",
+			status:    http.StatusOK,
+			body:      `{"data": {"token": "glpat-v1"}}`,
+			kvVersion: 1,
+			want:      "glpat-v1",
+		},
+		{
+			name:      "missing key",
+			path:      "/v1/secret/data/exporter",
+			status:    http.StatusOK,
+			body:      `{"data": {"data": {"password": "x"}}}`,
+			kvVersion: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "permission denied",
+			path:      "/v1/secret/data/exporter",
+			status:    http.StatusForbidden,
+			body:      `{"errors": ["permission denied"]}`,
+			kvVersion: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newVaultServer(t, tt.path, tt.status, tt.body)
+
+			source := NewVault(server.URL, Static("vault-token"), "secret", "exporter", "token", WithKVVersion(tt.kvVersion))
+			got, err := source.Secret(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Secret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Secret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVault_Refresh(t *testing.T) {
+	server, requests := newVaultServer(t, "/v1/secret/data/exporter", http.StatusOK, `{"data": {"data": {"token": "glpat"}}}`)
+
+	now := time.Now()
+	source := NewVault(server.URL+"/", Static("vault-token"), "/secret/", "exporter", "token", WithRefreshInterval(time.Minute))
+	source.now = func() time.Time { return now }
+
+	for range 3 {
+		if _, err := source.Secret(context.Background()); err != nil {
+			t.Fatalf("Secret() error = %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("vault requests within refresh interval = %d, want 1", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := source.Secret(context.Background()); err != nil {
+		t.Fatalf("Secret() error = %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("vault requests after refresh interval = %d, want 2", got)
+	}
+
+	// Недоступный Vault не сбрасывает последнее полученное значение
+	server.Close()
+	now = now.Add(2 * time.Minute)
+	if got, err := source.Secret(context.Background()); err != nil || got != "glpat" {
+		t.Errorf("Secret() with vault down = %q, %v, want previous value", got, err)
+	}
+}
+
+// testClock - время, которое тест сдвигает вручную, безопасное для параллельных вызовов
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestVault_Outage(t *testing.T) {
+	const (
+		vaultOK = iota
+		vaultFail
+		vaultHang
+	)
+	var mode, requests atomic.Int32
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch mode.Load() {
+		case vaultFail:
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"errors": ["internal error"]}`)
+			return
+		case vaultHang:
+			<-release
+		}
+		fmt.Fprint(w, `{"data": {"data": {"token": "glpat"}}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	clock := &testClock{now: time.Now()}
+	source := NewVault(server.URL, Static("vault-token"), "secret", "exporter", "token", WithRefreshInterval(time.Minute))
+	source.now = clock.Now
+
+	if _, err := source.Secret(context.Background()); err != nil {
+		t.Fatalf("Secret() error = %v", err)
+	}
+
+	// После ошибки прежнее значение отдается без запросов к Vault до истечения паузы
+	mode.Store(vaultFail)
+	clock.Add(2 * time.Minute)
+	for range 5 {
+		if got, err := source.Secret(context.Background()); err != nil || got != "glpat" {
+			t.Fatalf("Secret() with vault failing = %q, %v, want previous value", got, err)
+		}
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("vault requests after failure = %d, want 2", got)
+	}
+	clock.Add(vaultRetryInterval)
+	if _, err := source.Secret(context.Background()); err != nil {
+		t.Fatalf("Secret() error = %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("vault requests after retry interval = %d, want 3", got)
+	}
+
+	// Пока один вызов ждет зависший Vault, остальные сразу получают прежнее значение
+	mode.Store(vaultHang)
+	clock.Add(2 * time.Minute)
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		if _, err := source.Secret(context.Background()); err != nil {
+			t.Errorf("Secret() error = %v", err)
+		}
+	}()
+	for requests.Load() != 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	for range 5 {
+		if got, err := source.Secret(context.Background()); err != nil || got != "glpat" {
+			t.Fatalf("Secret() with vault hanging = %q, %v, want previous value", got, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Secret() with vault hanging took %v", elapsed)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("vault requests while hanging = %d, want 4", got)
+	}
+
+	mode.Store(vaultOK)
+	close(release)
+	<-refreshed
+}
+
+func TestVault_OutageWithoutValue(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"errors": ["Vault is sealed"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"data": {"token": "glpat"}}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	clock := &testClock{now: time.Now()}
+	source := NewVault(server.URL, Static("vault-token"), "secret", "exporter", "token")
+	source.now = clock.Now
+
+	// Без полученного значения ошибка возвращается без новых запросов до истечения паузы
+	for range 5 {
+		if _, err := source.Secret(context.Background()); err == nil {
+			t.Fatal("Secret() with vault sealed: expected an error")
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("vault requests during outage = %d, want 1", got)
+	}
+
+	failing.Store(false)
+	clock.Add(vaultRetryInterval)
+	if got, err := source.Secret(context.Background()); err != nil || got != "glpat" {
+		t.Errorf("Secret() after retry interval = %q, %v, want %q", got, err, "glpat")
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("vault requests after retry interval = %d, want 2", got)
+	}
+}
+
+func TestVault_CallerCancel(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		fmt.Fprint(w, `{"data": {"data": {"token": "glpat"}}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	source := NewVault(server.URL, Static("vault-token"), "secret", "exporter", "token")
+
+	// Первый вызов начинает обновление и отменяется, второй ждет то же обновление
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := source.Secret(ctx)
+		first <- err
+	}()
+	for requests.Load() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan string, 1)
+	go func() {
+		value, err := source.Secret(context.Background())
+		if err != nil {
+			t.Errorf("Secret() error = %v", err)
+		}
+		second <- value
+	}()
+
+	cancel()
+	if err := <-first; err == nil {
+		t.Error("canceled Secret(): expected an error")
+	}
+	close(release)
+	if got := <-second; got != "glpat" {
+		t.Errorf("Secret() = %q, want %q", got, "glpat")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("vault requests = %d, want 1", got)
+	}
+}
+
+// TestVault_DevServer проверяет чтение секрета из локального dev-сервера Vault:
+//
+//	vault server -dev -dev-root-token-id=root
+//	VAULT_ADDR=http://127.0.0.1:8200 VAULT_TOKEN=root go test ./internal/secret/ -run DevServer
+func TestVault_DevServer(t *testing.T) {
+	address, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if address == "" || token == "" {
+		t.Skip("VAULT_ADDR and VAULT_TOKEN are not set")
+	}
+
+	// Dev-сервер монтирует KV v2 в secret/
+	payload, _ := json.Marshal(map[string]any{"data": map[string]string{"token": "glpat-dev"}})
+	req, err := http.NewRequest(http.MethodPost, address+"/v1/secret/data/token-exporter-test", bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("write secret status = %d, want 200", resp.StatusCode)
+	}
+
+	source := NewVault(address, Static(token), "secret", "token-exporter-test", "token")
+	if got, err := source.Secret(context.Background()); err != nil || got != "glpat-dev" {
+		t.Errorf("Secret() = %q, %v, want %q", got, err, "glpat-dev")
+	}
+}